@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// findings listed in the job summary; the annotations carry all of them
+const actionSummaryLimit = 50
+
+// handles "gitguardian action", the entrypoint of the published GitHub
+// Action: inputs arrive as INPUT_* variables, pull requests and pushes are
+// scanned by the commits they bring, other events by the whole tree, and
+// the results become annotations, the job summary and step outputs
+func runActionCommand(args []string) error {
+	fs := flag.NewFlagSet("action", flag.ExitOnError)
+	var (
+		scanPath    = fs.String("path", actionInput("path", "."), "Repository to scan (input: path)")
+		configFile  = fs.String("config", actionInput("config", ""), "Configuration file path (input: config)")
+		failOn      = fs.String("fail-on", actionInput("fail-on", ""), "Only fail for issues at or above this severity (input: fail-on)")
+		onlySecrets = fs.Bool("secrets-only", actionBool("secrets-only"), "Only scan for secrets (input: secrets-only)")
+		fullScan    = fs.Bool("full", actionBool("full-scan"), "Scan the whole tree whatever the event (input: full-scan)")
+		sarifFile   = fs.String("sarif", actionInput("sarif-file", ""), "Also write SARIF results to this file (input: sarif-file)")
+	)
+	logging := addLogFlags(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		return configErrorf("invalid fail-on severity %q", cfg.FailOn)
+	}
+
+	scanType := scanner.ScanTypeAll
+	if *onlySecrets {
+		scanType = scanner.ScanTypeSecrets
+	}
+
+	root, err := filepath.Abs(*scanPath)
+	if err != nil {
+		return err
+	}
+
+	s := scanner.New(cfg)
+	var update *scanner.RefUpdate
+	if !*fullScan {
+		update = actionRange(root)
+	}
+
+	ctx, stop := timeout.context()
+	defer stop()
+
+	var results *scanner.Results
+	mode := "full"
+	if update != nil {
+		mode = "diff"
+		cfg.Log().Info("scanning commits", "range", shortCommit(update.OldRev)+".."+shortCommit(update.NewRev))
+		results, err = s.ScanPushRange(ctx, root, []scanner.RefUpdate{*update}, scanType)
+	} else {
+		results, err = s.ScanPath(ctx, root, scanType)
+	}
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	for _, issues := range [][]scanner.Issue{results.Issues, results.Duplicates} {
+		for i := range issues {
+			issues[i].File = scopeRelative(root, issues[i].File)
+		}
+	}
+
+	if err := results.OutputGitHub(os.Stdout); err != nil {
+		return err
+	}
+
+	outputs := map[string]string{
+		"scan-mode": mode,
+		"issues":    strconv.Itoa(results.Summary.Total),
+		"critical":  strconv.Itoa(results.Summary.Critical),
+		"high":      strconv.Itoa(results.Summary.High),
+		"medium":    strconv.Itoa(results.Summary.Medium),
+		"low":       strconv.Itoa(results.Summary.Low),
+	}
+	if *sarifFile != "" {
+		f, err := os.Create(*sarifFile)
+		if err != nil {
+			return fmt.Errorf("failed to write SARIF: %w", err)
+		}
+		err = results.OutputSARIF(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write SARIF: %w", err)
+		}
+		outputs["sarif-file"] = *sarifFile
+	}
+
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, func(w io.Writer) { writeJobSummary(w, results, mode, update) }); err != nil {
+			cfg.Log().Warn("failed to write job summary", "error", err)
+		}
+	}
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := appendFile(path, func(w io.Writer) {
+			for _, name := range []string{"scan-mode", "issues", "critical", "high", "medium", "low", "sarif-file"} {
+				if value, ok := outputs[name]; ok {
+					fmt.Fprintf(w, "%s=%s\n", name, value)
+				}
+			}
+		}); err != nil {
+			cfg.Log().Warn("failed to set outputs", "error", err)
+		}
+	}
+
+	if results.HasIssuesAtOrAbove(cfg.FailOn) {
+		os.Exit(exitFindings)
+	}
+	if results.Incomplete {
+		return fmt.Errorf("scan %s: the results are partial", timeout.reason(ctx))
+	}
+	return nil
+}
+
+// returns the commits the triggering event brings: a pull request's
+// commits not on its base branch, or the commits a push added. Nil means
+// the whole tree is scanned: other events, new branches, and histories
+// too shallow to hold the base, which is warned about.
+func actionRange(root string) *scanner.RefUpdate {
+	var event struct {
+		Before      string `json:"before"`
+		After       string `json:"after"`
+		PullRequest *struct {
+			Base struct {
+				SHA string `json:"sha"`
+			} `json:"base"`
+		} `json:"pull_request"`
+	}
+	if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &event)
+		}
+	}
+
+	head := firstNonEmpty(os.Getenv("GITHUB_SHA"), gitOutput(root, "rev-parse", "HEAD"))
+	var base string
+	switch {
+	case os.Getenv("GITHUB_BASE_REF") != "":
+		// the base branch tip, or the base the event recorded when the
+		// checkout did not fetch the branch
+		base = gitOutput(root, "rev-parse", "--verify", "--quiet", "origin/"+os.Getenv("GITHUB_BASE_REF")+"^{commit}")
+		if base == "" && event.PullRequest != nil {
+			base = event.PullRequest.Base.SHA
+		}
+	case event.Before != "" && strings.Trim(event.Before, "0") != "":
+		base = event.Before
+		head = firstNonEmpty(event.After, head)
+	default:
+		return nil
+	}
+
+	if base == "" || gitOutput(root, "cat-file", "-t", base) != "commit" {
+		fmt.Println("::warning title=GitGuardian::base commit not in the checkout, scanning the whole tree; use actions/checkout with fetch-depth: 0 to scan only the new commits")
+		return nil
+	}
+	return &scanner.RefUpdate{OldRev: base, NewRev: head, Ref: os.Getenv("GITHUB_REF")}
+}
+
+// renders the results as the markdown of a job summary
+func writeJobSummary(w io.Writer, results *scanner.Results, mode string, update *scanner.RefUpdate) {
+	fmt.Fprintf(w, "## GitGuardian\n\n")
+	if update != nil {
+		fmt.Fprintf(w, "Scanned %d commits (`%s..%s`).\n\n", results.CommitsScanned, shortCommit(update.OldRev), shortCommit(update.NewRev))
+	} else {
+		fmt.Fprintf(w, "Scanned %d files (%s scan).\n\n", results.FilesScanned, mode)
+	}
+
+	if len(results.Issues) == 0 {
+		fmt.Fprintf(w, ":white_check_mark: No security issues found.\n")
+		return
+	}
+
+	sum := results.Summary
+	fmt.Fprintf(w, "| Critical | High | Medium | Low | Total |\n|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(w, "| %d | %d | %d | %d | %d |\n\n", sum.Critical, sum.High, sum.Medium, sum.Low, sum.Total)
+
+	fmt.Fprintf(w, "| Severity | Rule | Location | Finding |\n|---|---|---|---|\n")
+	for i, issue := range results.Issues {
+		if i == actionSummaryLimit {
+			fmt.Fprintf(w, "\n%d more in the annotations.\n", len(results.Issues)-actionSummaryLimit)
+			break
+		}
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, issue.Line)
+		}
+		if issue.Commit != "" {
+			location += " @ " + shortCommit(issue.Commit)
+		}
+		finding := issue.Description
+		if issue.Content != "" {
+			finding += " `" + strings.ReplaceAll(issue.Content, "`", "'") + "`"
+		}
+		fmt.Fprintf(w, "| %s | %s | `%s` | %s |\n", strings.ToUpper(issue.Severity),
+			markdownCell(issue.Rule), location, markdownCell(finding))
+	}
+}
+
+// keeps a value from breaking out of its table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", "").Replace(s)
+}
+
+// reads an action input the way the runner passes it, e.g. fail-on as
+// INPUT_FAIL-ON
+func actionInput(name, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv("INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_")))); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func actionBool(name string) bool {
+	v, _ := strconv.ParseBool(actionInput(name, "false"))
+	return v
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// appends what write produces to a file the runner reads back
+func appendFile(path string, write func(w io.Writer)) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	write(f)
+	return f.Close()
+}
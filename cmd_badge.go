@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/badge"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian badge", which draws the status or risk badge of a
+// repository for READMEs and dashboards, from JSON results or from the
+// last scan the findings store recorded, without running a server
+func runBadgeCommand(args []string) error {
+	fs := flag.NewFlagSet("badge", flag.ExitOnError)
+	var (
+		scanPath   = fs.String("path", ".", "Repository whose last recorded scan the badge shows")
+		configFile = fs.String("config", "", "Configuration file path")
+		input      = fs.String("input", "", "Draw the badge of these JSON results instead")
+		kind       = fs.String("type", badge.KindStatus, "Badge: status (the last scan's findings) or risk")
+		branch     = fs.String("branch", "", "Branch whose last scan the badge shows (default: the last scan of any)")
+		format     = fs.String("format", "svg", "Output format: svg, json (shields.io endpoint)")
+		output     = fs.String("o", "", "Write the badge to this file instead of stdout")
+	)
+	logging := addLogFlags(fs)
+	storage := addStorageFlags(fs)
+	parseFlags(fs, args)
+
+	if *kind != badge.KindStatus && *kind != badge.KindRisk {
+		return configErrorf("unknown badge type %q: use status or risk", *kind)
+	}
+	if *format != "svg" && *format != "json" {
+		return configErrorf("unknown format %q: use svg or json", *format)
+	}
+
+	var b badge.Badge
+	if *input != "" {
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", *input, err)
+		}
+		var results scanner.Results
+		if err := json.Unmarshal(data, &results); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", *input, err)
+		}
+		if b, err = badge.FromResults(&results, *kind); err != nil {
+			return err
+		}
+	} else {
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			return configErrorf("failed to load configuration: %w", err)
+		}
+		storage.apply(cfg)
+		if err := logging.setup(cfg); err != nil {
+			return configError{err}
+		}
+		store, err := openFindings(cfg)
+		if err != nil {
+			return err
+		}
+		scope, err := filepath.Abs(*scanPath)
+		if err != nil {
+			scope = *scanPath
+		}
+		if b, err = badge.FromStore(store, scope, *branch, *kind, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	var data []byte
+	switch *format {
+	case "svg":
+		data = []byte(b.SVG())
+	case "json":
+		var err error
+		if data, err = json.MarshalIndent(b.Shields(), "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	return nil
+}
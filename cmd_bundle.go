@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/bundle"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// where an exported bundle keeps what it carries
+const (
+	bundleRulesDir    = "rules"
+	bundleGitleaksDir = "gitleaks"
+	bundleOSVDir      = "osv"
+	bundleFeedsDir    = "feeds"
+	bundleExploitsDir = "exploits"
+)
+
+// handles "gitguardian bundle export" and "gitguardian bundle import"
+func runBundleCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			return runBundleExport(args[1:])
+		case "import":
+			return runBundleImport(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: gitguardian bundle export [-config file] [-osv ecosystems] [-kev url] [-epss url] [-o file]")
+	fmt.Fprintln(os.Stderr, "       gitguardian bundle import [-dir dir] bundle.tar.gz")
+	return configErrorf("unknown bundle command")
+}
+
+// handles "gitguardian bundle export", which packs the configuration, its
+// rule packs and gitleaks rules, its offline advisory database and
+// advisory feed files, the OSV advisories of the ecosystems given, and
+// the KEV catalog and EPSS scores, into one tarball for a network without internet access. The bundled
+// configuration points at the bundled files and turns off the online
+// lookups; every credential is left out of it: API keys and tokens, the
+// secret hash key, notification sinks, whose URLs are secrets themselves,
+// and the password of a cache URL.
+func runBundleExport(args []string) error {
+	fs := flag.NewFlagSet("bundle export", flag.ExitOnError)
+	var (
+		configFile = fs.String("config", "", "Configuration file path")
+		outFile    = fs.String("o", "gitguardian-bundle.tar.gz", "Bundle to write")
+		ecosystems = fs.String("osv", "", "Comma-separated OSV ecosystems to download every advisory of, e.g. npm,PyPI,Go")
+		mirror     = fs.String("osv-mirror", bundle.DefaultOSVMirror, "Where OSV advisories are downloaded from, as <mirror>/<ecosystem>/all.zip")
+		kevURL     = fs.String("kev", bundle.DefaultKEVURL, "Where the CISA KEV catalog is downloaded from, unless kev_file is set; empty to leave it out")
+		epssURL    = fs.String("epss", bundle.DefaultEPSSURL, "Where the EPSS scores are downloaded from, unless epss_file is set; empty to leave them out")
+	)
+	logging := addLogFlags(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	logger := cfg.Log()
+
+	settings := map[string]json.RawMessage{}
+	configDir := ""
+	if files := cfg.Files(); len(files) > 0 && !config.IsLegacyFile(files[0]) {
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			return configErrorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return configErrorf("failed to parse config file: %w", err)
+		}
+		configDir = filepath.Dir(files[0])
+	}
+	deps := map[string]json.RawMessage{}
+	if raw, ok := settings["dependency_apis"]; ok {
+		if err := json.Unmarshal(raw, &deps); err != nil {
+			return configErrorf("failed to parse dependency_apis: %w", err)
+		}
+	}
+
+	out, err := os.Create(*outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	ok := false
+	defer func() {
+		out.Close()
+		if !ok {
+			os.Remove(*outFile)
+		}
+	}()
+	w := bundle.NewWriter(out)
+
+	// rule packs keep their order, which rule_files globs sort by
+	gitleaks := cfg.GitleaksRules
+	if gitleaks != "" && !filepath.IsAbs(gitleaks) {
+		gitleaks = filepath.Join(configDir, gitleaks)
+	}
+	packs := 0
+	for i, file := range cfg.Files() {
+		if i == 0 || config.IsLegacyFile(file) {
+			continue
+		}
+		name := path.Join(bundleGitleaksDir, filepath.Base(file))
+		if file != gitleaks {
+			packs++
+			name = path.Join(bundleRulesDir, fmt.Sprintf("%03d-%s", packs, filepath.Base(file)))
+		}
+		if err := w.AddFile(name, file); err != nil {
+			return fmt.Errorf("failed to bundle %s: %w", file, err)
+		}
+		if file == gitleaks {
+			settings["gitleaks_rules"], _ = json.Marshal(name)
+		}
+	}
+	if packs > 0 {
+		settings["rule_files"], _ = json.Marshal([]string{bundleRulesDir + "/*"})
+	}
+
+	advisories := 0
+	if cfg.DependencyAPIs.OfflineDB != "" {
+		n, err := w.AddTree(path.Join(bundleOSVDir, "local"), cfg.DependencyAPIs.OfflineDB, ".json")
+		if err != nil {
+			return fmt.Errorf("failed to bundle offline_db: %w", err)
+		}
+		advisories += n
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *ecosystems != "" {
+		for _, ecosystem := range strings.Split(*ecosystems, ",") {
+			if ecosystem = strings.TrimSpace(ecosystem); ecosystem == "" {
+				continue
+			}
+			n, err := w.AddOSVEcosystem(ctx, http.DefaultClient, *mirror, ecosystem, path.Join(bundleOSVDir, ecosystem))
+			if err != nil {
+				return err
+			}
+			logger.Info("bundled OSV advisories", "ecosystem", ecosystem, "advisories", n)
+			advisories += n
+		}
+	}
+	if advisories > 0 {
+		deps["offline_db"], _ = json.Marshal(bundleOSVDir)
+	} else {
+		logger.Warn("no advisories bundled: dependencies are not checked for vulnerabilities offline; add some with -osv or offline_db")
+	}
+
+	// the exploitation data: the configured files, or the current ones
+	exploits := []struct {
+		setting, file, url, name string
+	}{
+		{"kev_file", cfg.DependencyAPIs.KEVFile, *kevURL, "kev.json"},
+		{"epss_file", cfg.DependencyAPIs.EPSSFile, *epssURL, "epss.csv"},
+	}
+	for _, e := range exploits {
+		var err error
+		switch {
+		case e.file != "":
+			e.name = path.Join(bundleExploitsDir, filepath.Base(e.file))
+			err = w.AddFile(e.name, e.file)
+		case e.url != "":
+			if strings.HasSuffix(e.url, ".gz") {
+				e.name += ".gz"
+			}
+			e.name = path.Join(bundleExploitsDir, e.name)
+			err = w.AddURL(ctx, http.DefaultClient, e.url, e.name)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to bundle %s: %w", e.setting, err)
+		}
+		deps[e.setting], _ = json.Marshal(e.name)
+	}
+
+	// feeds from a URL are left as they are, for mirrors inside the network
+	feeds := cfg.DependencyAPIs.AdvisoryFeeds
+	for i := range feeds {
+		if feeds[i].Path == "" {
+			logger.Warn("advisory feed not bundled, as it is fetched from a URL", "url", feeds[i].URL)
+			continue
+		}
+		name := path.Join(bundleFeedsDir, fmt.Sprint(i+1))
+		if _, err := w.AddTree(name, feeds[i].Path, ".json"); err != nil {
+			return fmt.Errorf("failed to bundle advisory feed: %w", err)
+		}
+		if info, err := os.Stat(feeds[i].Path); err == nil && !info.IsDir() {
+			name = path.Join(name, filepath.Base(feeds[i].Path))
+		}
+		feeds[i].Path = name
+	}
+	for i := range feeds {
+		feeds[i].Token = ""
+	}
+	if len(feeds) > 0 {
+		deps["advisory_feeds"], _ = json.Marshal(feeds)
+	}
+
+	deps["osv_enabled"] = json.RawMessage("false")
+	settings["dependency_apis"], _ = json.Marshal(deps)
+	if err := stripCredentials(settings, logger); err != nil {
+		return configErrorf("failed to parse config file: %w", err)
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := w.AddBytes(bundle.ConfigName, append(data, '\n')); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	ok = true
+	logger.Info("bundle written", "file", *outFile, "files", len(w.Files()), "rule_packs", packs, "advisories", advisories)
+	return nil
+}
+
+// removes every credential from the settings of a bundled configuration
+func stripCredentials(settings map[string]json.RawMessage, logger *slog.Logger) error {
+	delete(settings, "secret_hash_key")
+
+	sections := make(map[string]map[string]json.RawMessage)
+	for _, name := range []string{"dependency_apis", "notify", "feedback", "cache"} {
+		raw, ok := settings[name]
+		if !ok {
+			continue
+		}
+		section := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &section); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		sections[name] = section
+	}
+
+	if deps := sections["dependency_apis"]; deps != nil {
+		delete(deps, "snyk_api_key")
+		delete(deps, "github_token")
+		if raw, ok := deps["private_packages"]; ok {
+			var packages []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &packages); err != nil {
+				return fmt.Errorf("private_packages: %w", err)
+			}
+			for _, p := range packages {
+				delete(p, "token")
+			}
+			deps["private_packages"], _ = json.Marshal(packages)
+		}
+	}
+	if notify := sections["notify"]; notify != nil {
+		if _, ok := notify["sinks"]; ok {
+			logger.Warn("notification sinks not bundled, as their URLs are credentials")
+			delete(notify, "sinks")
+		}
+	}
+	if feedback := sections["feedback"]; feedback != nil {
+		delete(feedback, "token")
+	}
+	if cache := sections["cache"]; cache != nil {
+		delete(cache, "token")
+		if raw, ok := cache["url"]; ok {
+			var address string
+			if err := json.Unmarshal(raw, &address); err != nil {
+				return fmt.Errorf("cache url: %w", err)
+			}
+			if u, err := url.Parse(address); err == nil && u.User != nil {
+				u.User = url.User(u.User.Username())
+				cache["url"], _ = json.Marshal(u.String())
+			}
+		}
+	}
+
+	for name, section := range sections {
+		settings[name], _ = json.Marshal(section)
+	}
+	return nil
+}
+
+// handles "gitguardian bundle import", which checks a bundle against its
+// checksums and unpacks it, replacing an earlier import in the same
+// directory; scans then use its configuration with -config
+func runBundleImport(args []string) error {
+	fs := flag.NewFlagSet("bundle import", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory to unpack the bundle in (default: the bundle's name without .tar.gz)")
+	logging := addLogFlags(fs)
+	parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		return configErrorf("usage: gitguardian bundle import [-dir dir] bundle.tar.gz")
+	}
+	file := fs.Arg(0)
+	cfg := config.DefaultConfig()
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	logger := cfg.Log()
+
+	if *dir == "" {
+		*dir = strings.TrimSuffix(strings.TrimSuffix(file, ".tgz"), ".tar.gz")
+		if *dir == file {
+			return configErrorf("-dir is required for %s", file)
+		}
+	}
+	target, err := filepath.Abs(*dir)
+	if err != nil {
+		return configError{err}
+	}
+	if _, err := os.Stat(target); err == nil {
+		if _, err := os.Stat(filepath.Join(target, bundle.ManifestName)); err != nil {
+			return configErrorf("%s exists and is not an imported bundle", target)
+		}
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer in.Close()
+
+	// unpacked next to the target first, so a bundle that fails its
+	// checksums leaves an earlier import in place
+	staging := fmt.Sprintf("%s.import-%d", target, os.Getpid())
+	manifest, err := bundle.Extract(in, staging)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	// the offline database is read relative to the working directory, so
+	// it has to be absolute
+	if err := pointOfflineDB(filepath.Join(staging, bundle.ConfigName), filepath.Join(target, bundleOSVDir)); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return fmt.Errorf("failed to import bundle: %w", err)
+	}
+
+	logger.Info("bundle imported", "dir", target, "files", len(manifest.Files), "created", manifest.Created)
+	fmt.Printf("Scan with: gitguardian -config %s\n", filepath.Join(target, bundle.ConfigName))
+	return nil
+}
+
+// sets dependency_apis.offline_db of a bundled configuration to dir, when
+// it has one
+func pointOfflineDB(configPath, dir string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+	var settings, deps map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("invalid bundle configuration: %w", err)
+	}
+	if err := json.Unmarshal(settings["dependency_apis"], &deps); err != nil || deps["offline_db"] == nil {
+		return nil
+	}
+	deps["offline_db"], _ = json.Marshal(dir)
+	settings["dependency_apis"], _ = json.Marshal(deps)
+	if data, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, append(data, '\n'), 0644)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian config validate|convert"
+func runConfigCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return runConfigValidate(args[1:])
+		case "convert":
+			return runConfigConvert(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: gitguardian config validate [-config file]")
+	fmt.Fprintln(os.Stderr, "       gitguardian config convert -to json|yaml [-config file] [-legacy .gitguardian.yml] [-o file]")
+	return configErrorf("unknown config command")
+}
+
+// handles "gitguardian config validate", which loads the configuration
+// (compiling and safety-checking every pattern) and runs each rule over
+// its positive and negative examples; run it in CI next to rule changes
+func runConfigValidate(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	configFile := fs.String("config", "", "Configuration file path")
+	output := addASCIIFlag(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return err
+	}
+
+	out := output.writer(os.Stdout)
+	failures, checked := scanner.CheckExamples(cfg)
+	for _, f := range failures {
+		if f.Positive {
+			fmt.Fprintf(out, ascii.Label(out, "❌ %s: does not match positive example %q\n"), f.Rule, f.Example)
+		} else {
+			fmt.Fprintf(out, ascii.Label(out, "❌ %s: matches negative example %q\n"), f.Rule, f.Example)
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d rule examples failed", len(failures), checked)
+	}
+
+	fmt.Fprintf(out, ascii.Label(out, "✅ %d rules valid, %d examples passed\n"), len(cfg.SecretPatterns), checked)
+	return nil
+}
+
+// handles "gitguardian config convert", which migrates between the legacy
+// .gitguardian.yml and the JSON configuration. -to json writes the JSON
+// configuration with the legacy ignore lists merged into disabled_rules
+// and exclude_paths; -to yaml writes those two lists, merged the same way,
+// as a legacy file. Load applies both files, so either output keeps every
+// rule and path ignored that was before.
+func runConfigConvert(args []string) error {
+	fs := flag.NewFlagSet("config convert", flag.ExitOnError)
+	var (
+		to         = fs.String("to", "", "Format to convert to: json or yaml")
+		configFile = fs.String("config", "", "JSON configuration file (default: the one scans load)")
+		legacyFile = fs.String("legacy", "", "Legacy configuration file (default: .gitguardian.yml)")
+		outFile    = fs.String("o", "", "Write the result to this file instead of stdout")
+	)
+	parseFlags(fs, args)
+
+	if *to != "json" && *to != "yaml" {
+		return configErrorf("unknown format %q: use -to json or -to yaml", *to)
+	}
+	if *configFile == "" {
+		*configFile = config.FindFile()
+	}
+	if *legacyFile == "" {
+		*legacyFile = config.FindLegacy(".")
+	}
+	if *configFile == "" && *legacyFile == "" {
+		return configErrorf("no configuration to convert: pass -config or -legacy")
+	}
+
+	// the JSON is kept as written, so defaults are not spelled out and
+	// settings this version does not know survive
+	settings := make(map[string]interface{})
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return configErrorf("failed to parse config file: %w", err)
+		}
+	}
+	merged := &config.Config{
+		DisabledRules: jsonStrings(settings["disabled_rules"]),
+		ExcludePaths:  jsonStrings(settings["exclude_paths"]),
+	}
+	if *legacyFile != "" {
+		legacy, err := config.LoadLegacy(*legacyFile)
+		if err != nil {
+			return configError{err}
+		}
+		merged.MergeLegacy(legacy)
+	}
+
+	var out []byte
+	switch *to {
+	case "json":
+		if len(merged.DisabledRules) > 0 {
+			settings["disabled_rules"] = merged.DisabledRules
+		}
+		if len(merged.ExcludePaths) > 0 {
+			settings["exclude_paths"] = merged.ExcludePaths
+		}
+		data, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return err
+		}
+		out = append(data, '\n')
+	case "yaml":
+		legacy := &config.LegacyConfig{IgnoreRules: merged.DisabledRules, IgnorePaths: merged.ExcludePaths}
+		out = []byte(legacy.YAML())
+		var rest []string
+		for key := range settings {
+			if key != "disabled_rules" && key != "exclude_paths" {
+				rest = append(rest, key)
+			}
+		}
+		if len(rest) > 0 {
+			sort.Strings(rest)
+			fmt.Fprintf(os.Stderr, "Note: %s cannot be written to a legacy file; keep %s for them, both are loaded\n",
+				strings.Join(rest, ", "), *configFile)
+		}
+	}
+
+	if *outFile == "" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(*outFile, out, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *outFile, err)
+	}
+	return nil
+}
+
+// the strings of a JSON list
+func jsonStrings(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/feedback"
+	"github.com/JohnnyCannelloni/gitguardian/internal/findings"
+)
+
+// handles "gitguardian feedback", which records a triage decision about a
+// finding in the findings store and, when "feedback.endpoint" is set,
+// sends an anonymized report of it for rule tuning
+func runFeedbackCommand(args []string) error {
+	fs := flag.NewFlagSet("feedback", flag.ExitOnError)
+	var (
+		configFile  = fs.String("config", "", "Configuration file path")
+		fingerprint = fs.String("fingerprint", "", "ID of the finding (\"id\" in JSON), or an unambiguous prefix of it")
+		scanPath    = fs.String("path", "", "Repository the finding is in, when its ID is found in several")
+		verdict     = fs.String("verdict", findings.FalsePositive, "Decision: "+findings.FalsePositive+" or "+findings.TruePositive)
+		reason      = fs.String("reason", "", "Why, e.g. \"build hash\"")
+		by          = fs.String("by", "", "Who decided (default: git user.email, or $USER)")
+		noSubmit    = fs.Bool("no-submit", false, "Only record the decision, even with feedback.endpoint set")
+	)
+	storage := addStorageFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian feedback -fingerprint <fingerprint> [-verdict false-positive|true-positive] [-reason text]")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	if *fingerprint == "" {
+		fs.Usage()
+		return configErrorf("missing -fingerprint")
+	}
+	if *verdict != findings.FalsePositive && *verdict != findings.TruePositive {
+		return configErrorf("invalid verdict %q: use %s or %s", *verdict, findings.FalsePositive, findings.TruePositive)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	storage.apply(cfg)
+	if cfg.NoWrite {
+		return fmt.Errorf("cannot record feedback with no_write set")
+	}
+
+	store, err := openFindings(cfg)
+	if err != nil {
+		return err
+	}
+	scope := ""
+	if *scanPath != "" {
+		if scope, err = filepath.Abs(*scanPath); err != nil {
+			return configErrorf("invalid -path: %w", err)
+		}
+	}
+	matches := store.Match(strings.ToLower(*fingerprint), scope)
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("no finding with fingerprint %s in the findings store; scan with \"findings\": {\"enabled\": true} first", *fingerprint)
+	case len(matches) > 1:
+		return fmt.Errorf("fingerprint %s is ambiguous: %d findings match; give more of it, or -path", *fingerprint, len(matches))
+	}
+	record := matches[0]
+
+	if *by == "" {
+		*by = firstNonEmpty(gitOutput(".", "config", "user.email"), os.Getenv("USER"))
+	}
+	triage := findings.Triage{Verdict: *verdict, Reason: *reason, By: *by, At: time.Now()}
+	if err := store.SetTriage(record.Fingerprint, triage); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded %s: %s in %s\n", *verdict, record.Rule, record.File)
+
+	if cfg.Feedback.Endpoint == "" || *noSubmit {
+		return nil
+	}
+	record.Triage = &triage
+	if err := feedback.Submit(context.Background(), cfg.Feedback.Endpoint, cfg.Feedback.Token, feedback.NewReport(record)); err != nil {
+		return fmt.Errorf("decision recorded, but sending it failed: %w", err)
+	}
+	fmt.Println("Sent anonymized feedback")
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian history", scanning every commit reachable from a branch
+func runHistoryCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var (
+		repoPath   = fs.String("path", ".", "Repository to scan")
+		configFile = fs.String("config", "", "Configuration file path")
+		branch     = fs.String("branch", "", "Branch or ref to walk (default HEAD)")
+		since      = fs.String("since", "", "Only commits more recent than this date (e.g. 2024-01-01, '2 weeks ago')")
+		maxCommits = fs.Int("max-commits", 0, "Maximum number of commits to scan (0 = all)")
+		format     = fs.String("format", "text", "Output format")
+		verbose    = fs.Bool("verbose", false, "Verbose output")
+		failOn     = fs.String("fail-on", "", "Only exit non-zero for issues at or above this severity")
+		profile    = fs.Bool("profile-rules", false, "Time every secret rule and file and print the slowest to stderr")
+		exitZero   = fs.Bool("exit-zero", false, "Exit 0 even when issues are found; scan errors still fail")
+	)
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if *verbose {
+		cfg.Verbose = true
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		return configError{err}
+	}
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		return configErrorf("invalid fail-on severity %q", cfg.FailOn)
+	}
+
+	if _, err := report.Get(*format); err != nil {
+		return configError{err}
+	}
+
+	s := scanner.New(cfg)
+	s.SetProfiling(*profile)
+	ctx, stop := timeout.context()
+	defer stop()
+	results, err := s.ScanHistory(ctx, *repoPath, scanner.ScanTypeSecrets, scanner.HistoryOptions{
+		Branch:     *branch,
+		Since:      *since,
+		MaxCommits: *maxCommits,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(output.reportWriter(os.Stdout, *format), *format, results); err != nil {
+		return err
+	}
+	if results.Profile != nil {
+		results.Profile.Write(output.writer(os.Stderr), 10)
+	}
+
+	if results.HasIssuesAtOrAbove(cfg.FailOn) && !*exitZero {
+		os.Exit(exitFindings)
+	}
+	if results.Incomplete {
+		return fmt.Errorf("scan %s: the results are partial", timeout.reason(ctx))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/hooks"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian hook run <hook> [args]", the entry point the
+// installed hook scripts and the pre-commit framework call into,
+// "gitguardian hook script <hook>", which prints a hook script, e.g.
+// pre-receive for a git server, and "gitguardian hook export-precommit",
+// which prints the hooks for a .pre-commit-hooks.yaml
+func runHookCommand(args []string) error {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	configFile := fs.String("config", "", "Configuration file path")
+	binary := fs.String("bin", "", "Scanner binary the printed script runs (default: gitguardian from PATH)")
+	outFile := fs.String("o", "", "Write the script to this file, executable, instead of printing it; PowerShell's > would change its encoding")
+	language := fs.String("language", "golang", "With export-precommit, how pre-commit gets the scanner: golang builds it, system finds it on the PATH")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian hook [-config file] run commit-msg <message-file>")
+		fmt.Fprintln(fs.Output(), "       gitguardian hook [-config file] run pre-commit [file...]")
+		fmt.Fprintln(fs.Output(), "       gitguardian hook [-bin path] [-o file] script <pre-commit|pre-push|commit-msg|pre-receive>")
+		fmt.Fprintln(fs.Output(), "       gitguardian hook [-language golang|system] [-o file] export-precommit")
+		fs.PrintDefaults()
+	}
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args)
+
+	if fs.NArg() == 2 && fs.Arg(0) == "script" {
+		script := hooks.GenerateHookScript(fs.Arg(1), *binary, *output.value)
+		if script == "" {
+			return configErrorf("unsupported hook: %s", fs.Arg(1))
+		}
+		if *outFile != "" {
+			return os.WriteFile(*outFile, []byte(script), 0755)
+		}
+		fmt.Print(script)
+		return nil
+	}
+
+	if fs.NArg() == 1 && fs.Arg(0) == "export-precommit" {
+		if *language != "golang" && *language != "system" {
+			return configErrorf("unknown language %q: use golang or system", *language)
+		}
+		manifest := hooks.PreCommitManifest(*language)
+		if *outFile != "" {
+			return os.WriteFile(*outFile, []byte(manifest), 0644)
+		}
+		fmt.Print(manifest)
+		return nil
+	}
+
+	if fs.NArg() < 2 || fs.Arg(0) != "run" {
+		fs.Usage()
+		return configErrorf("missing hook to run")
+	}
+	hook := fs.Arg(1)
+	// the pre-commit framework appends the args a hook is configured
+	// with, then the file names, to its entry
+	parseFlags(fs, fs.Args()[2:])
+	hookArgs := fs.Args()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		return configError{err}
+	}
+
+	switch hook {
+	case "commit-msg":
+		if len(hookArgs) < 1 {
+			return configErrorf("usage: gitguardian hook run commit-msg <message-file>")
+		}
+		ctx, stop := timeout.context()
+		defer stop()
+		ok, err := hooks.RunCommitMsg(ctx, cfg, hookArgs[0], output.writer(os.Stderr))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			os.Exit(exitFindings)
+		}
+		return nil
+
+	case "pre-commit":
+		ctx, stop := timeout.context()
+		defer stop()
+		s := scanner.New(cfg)
+		var results *scanner.Results
+		if len(hookArgs) > 0 {
+			// the pre-commit framework passes the staged files, with the
+			// changes left unstaged stashed away, or every file with
+			// --all-files; either way they are read from disk
+			results = s.ScanChangedFiles(ctx, ".", hookArgs, scanner.ScanTypeAll)
+		} else if results, err = s.ScanStaged(ctx, ".", scanner.ScanTypeAll); err != nil {
+			return err
+		}
+		if err := report.Write(output.reportWriter(os.Stdout, "text"), "text", results); err != nil {
+			return err
+		}
+		if results.HasIssuesAtOrAbove(cfg.FailOn) {
+			os.Exit(exitFindings)
+		}
+		if results.Incomplete {
+			return fmt.Errorf("scan %s: the results are partial", timeout.reason(ctx))
+		}
+		return nil
+
+	default:
+		return configErrorf("unsupported hook: %s", hook)
+	}
+}
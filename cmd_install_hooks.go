@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/hooks"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+)
+
+// handles "gitguardian install-hooks", which installs the hooks in one
+// repository, as -install-hooks does, or with -global in every repository
+// of the user; -uninstall takes them out again
+func runInstallHooksCommand(args []string) error {
+	fs := flag.NewFlagSet("install-hooks", flag.ExitOnError)
+	var (
+		repoPath   = fs.String("path", ".", "Repository to install the hooks in")
+		configFile = fs.String("config", "", "Configuration file path")
+		global     = fs.Bool("global", false, "Install the hooks for every repository, through the global core.hooksPath")
+		template   = fs.Bool("template", false, "With -global, use init.templateDir, so only repositories cloned or initialized from now on get the hooks")
+		uninstall  = fs.Bool("uninstall", false, "Remove the hooks, restoring the global settings -global replaced")
+	)
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	parseFlags(fs, args)
+
+	if *template && !*global {
+		return configErrorf("-template needs -global")
+	}
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		return configError{err}
+	}
+	if cfg.NoWrite {
+		return configErrorf("cannot change hooks with no_write set")
+	}
+	logger := cfg.Log()
+
+	// the hooks print to whatever terminal commits are made from, so only
+	// an explicit -ascii applies to them
+	switch {
+	case *global && *uninstall:
+		err = hooks.UninstallGlobal(logger)
+	case *global:
+		err = hooks.InstallGlobal(*template, *output.value, logger)
+	case *uninstall:
+		err = hooks.Uninstall(*repoPath, logger)
+	default:
+		err = hooks.Install(*repoPath, *output.value, logger)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to change hooks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/notify"
+)
+
+// handles "gitguardian notify status|flush|purge", which shows and
+// retries the notifications sinks did not take, e.g. from a cron job
+// after an outage
+func runNotifyCommand(args []string) error {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	configFile := fs.String("config", "", "Configuration file path")
+	dead := fs.Bool("dead", false, "With flush, also retry the dead letters")
+	logging := addLogFlags(fs)
+	storage := addStorageFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian notify [flags] status | flush | purge")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return configErrorf("missing notify command")
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	storage.apply(cfg)
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	sinks, err := notify.NewSinks(cfg.Notify)
+	if err != nil {
+		return configErrorf("invalid notification settings: %w", err)
+	}
+
+	queue, err := openNotifyQueue(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "status":
+		fmt.Printf("Pending: %d\n", len(queue.Pending()))
+		for _, d := range queue.Pending() {
+			printDelivery(d)
+		}
+		fmt.Printf("Dead letters: %d\n", len(queue.Dead()))
+		for _, d := range queue.Dead() {
+			printDelivery(d)
+		}
+		return nil
+
+	case "flush":
+		if cfg.NoWrite {
+			return fmt.Errorf("cannot flush with no_write set")
+		}
+		pending := len(queue.Pending())
+		now := time.Now()
+		// every error Flush returns is a delivery it buried
+		errs := queue.Flush(context.Background(), sinks, now)
+		fmt.Printf("Delivered %d of %d pending notifications, %d moved to the dead letters\n",
+			pending-len(queue.Pending())-len(errs), pending, len(errs))
+		if *dead {
+			letters := len(queue.Dead()) - len(errs)
+			failed := queue.RetryDead(context.Background(), sinks, now)
+			fmt.Printf("Delivered %d of %d dead letters\n", letters-len(failed), letters)
+			errs = append(errs, failed...)
+		}
+		if err := queue.Save(); err != nil {
+			return err
+		}
+		for _, err := range errs {
+			cfg.Log().Warn("notification failed", "error", err)
+		}
+		if len(queue.Pending()) > 0 || len(errs) > 0 {
+			return fmt.Errorf("%d pending, %d dead letters", len(queue.Pending()), len(queue.Dead()))
+		}
+		return nil
+
+	case "purge":
+		if cfg.NoWrite {
+			return fmt.Errorf("cannot purge with no_write set")
+		}
+		removed := queue.Purge()
+		if err := queue.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d dead letters\n", removed)
+		return nil
+
+	default:
+		fs.Usage()
+		return configErrorf("unknown notify command: %s", fs.Arg(0))
+	}
+}
+
+// opens the notification queue: notify.queue.path, or notify-queue.json
+// in state_dir or the cache directory
+func openNotifyQueue(cfg *config.Config) (*notify.Queue, error) {
+	path := cfg.Notify.Queue.Path
+	if path == "" && cfg.StateDir != "" {
+		path = filepath.Join(cfg.StateDir, "notify-queue.json")
+	}
+	if path == "" {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "notify-queue.json")
+	}
+	return notify.OpenQueue(path, cfg.Notify.Queue)
+}
+
+func printDelivery(d notify.Delivery) {
+	fmt.Printf("  %-8s %s  %d attempt(s)  %s\n", d.Sink, d.Created.Format(time.RFC3339), d.Attempts, d.LastError)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian scan-push-range", which reads the "<old> <new>
+// <ref>" lines of a push from stdin and scans every commit they add; the
+// pre-receive hook runs it so a git server can reject pushed secrets
+func runPushRangeCommand(args []string) error {
+	fs := flag.NewFlagSet("scan-push-range", flag.ExitOnError)
+	var (
+		repoPath    = fs.String("path", ".", "Repository receiving the push")
+		configFile  = fs.String("config", "", "Configuration file path")
+		format      = fs.String("format", "text", "Output format")
+		onlySecrets = fs.Bool("secrets-only", false, "Only scan for secrets")
+		failOn      = fs.String("fail-on", "", "Only reject the push for issues at or above this severity")
+		signatures  = fs.Bool("check-signatures", false, "Also require every pushed commit to be signed by an allowed key")
+		binaries    = fs.Bool("check-binaries", false, "Also report executables, archives and large binaries the commits add")
+	)
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		return configError{err}
+	}
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if *signatures {
+		cfg.Signatures.Enabled = true
+	}
+	if *binaries {
+		cfg.Binaries.Enabled = true
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		return configErrorf("invalid fail-on severity %q", cfg.FailOn)
+	}
+
+	if _, err := report.Get(*format); err != nil {
+		return configError{err}
+	}
+
+	updates, err := scanner.ParseRefUpdates(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	scanType := scanner.ScanTypeAll
+	if *onlySecrets {
+		scanType = scanner.ScanTypeSecrets
+	}
+
+	ctx, stop := timeout.context()
+	defer stop()
+	results, err := scanner.New(cfg).ScanPushRange(ctx, *repoPath, updates, scanType)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(output.reportWriter(os.Stdout, *format), *format, results); err != nil {
+		return err
+	}
+
+	if results.HasIssuesAtOrAbove(cfg.FailOn) {
+		i18n.Fprint(output.writer(os.Stderr), "GitGuardian: push rejected, fix the issues above in the pushed commits and push again\n")
+		os.Exit(exitFindings)
+	}
+	// commits the scan did not reach are not let through unchecked
+	if results.Incomplete {
+		return fmt.Errorf("scan %s: the results are partial", timeout.reason(ctx))
+	}
+	return nil
+}
+
+// handles "gitguardian scan-range", which scans the lines the commits of
+// one or more <old>..<new> ranges add, or with -pre-push those of the push
+// git describes on stdin; the pre-push hook runs it
+func runScanRangeCommand(args []string) error {
+	fs := flag.NewFlagSet("scan-range", flag.ExitOnError)
+	var (
+		repoPath    = fs.String("path", ".", "Repository to scan")
+		configFile  = fs.String("config", "", "Configuration file path")
+		format      = fs.String("format", "text", "Output format")
+		onlySecrets = fs.Bool("secrets-only", false, "Only scan for secrets")
+		failOn      = fs.String("fail-on", "", "Only fail for issues at or above this severity")
+		prePush     = fs.Bool("pre-push", false, "Read the ref updates of a push from stdin, as git passes them to a pre-push hook")
+		exitZero    = fs.Bool("exit-zero", false, "Exit 0 even when issues are found; scan errors still fail")
+		binaries    = fs.Bool("check-binaries", false, "Also report executables, archives and large binaries the commits add")
+	)
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	timeout := addTimeoutFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian scan-range [flags] <old>..<new> [<old>..<new> ...]")
+		fmt.Fprintln(fs.Output(), "       gitguardian scan-range [flags] -pre-push < <pre-push hook input>")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	var updates []scanner.RefUpdate
+	for _, spec := range fs.Args() {
+		u, err := scanner.ParseRange(spec)
+		if err != nil {
+			return configError{err}
+		}
+		updates = append(updates, u)
+	}
+	if *prePush {
+		pushed, err := scanner.ParsePrePushUpdates(os.Stdin)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, pushed...)
+	} else if len(updates) == 0 {
+		fs.Usage()
+		return configErrorf("missing range")
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		return configError{err}
+	}
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if *binaries {
+		cfg.Binaries.Enabled = true
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		return configErrorf("invalid fail-on severity %q", cfg.FailOn)
+	}
+
+	if _, err := report.Get(*format); err != nil {
+		return configError{err}
+	}
+
+	scanType := scanner.ScanTypeAll
+	if *onlySecrets {
+		scanType = scanner.ScanTypeSecrets
+	}
+
+	ctx, stop := timeout.context()
+	defer stop()
+	results, err := scanner.New(cfg).ScanRange(ctx, *repoPath, updates, scanType)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(output.reportWriter(os.Stdout, *format), *format, results); err != nil {
+		return err
+	}
+
+	if results.HasIssuesAtOrAbove(cfg.FailOn) && !*exitZero {
+		os.Exit(exitFindings)
+	}
+	if results.Incomplete {
+		return fmt.Errorf("scan %s: the results are partial", timeout.reason(ctx))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// one entry's outcome: pass, fail, or incomplete when the scan was cut
+// short before it could vouch for the entry
+type queueVerdict struct {
+	scanner.QueueResult
+	Verdict string `json:"verdict"`
+}
+
+// handles "gitguardian queue", which checks a merge queue batch: the pull
+// request heads given as arguments, or as a JSON list with -input, are
+// scanned together in one process and each gets its own verdict
+func runQueueCommand(args []string) error {
+	fs := flag.NewFlagSet("queue", flag.ExitOnError)
+	var (
+		repoPath    = fs.String("path", ".", "Repository holding the queued commits")
+		configFile  = fs.String("config", "", "Configuration file path")
+		base        = fs.String("base", "", "Branch the entries merge into, for entries that do not name their own")
+		input       = fs.String("input", "", "JSON list of entries, [{\"id\", \"head\", \"base\"}]; - reads stdin")
+		format      = fs.String("format", "text", "Output format (text, json)")
+		onlySecrets = fs.Bool("secrets-only", false, "Only scan for secrets")
+		failOn      = fs.String("fail-on", "", "Only fail entries for issues at or above this severity")
+		signatures  = fs.Bool("check-signatures", false, "Also require every queued commit to be signed by an allowed key")
+		binaries    = fs.Bool("check-binaries", false, "Also report executables, archives and large binaries the commits add")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian queue -base <branch> [options] <head> [<head>...]")
+		fmt.Fprintln(fs.Output(), "       gitguardian queue [-base <branch>] [options] -input entries.json")
+		fs.PrintDefaults()
+	}
+	logging := addLogFlags(fs)
+	output := addASCIIFlag(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if *signatures {
+		cfg.Signatures.Enabled = true
+	}
+	if *binaries {
+		cfg.Binaries.Enabled = true
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		return configErrorf("invalid fail-on severity %q", cfg.FailOn)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unsupported format %q: use text or json", *format)
+	}
+
+	entries, err := queueEntries(*input, *base, fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fs.Usage()
+		return configErrorf("no entries to scan")
+	}
+
+	scanType := scanner.ScanTypeAll
+	if *onlySecrets {
+		scanType = scanner.ScanTypeSecrets
+	}
+
+	ctx, stop := timeout.context()
+	defer stop()
+	queued, err := scanner.New(cfg).ScanQueue(ctx, *repoPath, entries, scanType)
+	if err != nil {
+		return err
+	}
+
+	verdicts := make([]queueVerdict, len(queued))
+	failed := false
+	for i, q := range queued {
+		verdict := "pass"
+		switch {
+		case q.Results.HasIssuesAtOrAbove(cfg.FailOn):
+			verdict = "fail"
+		case q.Results.Incomplete:
+			verdict = "incomplete"
+		}
+		failed = failed || verdict != "pass"
+		verdicts[i] = queueVerdict{QueueResult: q, Verdict: verdict}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(struct {
+			Verdicts []queueVerdict `json:"verdicts"`
+		}{verdicts})
+	} else {
+		err = writeQueueVerdicts(output.writer(os.Stdout), verdicts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		os.Exit(exitFindings)
+	}
+	return nil
+}
+
+// collects the entries from the -input list or the head arguments
+func queueEntries(input, base string, heads []string) ([]scanner.QueueEntry, error) {
+	if input != "" {
+		if len(heads) > 0 {
+			return nil, fmt.Errorf("give entries either with -input or as arguments, not both")
+		}
+		r := os.Stdin
+		if input != "-" {
+			f, err := os.Open(input)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+		return scanner.ParseQueueEntries(r, base)
+	}
+
+	if base == "" && len(heads) > 0 {
+		return nil, fmt.Errorf("-base is required with head arguments")
+	}
+	var entries []scanner.QueueEntry
+	for _, head := range heads {
+		entries = append(entries, scanner.QueueEntry{ID: head, Head: head, Base: base})
+	}
+	return entries, nil
+}
+
+// prints one line per entry, followed by the issues of those that failed
+func writeQueueVerdicts(w io.Writer, verdicts []queueVerdict) error {
+	for _, v := range verdicts {
+		icon := "✅"
+		switch v.Verdict {
+		case "fail":
+			icon = "❌"
+		case "incomplete":
+			icon = "⚠️"
+		}
+		fmt.Fprintf(w, "%s %-10s %s (%d commits", ascii.Label(w, icon), strings.ToUpper(v.Verdict), v.ID, len(v.Commits))
+		if n := len(v.Results.Issues); n > 0 {
+			fmt.Fprintf(w, ", %d issues", n)
+		}
+		fmt.Fprintln(w, ")")
+
+		if v.Verdict != "fail" {
+			continue
+		}
+		for _, issue := range v.Results.Issues {
+			location := issue.File
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", location, issue.Line)
+			}
+			fmt.Fprintf(w, "   [%s] %s: %s @ %s\n", strings.ToUpper(issue.Severity), issue.Description, location, shortCommit(issue.Commit))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian report merge shard1.json shard2.json ..."
+func runReportCommand(args []string) error {
+	if len(args) == 0 || args[0] != "merge" {
+		fmt.Fprintln(os.Stderr, "Usage: gitguardian report merge [-format text|json|html] [-allow-partial] results.json...")
+		return fmt.Errorf("unknown report command")
+	}
+
+	fs := flag.NewFlagSet("report merge", flag.ExitOnError)
+	format := fs.String("format", "json", "Output format")
+	allowPartial := fs.Bool("allow-partial", false, "Merge even if shards are missing")
+	output := addASCIIFlag(fs)
+	parseFlags(fs, args[1:])
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no result files given")
+	}
+
+	var parts []*scanner.Results
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var part scanner.Results
+		if err := json.Unmarshal(data, &part); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		parts = append(parts, &part)
+	}
+
+	merged, err := scanner.MergeResults(parts)
+	if err != nil {
+		if !*allowPartial {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if err := report.Write(output.reportWriter(os.Stdout, *format), *format, merged); err != nil {
+		return err
+	}
+
+	if merged.HasIssues() {
+		os.Exit(exitFindings)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian rules test"
+func runRulesCommand(args []string) error {
+	if len(args) > 0 && args[0] == "test" {
+		return runRulesTest(args[1:])
+	}
+	fmt.Fprintln(os.Stderr, "Usage: gitguardian rules test -rules file [-samples dir] [-config file] [-configured] [-format text|json]")
+	return configErrorf("unknown rules command")
+}
+
+// handles "gitguardian rules test", which runs the rules of a rule pack or
+// gitleaks TOML file over sample files: samples under positive/ must be
+// reported by a rule, samples under negative/ by none, and others only
+// have their matches listed. Rules that do not compile, unsafe patterns,
+// rules reporting the same secret and the rules' own examples are
+// reported as well.
+func runRulesTest(args []string) error {
+	fs := flag.NewFlagSet("rules test", flag.ExitOnError)
+	var (
+		rulesFile  = fs.String("rules", "", "Rule pack (JSON) or gitleaks rules (.toml) to test")
+		samplesDir = fs.String("samples", "", "Directory of sample files, in positive/ and negative/ subdirectories")
+		configFile = fs.String("config", "", "Configuration file path, for the whitelist, placeholders and allow_unsafe_patterns")
+		configured = fs.Bool("configured", false, "Also run the configured rules, built-ins included, to find overlaps with them")
+		format     = fs.String("format", "text", "Output format (text, json)")
+	)
+	output := addASCIIFlag(fs)
+	parseFlags(fs, args)
+
+	if *rulesFile == "" {
+		return configErrorf("-rules is required")
+	}
+	if *format != "text" && *format != "json" {
+		return configErrorf("unknown format %q: use text or json", *format)
+	}
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if !*configured {
+		cfg.SecretPatterns = nil
+	}
+
+	var rules []config.SecretPattern
+	if strings.EqualFold(filepath.Ext(*rulesFile), ".toml") {
+		rules, err = config.LoadGitleaksRules(*rulesFile)
+	} else {
+		rules, err = config.LoadRuleFile(*rulesFile)
+	}
+	if err != nil {
+		return configError{err}
+	}
+
+	var samples []scanner.RuleSample
+	if *samplesDir != "" {
+		if samples, err = loadRuleSamples(*samplesDir, cfg.MaxFileSize); err != nil {
+			return configError{err}
+		}
+	}
+
+	report := scanner.RunRuleTests(cfg, rules, samples)
+	out := output.reportWriter(os.Stdout, *format)
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		writeRuleTestReport(out, report)
+	}
+	if report.Failures > 0 {
+		return fmt.Errorf("%d rule test failures", report.Failures)
+	}
+	return nil
+}
+
+// reads every file under dir; the first directory of its path, positive
+// or negative, says what is expected of it
+func loadRuleSamples(dir string, maxSize int64) ([]scanner.RuleSample, error) {
+	var samples []scanner.RuleSample
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() > maxSize {
+			fmt.Fprintf(os.Stderr, "Skipping %s: larger than max_file_size\n", path)
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		sample := scanner.RuleSample{Path: rel, Content: string(data)}
+		if first, _, ok := strings.Cut(rel, "/"); ok && (first == "positive" || first == "negative") {
+			sample.Expect = first
+		}
+		samples = append(samples, sample)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no sample files in %s", dir)
+	}
+	return samples, nil
+}
+
+func writeRuleTestReport(w io.Writer, report *scanner.RuleTestReport) {
+	positives := 0
+	for _, sample := range report.Samples {
+		if sample.Expect == "positive" {
+			positives++
+		}
+	}
+
+	fmt.Fprintln(w, "Rules:")
+	for _, rule := range report.Rules {
+		switch {
+		case rule.Error != "":
+			fmt.Fprintf(w, ascii.Label(w, "  ❌ %s: %s\n"), rule.Name, rule.Error)
+			continue
+		case positives > 0 && rule.Positive == 0:
+			fmt.Fprintf(w, ascii.Label(w, "  ⚠️  %s: matches no positive sample\n"), rule.Name)
+		default:
+			fmt.Fprintf(w, ascii.Label(w, "  ✅ %s: %d positive and %d negative samples, %d matches\n"), rule.Name, rule.Positive, rule.Negative, rule.Matches)
+		}
+		for _, problem := range rule.Unsafe {
+			fmt.Fprintf(w, "     unsafe: %s\n", problem)
+		}
+	}
+
+	if len(report.Samples) > 0 {
+		fmt.Fprintln(w, "\nSamples:")
+	}
+	for _, sample := range report.Samples {
+		mark := "  "
+		switch {
+		case !sample.Passed:
+			mark = "❌"
+		case sample.Expect != "":
+			mark = "✅"
+		}
+		fmt.Fprintf(w, "  %s %s\n", ascii.Label(w, mark), sample.Path)
+		if len(sample.Matches) == 0 {
+			fmt.Fprintln(w, "       no matches")
+		}
+		for _, match := range sample.Matches {
+			var notes []string
+			if match.Configured {
+				notes = append(notes, "configured rule")
+			}
+			if match.SuppressedBy != "" {
+				notes = append(notes, "suppressed: "+match.SuppressedBy)
+			}
+			note := ""
+			if len(notes) > 0 {
+				note = " (" + strings.Join(notes, ", ") + ")"
+			}
+			fmt.Fprintf(w, "       %d:%d %s: %s%s\n", match.Line, match.Column, match.Rule, match.Secret, note)
+		}
+	}
+
+	if len(report.Overlaps) > 0 {
+		fmt.Fprintln(w, "\nOverlaps:")
+	}
+	for _, overlap := range report.Overlaps {
+		fmt.Fprintf(w, ascii.Label(w, "  ⚠️  %s and %s both report %d matches, e.g. %s\n"),
+			overlap.Rules[0], overlap.Rules[1], overlap.Count, overlap.Example)
+	}
+
+	if len(report.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+	}
+	for _, f := range report.Examples {
+		if f.Positive {
+			fmt.Fprintf(w, ascii.Label(w, "  ❌ %s: does not match positive example %q\n"), f.Rule, f.Example)
+		} else {
+			fmt.Fprintf(w, ascii.Label(w, "  ❌ %s: matches negative example %q\n"), f.Rule, f.Example)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d rules, %d samples, %d failures\n", len(report.Rules), len(report.Samples), report.Failures)
+}
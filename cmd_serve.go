@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/server"
+)
+
+// handles "gitguardian serve", running the scanner as an HTTP service
+func runServeCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on; one other hosts can reach needs a token")
+	configFile := fs.String("config", "", "Configuration file path")
+	token := fs.String("token", "", "Bearer token clients must send (default: $GITGUARDIAN_SERVER_TOKEN)")
+	reload := fs.Duration("reload-interval", 2*time.Second, "How often to check the config files for changes (0 disables reloading)")
+	publicBadges := fs.Bool("public-badges", false, "Serve /badge without the token, for dashboards that embed it")
+	logging := addLogFlags(fs)
+	storage := addStorageFlags(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	storage.apply(cfg)
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	logger := cfg.Log()
+
+	if *token == "" {
+		*token = os.Getenv("GITGUARDIAN_SERVER_TOKEN")
+	}
+	if *token == "" {
+		if !isLoopback(*addr) {
+			return configErrorf("serving on %s without a token would open the API to anyone who can reach it; set -token or $GITGUARDIAN_SERVER_TOKEN", *addr)
+		}
+		logger.Warn("no token set, the API is open to anyone on this host")
+	}
+
+	handler := server.New(cfg, *token)
+	if cfg.Findings.Enabled {
+		path, err := findingsPath(cfg)
+		if err != nil {
+			return err
+		}
+		handler.SetFindings(path, *publicBadges)
+	}
+	if *reload > 0 && len(cfg.Files()) > 0 {
+		// the storage flags and environment apply to every reloaded
+		// configuration, as they did at startup
+		watcher := config.WatchPrepared(cfg, *reload, storage.apply, func(next *config.Config, changes []string) {
+			// log settings only take effect on restart
+			next.Logger = logger
+			handler.SetConfig(next)
+			logger.Info("reloaded configuration", "changes", strings.Join(changes, ", "))
+		}, func(err error) {
+			logger.Error("configuration not reloaded, keeping the previous one", "error", err)
+		})
+		defer watcher.Stop()
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("GitGuardian listening", "addr", *addr)
+	return srv.ListenAndServe()
+}
+
+// reports whether addr only listens on the local host
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
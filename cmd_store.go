@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// handles "gitguardian store prune", which applies retention to the
+// findings store and the cache, e.g. from a cron job on a scan server
+func runStoreCommand(args []string) error {
+	fs := flag.NewFlagSet("store", flag.ExitOnError)
+	configFile := fs.String("config", "", "Configuration file path")
+	olderThan := fs.String("older-than", "", "Drop findings not seen for this long, e.g. 90d, 2w or 720h (default: findings.max_age)")
+	maxRecords := fs.Int("max-records", 0, "Keep at most this many findings, the most recently seen (default: findings.max_records)")
+	maxSize := fs.Int("max-cache-mb", 0, "Evict cache entries until the cache is this many megabytes (default: cache.max_size_mb)")
+	storage := addStorageFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitguardian store [flags] prune")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	if fs.NArg() != 1 || fs.Arg(0) != "prune" {
+		fs.Usage()
+		return configErrorf("missing store command")
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	storage.apply(cfg)
+	if cfg.NoWrite {
+		return fmt.Errorf("cannot prune with no_write set")
+	}
+	age, err := config.ParseAge(*olderThan)
+	if err != nil {
+		return err
+	}
+	if *maxRecords > 0 {
+		cfg.Findings.MaxRecords = *maxRecords
+	}
+	if *maxSize > 0 {
+		cfg.Cache.MaxSizeMB = *maxSize
+	}
+
+	store, err := openFindings(cfg)
+	if err != nil {
+		return err
+	}
+	before := store.Len()
+	removed, err := applyRetention(cfg, store, age, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Findings store: removed %d of %d records\n", removed, before)
+
+	backend, err := openCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	pruner, ok := backend.(cache.Pruner)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Cache: the %s backend expires entries itself\n", cfg.Cache.Backend)
+		return nil
+	}
+	evicted, err := pruner.Prune(int64(cfg.Cache.MaxSizeMB) << 20)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Cache: removed %d entries\n", evicted)
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/github"
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian sync github", which publishes findings to the
+// repository's Security tab: results go up as a code scanning SARIF
+// upload and, optionally, severe findings become draft security advisories
+func runSyncCommand(args []string) error {
+	if len(args) == 0 || args[0] != "github" {
+		fmt.Fprintln(os.Stderr, "Usage: gitguardian sync github [-path dir] [-input results.json] [-repo owner/name] [-advisories]")
+		return fmt.Errorf("unknown sync target")
+	}
+
+	fs := flag.NewFlagSet("sync github", flag.ExitOnError)
+	var (
+		scanPath    = fs.String("path", ".", "Repository to scan and sync")
+		configFile  = fs.String("config", "", "Configuration file path")
+		input       = fs.String("input", "", "Sync these JSON results instead of scanning")
+		repository  = fs.String("repo", "", "GitHub repository as owner/name (default: $GITHUB_REPOSITORY or the origin remote)")
+		commit      = fs.String("commit", "", "Commit the results belong to (default: $GITHUB_SHA or HEAD)")
+		ref         = fs.String("ref", "", "Ref the results belong to (default: $GITHUB_REF or the current branch)")
+		apiURL      = fs.String("api", github.DefaultBaseURL, "GitHub API base URL")
+		advisories  = fs.Bool("advisories", false, "Also file draft repository security advisories")
+		minSeverity = fs.String("advisory-severity", "critical", "Lowest severity that gets an advisory")
+	)
+	logging := addLogFlags(fs)
+	storage := addStorageFlags(fs)
+	timeout := addTimeoutFlag(fs)
+	parseFlags(fs, args[1:])
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	storage.apply(cfg)
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	if !scanner.ValidSeverity(*minSeverity) {
+		return configErrorf("invalid advisory severity %q", *minSeverity)
+	}
+
+	token := cfg.DependencyAPIs.GitHubToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *repository == "" {
+		*repository = firstNonEmpty(os.Getenv("GITHUB_REPOSITORY"), originRepository(*scanPath))
+	}
+	if *commit == "" {
+		*commit = firstNonEmpty(os.Getenv("GITHUB_SHA"), gitOutput(*scanPath, "rev-parse", "HEAD"))
+	}
+	if *ref == "" {
+		*ref = firstNonEmpty(os.Getenv("GITHUB_REF"), gitOutput(*scanPath, "symbolic-ref", "HEAD"))
+	}
+	if *commit == "" || *ref == "" {
+		return fmt.Errorf("cannot tell the commit and ref of the results; pass -commit and -ref")
+	}
+
+	client, err := github.NewClient(*apiURL, token, *repository)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := timeout.context()
+	defer stop()
+	results, err := syncResults(ctx, cfg, *scanPath, *input)
+	if err != nil {
+		return err
+	}
+	// code scanning closes the alerts an upload leaves out, so a partial
+	// scan would mark findings it never reached as fixed
+	if results.Incomplete {
+		return fmt.Errorf("the results are partial, the scan was cut short; not uploading them")
+	}
+
+	var sarif bytes.Buffer
+	if err := report.Write(&sarif, "sarif", results); err != nil {
+		return err
+	}
+	id, err := client.UploadSARIF(sarif.Bytes(), *commit, *ref)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %d finding(s) to code scanning (upload %s)\n", len(results.Issues), id)
+
+	if *advisories {
+		created, err := client.SyncAdvisories(results.Issues, *minSeverity)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %d draft security advisory(ies)\n", created)
+	}
+	return nil
+}
+
+// loads results from a JSON report, or scans the repository; file paths
+// are made relative to it, as code scanning expects
+func syncResults(ctx context.Context, cfg *config.Config, scanPath, input string) (*scanner.Results, error) {
+	var results *scanner.Results
+	if input != "" {
+		data, err := os.ReadFile(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", input, err)
+		}
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", input, err)
+		}
+		return results, nil
+	}
+
+	s := scanner.New(cfg)
+	if cfg.DependencyAPIs.CacheEnabled {
+		if depCache, err := openCache(cfg); err == nil {
+			s.SetCache(depCache)
+			defer depCache.Close()
+		}
+	}
+	results, err := s.ScanPath(ctx, scanPath, scanner.ScanTypeAll)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+
+	root, err := filepath.Abs(scanPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results.Issues {
+		results.Issues[i].File = scopeRelative(root, results.Issues[i].File)
+	}
+	return results, nil
+}
+
+var githubRemote = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+// returns owner/name of a github.com origin remote
+func originRepository(repoPath string) string {
+	if m := githubRemote.FindStringSubmatch(gitOutput(repoPath, "remote", "get-url", "origin")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func gitOutput(repoPath string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
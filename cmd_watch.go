@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// handles "gitguardian watch", which polls the working tree and rescans
+// files as they change, printing findings as they appear and disappear.
+// It polls instead of subscribing to filesystem notifications, which need
+// a dependency such as fsnotify: go.mod has none, and polling also works
+// on network and container mounts that deliver no notifications.
+func runWatchCommand(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var (
+		watchPath   = fs.String("path", ".", "Directory to watch")
+		configFile  = fs.String("config", "", "Configuration file path")
+		interval    = fs.Duration("interval", time.Second, "How often to look for changed files")
+		onlySecrets = fs.Bool("secrets-only", false, "Only scan for secrets")
+	)
+	logging := addLogFlags(fs)
+	parseFlags(fs, args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return configErrorf("failed to load configuration: %w", err)
+	}
+	if err := logging.setup(cfg); err != nil {
+		return configError{err}
+	}
+	logger := cfg.Log()
+	// findings are tracked per file, so a repeat must not be folded into
+	// an occurrence in another file
+	cfg.Deduplicate = false
+
+	root, err := filepath.Abs(*watchPath)
+	if err != nil {
+		return err
+	}
+	scanType := scanner.ScanTypeAll
+	if *onlySecrets {
+		scanType = scanner.ScanTypeSecrets
+	}
+
+	// config changes rescan everything with the new rules
+	reloaded := make(chan *config.Config, 1)
+	if len(cfg.Files()) > 0 {
+		watcher := config.Watch(cfg, *interval, func(next *config.Config, changes []string) {
+			next.Logger = logger
+			next.Deduplicate = false
+			logger.Info("reloaded configuration", "changes", strings.Join(changes, ", "))
+			reloaded <- next
+		}, func(err error) {
+			logger.Error("configuration not reloaded, keeping the previous one", "error", err)
+		})
+		defer watcher.Stop()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := newTreeWatcher(root, scanner.New(cfg), scanType, os.Stdout)
+	logger.Info("watching for changes, Ctrl-C to stop", "path", root)
+	if err := w.poll(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case next := <-reloaded:
+			w.reset(scanner.New(next))
+		case <-ticker.C:
+		}
+		if err := w.poll(ctx); err != nil {
+			logger.Warn("watch scan failed", "error", err)
+		}
+	}
+}
+
+// when a file was last seen to change
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// remembers every watched file and its findings, so each poll only
+// rescans what changed and reports only what is new or gone
+type treeWatcher struct {
+	root     string
+	scanner  *scanner.Scanner
+	scanType scanner.ScanType
+	out      io.Writer
+
+	stamps   map[string]fileStamp
+	findings map[string]map[string]scanner.Issue // file -> fingerprint -> issue
+}
+
+func newTreeWatcher(root string, s *scanner.Scanner, scanType scanner.ScanType, out io.Writer) *treeWatcher {
+	return &treeWatcher{
+		root:     root,
+		scanner:  s,
+		scanType: scanType,
+		out:      out,
+		stamps:   make(map[string]fileStamp),
+		findings: make(map[string]map[string]scanner.Issue),
+	}
+}
+
+// switches to a new scanner and forgets when files last changed, so the
+// next poll rescans the whole tree; only findings the new rules add or
+// drop are reported
+func (w *treeWatcher) reset(s *scanner.Scanner) {
+	w.scanner = s
+	w.stamps = make(map[string]fileStamp)
+}
+
+// rescans the files that were added or modified since the last poll and
+// drops the findings of deleted ones
+func (w *treeWatcher) poll(ctx context.Context) error {
+	listed, err := w.scanner.ListFiles(w.root)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(listed))
+	var changed []string
+	for _, rel := range listed {
+		file := filepath.Join(w.root, filepath.FromSlash(rel))
+		seen[file] = true
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		stamp := fileStamp{size: info.Size(), modTime: info.ModTime()}
+		if old, ok := w.stamps[file]; ok && old == stamp {
+			continue
+		}
+		w.stamps[file] = stamp
+		changed = append(changed, file)
+	}
+
+	for file := range w.stamps {
+		if !seen[file] {
+			delete(w.stamps, file)
+			w.report(file, nil)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	results := w.scanner.ScanFiles(ctx, w.root, changed, w.scanType)
+	if results.Incomplete {
+		// stopping; files left unscanned must not be reported as clean
+		return nil
+	}
+	byFile := make(map[string][]scanner.Issue, len(changed))
+	for _, issue := range results.Occurrences() {
+		byFile[issue.File] = append(byFile[issue.File], issue)
+	}
+	for _, file := range changed {
+		w.report(file, byFile[file])
+	}
+	return nil
+}
+
+// prints the findings of a file that were not there before, and those
+// that are gone
+func (w *treeWatcher) report(file string, issues []scanner.Issue) {
+	previous := w.findings[file]
+	current := make(map[string]scanner.Issue, len(issues))
+	for _, issue := range issues {
+		current[issue.ID] = issue
+	}
+
+	var lines []string
+	for fp, issue := range current {
+		if _, ok := previous[fp]; !ok {
+			lines = append(lines, "+ "+w.describe(issue))
+		}
+	}
+	for fp, issue := range previous {
+		if _, ok := current[fp]; !ok {
+			lines = append(lines, "- "+w.describe(issue)+" (resolved)")
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintf(w.out, "%s %s\n", time.Now().Format("15:04:05"), line)
+	}
+
+	if len(current) == 0 {
+		delete(w.findings, file)
+	} else {
+		w.findings[file] = current
+	}
+}
+
+func (w *treeWatcher) describe(issue scanner.Issue) string {
+	location := scopeRelative(w.root, issue.File)
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", location, issue.Line, issue.Column)
+	}
+	return fmt.Sprintf("[%s] %s %s (%s)", strings.ToUpper(issue.Severity), location, issue.Description, issue.Rule)
+}
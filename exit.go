@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// exit codes, so that pipelines can tell findings from a scan that could
+// not run
+const (
+	exitClean    = 0 // nothing at or above fail-on
+	exitFindings = 1 // issues at or above fail-on
+	exitError    = 2 // the scan failed or was cut short
+	exitConfig   = 3 // invalid configuration, flags or arguments
+)
+
+// an error in the configuration or on the command line, which exits with
+// exitConfig rather than exitError
+type configError struct {
+	err error
+}
+
+func (e configError) Error() string { return e.err.Error() }
+func (e configError) Unwrap() error { return e.err }
+
+func configErrorf(format string, args ...any) error {
+	return configError{fmt.Errorf(format, args...)}
+}
+
+// the exit code of a subcommand that returned err
+func exitCode(err error) int {
+	var cfgErr configError
+	if errors.As(err, &cfgErr) {
+		return exitConfig
+	}
+	return exitError
+}
+
+// logs like log.Fatalf, exiting with code
+func fatalf(code int, format string, args ...any) {
+	log.Printf(format, args...)
+	os.Exit(code)
+}
+
+// parses the flags of fs, exiting with exitConfig on an unknown or
+// malformed flag where the flag package would use 2, which is exitError
+func parseFlags(fs *flag.FlagSet, args []string) {
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(exitClean)
+		}
+		os.Exit(exitConfig)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/findings"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// opens the configured findings store
+func openFindings(cfg *config.Config) (*findings.Store, error) {
+	path, err := findingsPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return findings.Open(path)
+}
+
+// returns where the findings store is: findings.path, or findings.json in
+// state_dir or the cache directory
+func findingsPath(cfg *config.Config) (string, error) {
+	if cfg.Findings.Path != "" {
+		return cfg.Findings.Path, nil
+	}
+	if cfg.StateDir != "" {
+		return filepath.Join(cfg.StateDir, "findings.json"), nil
+	}
+	return findings.DefaultPath()
+}
+
+// records the results in the findings store, marking issues it has never
+// seen for the scanned path as new, and the scan itself, on the branch
+// and commit of rev or of the checkout; with no_write the store is only
+// read
+func trackFindings(cfg *config.Config, scanPath, rev string, results *scanner.Results) error {
+	store, err := openFindings(cfg)
+	if err != nil {
+		return err
+	}
+
+	scope, err := filepath.Abs(scanPath)
+	if err != nil {
+		scope = scanPath
+	}
+
+	now := time.Now()
+	// repeats of a secret are tracked at each of their files too
+	for _, issues := range [][]scanner.Issue{results.Issues, results.Duplicates} {
+		for i := range issues {
+			observeFinding(store, scope, &issues[i], now)
+		}
+	}
+	branch, commit := scanRef(scanPath, rev)
+	store.ObserveScan(findings.Scan{
+		Scope:      scope,
+		Branch:     branch,
+		Commit:     commit,
+		At:         now,
+		Critical:   results.Summary.Critical,
+		High:       results.Summary.High,
+		Medium:     results.Summary.Medium,
+		Low:        results.Summary.Low,
+		Total:      results.Summary.Total,
+		Incomplete: results.Incomplete,
+	})
+	// the ages the store knows weigh into the risk
+	results.Summary.Risk = scanner.ScoreRisk(results.Issues, now)
+
+	if cfg.NoWrite {
+		return nil
+	}
+	if _, err := applyRetention(cfg, store, 0, now); err != nil {
+		return err
+	}
+	return store.Save()
+}
+
+// records one issue in the store and marks it with what the store knows
+func observeFinding(store *findings.Store, scope string, issue *scanner.Issue, now time.Time) {
+	file := scopeRelative(scope, issue.File)
+	id := issue.ID
+	if id == "" {
+		relative := *issue
+		relative.File = file
+		id = relative.Fingerprint()
+	}
+
+	fingerprint := findingFingerprint(scope, id)
+	issue.New = store.Observe(findings.Record{
+		Fingerprint: fingerprint,
+		ID:          id,
+		Scope:       scope,
+		Rule:        issue.Rule,
+		File:        file,
+		Severity:    issue.Severity,
+		Verified:    issue.Verified,
+	}, now)
+	if record, ok := store.Get(fingerprint); ok {
+		issue.FirstSeen = &record.FirstSeen
+	}
+}
+
+// returns the branch and commit a scan of path saw: rev's, or those of
+// the checkout. CI systems check out a detached HEAD, so the branch they
+// build is taken from their environment; both are empty outside git.
+func scanRef(path, rev string) (branch, commit string) {
+	if rev != "" {
+		commit = gitOutput(path, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+		if commit == "" {
+			return "", ""
+		}
+		return strings.TrimPrefix(rev, "refs/heads/"), commit
+	}
+
+	commit = gitOutput(path, "rev-parse", "--verify", "--quiet", "HEAD")
+	if commit == "" {
+		return "", ""
+	}
+	head := gitOutput(path, "rev-parse", "--abbrev-ref", "HEAD")
+	if head == "HEAD" {
+		head = ""
+	}
+	branch = firstNonEmpty(
+		os.Getenv("GITHUB_HEAD_REF"), // pull requests
+		head,
+		os.Getenv("GITHUB_REF_NAME"),
+		os.Getenv("CI_COMMIT_REF_NAME"), // GitLab
+		os.Getenv("BRANCH_NAME"),        // Jenkins
+	)
+	return branch, commit
+}
+
+// drops the records retention allows no longer: those not seen for
+// olderThan, or findings.max_age when it is zero, and the least recently
+// seen beyond findings.max_records; returns how many it removed
+func applyRetention(cfg *config.Config, store *findings.Store, olderThan time.Duration, now time.Time) (int, error) {
+	if olderThan == 0 {
+		var err error
+		if olderThan, err = config.ParseAge(cfg.Findings.MaxAge); err != nil {
+			return 0, err
+		}
+	}
+
+	removed := 0
+	if olderThan > 0 {
+		removed += store.Prune(now.Add(-olderThan))
+	}
+	return removed + store.Trim(cfg.Findings.MaxRecords), nil
+}
+
+// keys an issue's ID within a scope, so the same finding in two
+// repositories is tracked twice
+func findingFingerprint(scope, id string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + id))
+	return hex.EncodeToString(sum[:])
+}
+
+// makes an issue path relative to the scanned repository; paths that are
+// already repository-relative, as in staged scans, are kept
+func scopeRelative(scope, file string) string {
+	abs := file
+	if !filepath.IsAbs(abs) {
+		var err error
+		if abs, err = filepath.Abs(file); err != nil {
+			return filepath.ToSlash(file)
+		}
+	}
+	if rel, err := filepath.Rel(scope, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(file)
+}
@@ -0,0 +1,91 @@
+package ascii
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ASCII stand-ins for the icons and symbols in the tool's output
+var symbols = map[rune]string{
+	'🚨':      "[!!]",
+	'⚠':      "[!]",
+	'⚡':      "[*]",
+	'ℹ':      "[i]",
+	'❓':      "[?]",
+	'✅':      "[ok]",
+	'❌':      "[x]",
+	'🔍':      ">",
+	'•':      "-",
+	'µ':      "u",
+	'—':      "-",
+	'–':      "-",
+	'…':      "...",
+	'“':      `"`,
+	'”':      `"`,
+	'‘':      "'",
+	'’':      "'",
+	'¡':      "",
+	'¿':      "",
+	'\uFE0F': "", // emoji presentation selector
+}
+
+// the accented letters of the message catalogs, by their base letters
+// so that columns and underlines keep their width
+var letters = map[rune]string{
+	'á': "a", 'é': "e", 'í': "i", 'ó': "o", 'ú': "u", 'ñ': "n",
+	'Á': "A", 'É': "E", 'Í': "I", 'Ó': "O", 'Ú': "U", 'Ñ': "N",
+	'ä': "a", 'ö': "o", 'ü': "u", 'ß': "ss",
+	'Ä': "A", 'Ö': "O", 'Ü': "U",
+}
+
+// replaces every non-ASCII character of s: icons by bracketed stand-ins,
+// accented letters by their base letters and anything else by "?"
+func String(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		writeRune(&b, r)
+	}
+	return b.String()
+}
+
+func writeRune(b *strings.Builder, r rune) {
+	if r < utf8.RuneSelf {
+		b.WriteRune(r)
+		return
+	}
+	if s, ok := symbols[r]; ok {
+		b.WriteString(s)
+		return
+	}
+	if s, ok := letters[r]; ok {
+		b.WriteString(s)
+		return
+	}
+	b.WriteByte('?')
+}
+
+// marks output that should be plain ASCII. What is written through it
+// passes as it is, as file paths, matched content and rule descriptions
+// are data and must not change; the tool's own icons and labels are
+// converted with Label before they are written.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (a *Writer) Write(p []byte) (int, error) {
+	return a.w.Write(p)
+}
+
+// converts s, an icon, label or message format of the tool's own, with
+// String when it is written to a Writer
+func Label(w io.Writer, s string) string {
+	if _, ok := w.(*Writer); ok {
+		return String(s)
+	}
+	return s
+}
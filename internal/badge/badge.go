@@ -0,0 +1,192 @@
+package badge
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/findings"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// a shields-style badge: a grey label and a colored message
+type Badge struct {
+	Label   string
+	Message string
+	Color   string // a shields.io color name
+}
+
+// the colors badges use, as shields.io names and draws them
+var colors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"lightgrey":   "#9f9f9f",
+}
+
+// risk grades and the colors they show in
+var gradeColors = map[string]string{
+	"A": "brightgreen",
+	"B": "green",
+	"C": "yellow",
+	"D": "orange",
+	"F": "red",
+}
+
+// the outcome of a scan: "clean", or the number of issues in the color of
+// the most severe; "incomplete" when the scan was cut short, since its
+// counts cannot be trusted
+func Status(counts scanner.SeverityCounts, incomplete bool) Badge {
+	b := Badge{Label: "security"}
+	switch {
+	case incomplete:
+		b.Message, b.Color = "incomplete", "lightgrey"
+		return b
+	case counts.Total == 0:
+		b.Message, b.Color = "clean", "brightgreen"
+		return b
+	case counts.Critical > 0:
+		b.Color = "red"
+	case counts.High > 0:
+		b.Color = "orange"
+	case counts.Medium > 0:
+		b.Color = "yellow"
+	default:
+		b.Color = "yellowgreen"
+	}
+	b.Message = fmt.Sprintf("%d issues", counts.Total)
+	if counts.Total == 1 {
+		b.Message = "1 issue"
+	}
+	return b
+}
+
+// a risk score and its grade, e.g. "22 B"
+func Risk(risk scanner.RiskScore) Badge {
+	return Badge{
+		Label:   "risk",
+		Message: fmt.Sprintf("%d %s", risk.Score, risk.Grade),
+		Color:   gradeColors[risk.Grade],
+	}
+}
+
+// the JSON of a shields.io endpoint badge
+func (b Badge) Shields() map[string]interface{} {
+	return map[string]interface{}{
+		"schemaVersion": 1,
+		"label":         b.Label,
+		"message":       b.Message,
+		"color":         b.Color,
+	}
+}
+
+// draws the badge in the flat style; text widths are estimated, as
+// Verdana at 11px averages about 7px a character
+func (b Badge) SVG() string {
+	labelWidth := 10 + 7*len(b.Label)
+	messageWidth := 10 + 7*len(b.Message)
+	color, ok := colors[b.Color]
+	if !ok {
+		color = colors["lightgrey"]
+	}
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<title>%s: %s</title>
+<rect width="%d" height="20" fill="#555"/>
+<rect x="%d" width="%d" height="20" fill="%s"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="14">%s</text>
+<text x="%d" y="14">%s</text>
+</g>
+</svg>
+`, labelWidth+messageWidth, label, message, label, message,
+		labelWidth, labelWidth, messageWidth, color,
+		labelWidth/2, label, labelWidth+messageWidth/2, message)
+}
+
+// the kinds of badge there are
+const (
+	KindStatus = "status"
+	KindRisk   = "risk"
+)
+
+// the badge of kind for scan results
+func FromResults(results *scanner.Results, kind string) (Badge, error) {
+	switch kind {
+	case KindStatus:
+		return Status(results.Summary.SeverityCounts, results.Incomplete), nil
+	case KindRisk:
+		return Risk(results.Summary.Risk), nil
+	}
+	return Badge{}, fmt.Errorf("unknown badge %q: use status or risk", kind)
+}
+
+// the badge of kind for the last scan of scope on branch that store
+// recorded, any branch when empty. The risk is the repository's, whatever
+// the branch: it weighs the findings the last scan of any branch saw, by
+// the age the store knows.
+func FromStore(store *findings.Store, scope, branch, kind string, now time.Time) (Badge, error) {
+	scan, ok := store.LastScan(scope, branch)
+	if !ok {
+		if branch != "" {
+			return Badge{}, fmt.Errorf("no scans of %s on %s recorded", scope, branch)
+		}
+		return Badge{}, fmt.Errorf("no scans of %s recorded", scope)
+	}
+
+	switch kind {
+	case KindStatus:
+		return Status(scanner.SeverityCounts{
+			Critical: scan.Critical,
+			High:     scan.High,
+			Medium:   scan.Medium,
+			Low:      scan.Low,
+			Total:    scan.Total,
+		}, scan.Incomplete), nil
+	case KindRisk:
+		return Risk(StoreRisk(store, scope, now)), nil
+	}
+	return Badge{}, fmt.Errorf("unknown badge %q: use status or risk", kind)
+}
+
+// scores the findings the last scan of scope saw, by their age in store
+func StoreRisk(store *findings.Store, scope string, now time.Time) scanner.RiskScore {
+	records := store.Current(scope)
+	issues := make([]scanner.Issue, len(records))
+	for i := range records {
+		issues[i] = scanner.Issue{
+			Severity:  records[i].Severity,
+			Verified:  records[i].Verified,
+			FirstSeen: &records[i].FirstSeen,
+		}
+	}
+	return scanner.ScoreRisk(issues, now)
+}
+
+// returns the scanned scope that repo names: the scope itself, or the
+// only one whose path ends in it, e.g. "payments" or "team/payments"
+func FindScope(scopes []string, repo string) (string, error) {
+	suffix := "/" + strings.Trim(filepath.ToSlash(repo), "/")
+	var matches []string
+	for _, scope := range scopes {
+		if scope == repo {
+			return scope, nil
+		}
+		if strings.HasSuffix(filepath.ToSlash(scope), suffix) {
+			matches = append(matches, scope)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no scans of %s recorded", repo)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%s matches %d scanned repositories; give more of its path", repo, len(matches))
+	}
+}
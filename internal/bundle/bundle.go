@@ -0,0 +1,258 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the manifest's name in the tarball; it is written last
+const ManifestName = "manifest.json"
+
+// the configuration's name in the tarball
+const ConfigName = "config.json"
+
+// the format of the tarballs this package writes
+const FormatVersion = 1
+
+// lists the files of a bundle
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Files   []File    `json:"files"`
+}
+
+type File struct {
+	Path   string `json:"path"` // slash-separated, relative to the bundle
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// writes a bundle; Close writes the manifest
+type Writer struct {
+	gz    *gzip.Writer
+	tw    *tar.Writer
+	files []File
+	seen  map[string]bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	gz := gzip.NewWriter(w)
+	return &Writer{gz: gz, tw: tar.NewWriter(gz), seen: make(map[string]bool)}
+}
+
+// adds data as the file name
+func (w *Writer) AddBytes(name string, data []byte) error {
+	name = path.Clean(name)
+	if !filepath.IsLocal(name) || name == ManifestName {
+		return fmt.Errorf("invalid bundle path %q", name)
+	}
+	if w.seen[name] {
+		return fmt.Errorf("%s is already in the bundle", name)
+	}
+	w.seen[name] = true
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	w.files = append(w.files, File{Path: name, Size: hdr.Size, SHA256: hex.EncodeToString(sum[:])})
+	return nil
+}
+
+// adds the file src as name
+func (w *Writer) AddFile(name, src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return w.AddBytes(name, data)
+}
+
+// adds src, a file or a directory, under name; of a directory, only the
+// files whose extension is in exts are added, with their paths below it
+func (w *Writer) AddTree(name, src string, exts ...string) (int, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return 1, w.AddFile(path.Join(name, filepath.Base(src)), src)
+	}
+
+	count := 0
+	err = filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || !hasExt(p, exts) {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		count++
+		return w.AddFile(path.Join(name, filepath.ToSlash(rel)), p)
+	})
+	return count, err
+}
+
+// the files added so far
+func (w *Writer) Files() []File {
+	return w.files
+}
+
+// writes the manifest and finishes the tarball
+func (w *Writer) Close() error {
+	manifest := Manifest{Version: FormatVersion, Created: time.Now().UTC(), Files: w.files}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: ManifestName, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return err
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// unpacks a bundle into dir, which must not exist yet, and checks every
+// file against the manifest: a file missing, unlisted or with another
+// checksum fails the extraction, and dir is removed again. The manifest
+// is written into dir last.
+func Extract(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+	manifest, err := extract(tar.NewReader(gz), dir)
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(manifest, "", "  "); err == nil {
+			err = os.WriteFile(filepath.Join(dir, ManifestName), data, 0644)
+		}
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func extract(tr *tar.Reader, dir string) (*Manifest, error) {
+	sums := make(map[string]File)
+	var manifest *Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("invalid bundle: %s is not a regular file", hdr.Name)
+		}
+		name := path.Clean(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("invalid bundle: unsafe path %q", hdr.Name)
+		}
+
+		if name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			continue
+		}
+		if _, ok := sums[name]; ok {
+			return nil, fmt.Errorf("invalid bundle: %s appears twice", name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		size, err := io.Copy(io.MultiWriter(f, h), tr)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		sums[name] = File{Path: name, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("invalid bundle: no %s", ManifestName)
+	}
+	if manifest.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+	var problems []string
+	for _, want := range manifest.Files {
+		got, ok := sums[want.Path]
+		switch {
+		case !ok:
+			problems = append(problems, want.Path+" is missing")
+		case got.Size != want.Size || !strings.EqualFold(got.SHA256, want.SHA256):
+			problems = append(problems, want.Path+" does not match its checksum")
+		}
+		delete(sums, want.Path)
+	}
+	for name := range sums {
+		problems = append(problems, name+" is not in the manifest")
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("bundle verification failed: %s", strings.Join(problems, "; "))
+	}
+	return manifest, nil
+}
+
+func hasExt(p string, exts []string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
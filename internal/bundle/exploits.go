@@ -0,0 +1,37 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// where CISA publishes its Known Exploited Vulnerabilities catalog and
+// FIRST the current EPSS scores
+const (
+	DefaultKEVURL  = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+	DefaultEPSSURL = "https://epss.cyentia.com/epss_scores-current.csv.gz"
+)
+
+// downloads url and adds it as name, e.g. the KEV catalog or the EPSS
+// scores; what is downloaded is added as it is
+func (w *Writer) AddURL(ctx context.Context, client *http.Client, url, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return w.AddBytes(name, data)
+}
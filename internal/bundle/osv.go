@@ -0,0 +1,71 @@
+package bundle
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// where OSV publishes a zip of every advisory of each ecosystem, as
+// <mirror>/<ecosystem>/all.zip
+const DefaultOSVMirror = "https://osv-vulnerabilities.storage.googleapis.com"
+
+// downloads the OSV advisories of an ecosystem, e.g. npm, PyPI or Go, and
+// adds them under name; it returns how many there were
+func (w *Writer) AddOSVEcosystem(ctx context.Context, client *http.Client, mirror, ecosystem, name string) (int, error) {
+	url := strings.TrimSuffix(mirror, "/") + "/" + ecosystem + "/all.zip"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s advisories: %w", ecosystem, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s advisories: %s returned %s", ecosystem, url, resp.Status)
+	}
+
+	// zip needs random access, so the archive goes to a temporary file
+	tmp, err := os.CreateTemp("", "gitguardian-osv-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s advisories: %w", ecosystem, err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s advisories archive: %w", ecosystem, err)
+	}
+	count := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !hasExt(f.Name, []string{".json"}) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return count, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return count, fmt.Errorf("invalid %s advisories archive: %w", ecosystem, err)
+		}
+		if err := w.AddBytes(path.Join(name, path.Base(f.Name)), data); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
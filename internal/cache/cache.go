@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stores values by namespace and key; namespaces separate users of the
+// cache such as "osv" lookups and incremental file hashes
+type Backend interface {
+	Get(namespace, key string) ([]byte, bool, error)
+	Set(namespace, key string, value []byte, ttl time.Duration) error
+	// removes a namespace, or everything when namespace is empty
+	Clear(namespace string) error
+	Stats() ([]Stats, error)
+	// persists pending writes
+	Close() error
+}
+
+// implemented by backends whose entries can be dropped on demand; shared
+// backends expire entries themselves
+type Pruner interface {
+	// removes expired entries and, when maxBytes is positive, the entries
+	// closest to expiry until the cache fits; returns how many it removed
+	Prune(maxBytes int64) (int, error)
+}
+
+// size information for one namespace
+type Stats struct {
+	Namespace string `json:"namespace"`
+	Entries   int    `json:"entries"`
+	Expired   int    `json:"expired"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// a view of a Backend restricted to one namespace
+type Store struct {
+	backend   Backend
+	namespace string
+}
+
+func NewStore(backend Backend, namespace string) *Store {
+	return &Store{backend: backend, namespace: namespace}
+}
+
+// returns a cached value; lookup errors are treated as misses
+func (s *Store) Get(key string) ([]byte, bool) {
+	if s == nil {
+		return nil, false
+	}
+	value, ok, err := s.backend.Get(s.namespace, key)
+	if err != nil {
+		return nil, false
+	}
+	return value, ok
+}
+
+// stores a value; a zero ttl never expires
+func (s *Store) Set(key string, value []byte, ttl time.Duration) error {
+	if s == nil {
+		return nil
+	}
+	return s.backend.Set(s.namespace, key, value, ttl)
+}
+
+// where and how a backend is reached
+type Options struct {
+	Backend string // file, memory, redis or http
+	Dir     string // file backend directory
+	URL     string // redis:// or http(s):// address of a shared cache
+	Token   string // bearer token for the http backend
+
+	// file backend size limit, enforced on Close; zero is unlimited
+	MaxBytes int64
+
+	// serve the file backend from disk without ever writing it back
+	ReadOnly bool
+}
+
+// opens the backend described by the options
+func Open(opts Options) (Backend, error) {
+	dir := opts.Dir
+
+	switch opts.Backend {
+	case "", "file":
+		if dir == "" {
+			var err error
+			if dir, err = DefaultDir(); err != nil {
+				return nil, err
+			}
+		}
+		f := NewFileBackend(dir)
+		f.maxBytes = opts.MaxBytes
+		f.readOnly = opts.ReadOnly
+		return f, nil
+	case "memory":
+		return NewMemoryBackend(), nil
+	case "redis":
+		return NewRedisBackend(opts.URL)
+	case "http":
+		if opts.URL == "" {
+			return nil, fmt.Errorf("http cache backend requires a url")
+		}
+		return NewHTTPBackend(opts.URL, opts.Token), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", opts.Backend)
+	}
+}
+
+// returns the per-user cache directory, e.g. ~/.cache/gitguardian
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(base, "gitguardian"), nil
+}
+
+// a cached value with its expiry
+type entry struct {
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
+func newEntry(value []byte, ttl time.Duration) entry {
+	e := entry{Value: value}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl)
+	}
+	return e
+}
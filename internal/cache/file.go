@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stores each namespace as a JSON file <dir>/<namespace>.db, loaded on
+// first use; changes are written atomically on Close
+type FileBackend struct {
+	dir      string
+	maxBytes int64
+	readOnly bool // changes are kept in memory and dropped on Close
+
+	mu     sync.Mutex
+	loaded map[string]map[string]entry
+	dirty  map[string]bool
+}
+
+var errReadOnly = fmt.Errorf("the cache is read-only (no_write)")
+
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{
+		dir:    dir,
+		loaded: make(map[string]map[string]entry),
+		dirty:  make(map[string]bool),
+	}
+}
+
+func (f *FileBackend) Get(namespace, key string) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, err := f.load(namespace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	e, ok := entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+func (f *FileBackend) Set(namespace, key string, value []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, err := f.load(namespace)
+	if err != nil {
+		return err
+	}
+
+	entries[key] = newEntry(value, ttl)
+	f.dirty[namespace] = true
+	return nil
+}
+
+// writes every changed namespace to disk, then trims the cache to its
+// size limit
+func (f *FileBackend) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.readOnly {
+		return nil
+	}
+	changed := len(f.dirty) > 0
+	if err := f.flush(); err != nil {
+		return err
+	}
+	if changed && f.maxBytes > 0 {
+		if _, err := f.prune(f.maxBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FileBackend) Prune(maxBytes int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.readOnly {
+		return 0, errReadOnly
+	}
+
+	if err := f.flush(); err != nil {
+		return 0, err
+	}
+	return f.prune(maxBytes)
+}
+
+// drops expired entries, then evicts the entries that expire first, never
+// expiring ones last, until the namespace files fit in maxBytes
+func (f *FileBackend) prune(maxBytes int64) (int, error) {
+	namespaces, err := f.namespaces()
+	if err != nil {
+		return 0, err
+	}
+
+	type candidate struct {
+		namespace, key string
+		expires        time.Time
+		size           int64
+	}
+	var (
+		candidates []candidate
+		total      int64
+		removed    int
+	)
+	now := time.Now()
+	for _, ns := range namespaces {
+		entries, err := f.load(ns)
+		if err != nil {
+			return removed, err
+		}
+		for key, e := range entries {
+			if e.expired(now) {
+				delete(entries, key)
+				f.dirty[ns] = true
+				removed++
+				continue
+			}
+			// the entry as it is written: quoted key, base64 value, expiry
+			size := int64(len(key) + base64.StdEncoding.EncodedLen(len(e.Value)) + 64)
+			candidates = append(candidates, candidate{ns, key, e.Expires, size})
+			total += size
+		}
+	}
+
+	if maxBytes > 0 && total > maxBytes {
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i].expires, candidates[j].expires
+			if a.IsZero() != b.IsZero() {
+				return b.IsZero()
+			}
+			return a.Before(b)
+		})
+		for _, c := range candidates {
+			if total <= maxBytes {
+				break
+			}
+			delete(f.loaded[c.namespace], c.key)
+			f.dirty[c.namespace] = true
+			total -= c.size
+			removed++
+		}
+	}
+
+	return removed, f.flush()
+}
+
+// writes the changed namespaces to disk; the caller holds the lock
+func (f *FileBackend) flush() error {
+	for namespace := range f.dirty {
+		if err := f.save(namespace, f.loaded[namespace]); err != nil {
+			return err
+		}
+		delete(f.dirty, namespace)
+	}
+	return nil
+}
+
+func (f *FileBackend) Clear(namespace string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.readOnly {
+		return errReadOnly
+	}
+
+	namespaces := []string{namespace}
+	if namespace == "" {
+		var err error
+		if namespaces, err = f.namespaces(); err != nil {
+			return err
+		}
+	}
+
+	for _, ns := range namespaces {
+		delete(f.loaded, ns)
+		delete(f.dirty, ns)
+		if err := os.Remove(f.path(ns)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *FileBackend) Stats() ([]Stats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	namespaces, err := f.namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var stats []Stats
+	for _, ns := range namespaces {
+		entries, err := f.load(ns)
+		if err != nil {
+			return nil, err
+		}
+
+		st := Stats{Namespace: ns}
+		if info, err := os.Stat(f.path(ns)); err == nil {
+			st.Bytes = info.Size()
+		}
+		for _, e := range entries {
+			st.Entries++
+			if e.expired(now) {
+				st.Expired++
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+func (f *FileBackend) path(namespace string) string {
+	return filepath.Join(f.dir, namespace+".db")
+}
+
+// lists the namespaces present on disk
+func (f *FileBackend) namespaces() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var namespaces []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".db") {
+			namespaces = append(namespaces, strings.TrimSuffix(e.Name(), ".db"))
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// returns the entries of a namespace, reading them from disk once
+func (f *FileBackend) load(namespace string) (map[string]entry, error) {
+	if entries, ok := f.loaded[namespace]; ok {
+		return entries, nil
+	}
+
+	entries := make(map[string]entry)
+	data, err := os.ReadFile(f.path(namespace))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+	if len(data) > 0 {
+		// a corrupt cache file is discarded rather than failing the scan
+		if err := json.Unmarshal(data, &entries); err != nil {
+			entries = make(map[string]entry)
+		}
+	}
+
+	f.loaded[namespace] = entries
+	return entries, nil
+}
+
+// writes a namespace to disk, dropping expired entries
+func (f *FileBackend) save(namespace string, entries map[string]entry) error {
+	now := time.Now()
+	for key, e := range entries {
+		if e.expired(now) {
+			delete(entries, key)
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, namespace+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path(namespace)); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stores entries in a shared HTTP cache service using a small protocol:
+//
+//	GET    {base}/{namespace}/{key}  200 with the value, 404 on a miss
+//	PUT    {base}/{namespace}/{key}  body is the value, X-Cache-TTL in seconds
+//	DELETE {base}/{namespace}        or {base}/ to clear everything
+//	GET    {base}/                   JSON array of Stats
+type HTTPBackend struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPBackend(base, token string) *HTTPBackend {
+	return &HTTPBackend{
+		base:   strings.TrimRight(base, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (h *HTTPBackend) url(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = url.PathEscape(p)
+	}
+	return h.base + "/" + strings.Join(escaped, "/")
+}
+
+func (h *HTTPBackend) request(method, target string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache request: %w", err)
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	return h.client.Do(req)
+}
+
+func (h *HTTPBackend) Get(namespace, key string) ([]byte, bool, error) {
+	resp, err := h.request(http.MethodGet, h.url(namespace, key), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("cache request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		value, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read cache response: %w", err)
+		}
+		return value, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("cache service returned status %d", resp.StatusCode)
+	}
+}
+
+func (h *HTTPBackend) Set(namespace, key string, value []byte, ttl time.Duration) error {
+	req, err := http.NewRequest(http.MethodPut, h.url(namespace, key), bytes.NewReader(value))
+	if err != nil {
+		return fmt.Errorf("failed to create cache request: %w", err)
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	if ttl > 0 {
+		req.Header.Set("X-Cache-TTL", strconv.Itoa(int(ttl.Seconds())))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cache request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("cache service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *HTTPBackend) Clear(namespace string) error {
+	target := h.base + "/"
+	if namespace != "" {
+		target = h.url(namespace)
+	}
+
+	resp, err := h.request(http.MethodDelete, target, nil)
+	if err != nil {
+		return fmt.Errorf("cache request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("cache service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *HTTPBackend) Stats() ([]Stats, error) {
+	resp, err := h.request(http.MethodGet, h.base+"/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("cache request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cache service returned status %d", resp.StatusCode)
+	}
+
+	var stats []Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to parse cache stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (h *HTTPBackend) Close() error {
+	return nil
+}
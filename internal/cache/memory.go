@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// keeps entries in process memory, e.g. for a long-running server
+type MemoryBackend struct {
+	mu   sync.Mutex
+	data map[string]map[string]entry
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{data: make(map[string]map[string]entry)}
+}
+
+func (m *MemoryBackend) Get(namespace, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.data[namespace][key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+func (m *MemoryBackend) Set(namespace, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.data[namespace] == nil {
+		m.data[namespace] = make(map[string]entry)
+	}
+	m.data[namespace][key] = newEntry(value, ttl)
+	return nil
+}
+
+func (m *MemoryBackend) Clear(namespace string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if namespace == "" {
+		m.data = make(map[string]map[string]entry)
+	} else {
+		delete(m.data, namespace)
+	}
+	return nil
+}
+
+func (m *MemoryBackend) Stats() ([]Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var stats []Stats
+	for namespace, entries := range m.data {
+		st := Stats{Namespace: namespace}
+		for _, e := range entries {
+			st.Entries++
+			st.Bytes += int64(len(e.Value))
+			if e.expired(now) {
+				st.Expired++
+			}
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Namespace < stats[j].Namespace })
+	return stats, nil
+}
+
+func (m *MemoryBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,284 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stores entries in a shared Redis server so many CI runners can reuse
+// each other's lookups; speaks just enough RESP to avoid a client library
+type RedisBackend struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// parses a redis://[:password@]host[:port][/db] URL
+func NewRedisBackend(rawURL string) (*RedisBackend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid redis URL: unsupported scheme %q", u.Scheme)
+	}
+
+	r := &RedisBackend{
+		addr:   u.Host,
+		prefix: "gitguardian:",
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *RedisBackend) key(namespace, key string) string {
+	return r.prefix + namespace + ":" + key
+}
+
+func (r *RedisBackend) Get(namespace, key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", r.key(namespace, key))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	return value, true, nil
+}
+
+func (r *RedisBackend) Set(namespace, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", r.key(namespace, key), string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+func (r *RedisBackend) Clear(namespace string) error {
+	pattern := r.prefix + "*"
+	if namespace != "" {
+		pattern = r.prefix + namespace + ":*"
+	}
+
+	keys, err := r.scan(pattern)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, err := r.do("DEL", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RedisBackend) Stats() ([]Stats, error) {
+	keys, err := r.scan(r.prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	byNamespace := make(map[string]*Stats)
+	var order []string
+	for _, key := range keys {
+		namespace, _, _ := strings.Cut(strings.TrimPrefix(key, r.prefix), ":")
+		st, ok := byNamespace[namespace]
+		if !ok {
+			st = &Stats{Namespace: namespace}
+			byNamespace[namespace] = st
+			order = append(order, namespace)
+		}
+		st.Entries++
+		if n, err := r.do("STRLEN", key); err == nil {
+			if size, ok := n.(int64); ok {
+				st.Bytes += size
+			}
+		}
+	}
+
+	stats := make([]Stats, 0, len(order))
+	for _, namespace := range order {
+		stats = append(stats, *byNamespace[namespace])
+	}
+	return stats, nil
+}
+
+func (r *RedisBackend) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// iterates SCAN to collect every key matching a pattern
+func (r *RedisBackend) scan(pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", pattern, "COUNT", "500")
+		if err != nil {
+			return nil, err
+		}
+
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected redis SCAN reply")
+		}
+		next, _ := parts[0].([]byte)
+		batch, _ := parts[1].([]interface{})
+		for _, k := range batch {
+			if key, ok := k.([]byte); ok {
+				keys = append(keys, string(key))
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+// sends one command and reads its reply, reconnecting on demand
+func (r *RedisBackend) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := r.roundTrip(args)
+	if err != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return reply, err
+}
+
+func (r *RedisBackend) connect() error {
+	conn, err := net.DialTimeout("tcp", r.addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+
+	if r.password != "" {
+		if _, err := r.roundTrip([]string{"AUTH", r.password}); err != nil {
+			conn.Close()
+			r.conn = nil
+			return fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := r.roundTrip([]string{"SELECT", strconv.Itoa(r.db)}); err != nil {
+			conn.Close()
+			r.conn = nil
+			return fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *RedisBackend) roundTrip(args []string) (interface{}, error) {
+	r.conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := r.conn.Write([]byte(b.String())); err != nil {
+		return nil, fmt.Errorf("redis write failed: %w", err)
+	}
+
+	return readRESP(r.rd)
+}
+
+// reads one RESP value: simple strings and bulk strings as []byte,
+// integers as int64, arrays as []interface{}, nil for null replies
+func readRESP(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis read failed: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, fmt.Errorf("redis error: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, fmt.Errorf("redis read failed: %w", err)
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply: %q", line)
+	}
+}
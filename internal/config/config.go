@@ -1,11 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/vcs"
 )
 
 // holds all configuration for the tool
@@ -13,28 +20,385 @@ type Config struct {
 	// general settings
 	Verbose bool `json:"verbose"`
 
+	// diagnostics on stderr: log_level is debug, info, warn or error
+	// (verbose means debug), log_format is text or json
+	LogLevel  string `json:"log_level"`
+	LogFormat string `json:"log_format"`
+
+	// where the scanner, hooks and commands log; see SetupLogger
+	Logger *slog.Logger `json:"-"`
+
+	// language of the text report and hook messages: en, es or de; empty
+	// takes it from LC_ALL, LC_MESSAGES or LANG
+	Language string `json:"language"`
+
+	// never output secret plaintext: secrets are fully masked and issue
+	// content taken from scanned files is dropped
+	NoPlaintext bool `json:"no_plaintext"`
+
 	// secret scanning configuration
 	SecretPatterns []SecretPattern `json:"secret_patterns"`
-	Whitelist      []string        `json:"whitelist"`
-	MaxFileSize    int64           `json:"max_file_size"`
+	RuleFiles      []string        `json:"rule_files"`     // globs of rule packs, relative to the config file
+	GitleaksRules  string          `json:"gitleaks_rules"` // gitleaks TOML rules file, relative to the config file
+	DisabledRules  []string        `json:"disabled_rules"` // rule names to turn off, e.g. built-ins
+
+	// use patterns CheckPattern finds unsafe instead of refusing to load
+	AllowUnsafePatterns bool `json:"allow_unsafe_patterns"`
+
+	Whitelist   []WhitelistEntry `json:"whitelist"`
+	MaxFileSize int64            `json:"max_file_size"`
+
+	// secret matches reported per file; past it the rest, as a generated
+	// fixtures file can hold thousands, are reported as one issue counting
+	// them. 0 reports every match.
+	MaxFindingsPerFile int `json:"max_findings_per_file"`
+
+	// globs scoping every scan to matching paths, e.g. "src/**", and
+	// leaving others out, e.g. "testdata/**"; relative to the scanned
+	// directory or repository, and extended by -include and -exclude
+	IncludePaths []string `json:"include_paths"`
+	ExcludePaths []string `json:"exclude_paths"`
+
+	// skip what git ignores in path scans: each directory's .gitignore,
+	// .git/info/exclude and the global core.excludesFile
+	RespectGitignore bool `json:"respect_gitignore"`
+
+	// version control of the scanned working copy: git, hg, svn, none, or
+	// empty or auto to detect it. -changed asks it which files changed;
+	// outside git, what needs git (hooks, -staged, -rev, Git LFS) is off.
+	VCS string `json:"vcs"`
+
+	// report a secret found in several places once, listing the repeats
+	// under "duplicates"
+	Deduplicate bool `json:"deduplicate"`
+
+	// in staged scans, only report findings on the lines the commit adds
+	// or modifies, not those already in the files it touches
+	DiffOnly bool `json:"diff_only"`
+
+	// matches whose secret is clearly a placeholder, e.g. <YOUR_API_KEY>
+	Placeholders PlaceholderConfig `json:"placeholders"`
+
+	// entropy analysis for secrets no pattern describes
+	Entropy EntropyConfig `json:"entropy"`
+
+	// checks detected credentials against provider APIs
+	Verify VerifyConfig `json:"verify"`
+
+	// built-in ignores for checksum lines in lockfiles
+	LockfileIgnores LockfileIgnoreConfig `json:"lockfile_ignores"`
+
+	// signature checks on pushed commits
+	Signatures SignatureConfig `json:"signatures"`
+
+	// credentials and internal URLs in the metadata of images and
+	// documents (EXIF, XMP, PNG text, PDF info, Office and OpenDocument
+	// properties), which are otherwise skipped as binary
+	Metadata MetadataConfig `json:"metadata"`
+
+	// opt-in extraction of the text of PDF, docx, xlsx and pptx files, so
+	// credentials in committed runbooks are found
+	Documents DocumentConfig `json:"documents"`
+
+	// files stored in Git LFS, which a checkout without git-lfs, a
+	// revision scan and a staged scan only see as pointers
+	LFS LFSConfig `json:"lfs"`
+
+	// binaries, executables and archives added by pushed commits
+	Binaries BinaryConfig `json:"binaries"`
 
 	// dependency scanning
 	DependencyAPIs DependencyConfig `json:"dependency_apis"`
 
+	// base images of Dockerfiles and docker-compose services
+	Images ImageConfig `json:"images"`
+
 	// social engineering detection
 	SocialEngineering SocialConfig `json:"social_engineering"`
 
+	// local or shared cache used across scans
+	Cache CacheConfig `json:"cache"`
+
+	// detectors to skip by name (secrets, entropy, dependencies, images, social)
+	DisabledDetectors []string `json:"disabled_detectors"`
+
+	// remembers findings across scans to flag new ones
+	Findings FindingsConfig `json:"findings"`
+
+	// where findings are sent after a scan
+	Notify NotifyConfig `json:"notify"`
+
+	// where "gitguardian feedback" sends anonymized triage decisions
+	Feedback FeedbackConfig `json:"feedback"`
+
+	// lowest severity that makes the scan exit non-zero; empty means any
+	FailOn string `json:"fail_on"`
+
 	// performance settings
 	MaxConcurrency int `json:"max_concurrency"`
+
+	// where state and temporary clones go: the findings store defaults to
+	// state_dir, manifest clones to temp_dir; empty means the cache
+	// directory and the system temp directory
+	StateDir string `json:"state_dir"`
+	TempDir  string `json:"temp_dir"`
+
+	// never write to disk, for read-only containers: the file cache is
+	// only read, the findings store is not updated and -fix is refused
+	NoWrite bool `json:"no_write"`
+
+	// the key secret hashes and fingerprints are an HMAC with, so a report
+	// cannot be checked against guessed secrets; overridden by
+	// GITGUARDIAN_SECRET_HASH_KEY. Empty means DefaultSecretHashKey, which
+	// is the same everywhere; set it, e.g. from a CI secret shared by every
+	// runner, to keep fingerprints private and still matching across
+	// machines.
+	SecretHashKey string `json:"secret_hash_key"`
+
+	// hash with a key generated for this installation and kept in the state
+	// directory instead, when secret_hash_key is not set; fingerprints then
+	// match no other machine's, so leave it off for sharded scans, shared
+	// caches and findings stores of ephemeral CI runners
+	InstallationHashKey bool `json:"installation_hash_key"`
+
+	// the installation's key, read by Load when InstallationHashKey is set
+	installationKey string
+
+	// the config file, rule packs and gitleaks rules this was loaded from
+	files []string
 }
 
 // defines a pattern to match secrets
 type SecretPattern struct {
-	Name        string `json:"name"`
-	Pattern     string `json:"pattern"`
-	Description string `json:"description"`
-	Severity    string `json:"severity"` // low, medium, high, critical
-	compiled    *regexp.Regexp
+	Name        string   `json:"name"`
+	Pattern     string   `json:"pattern"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"` // low, medium, high, critical
+	Tags        []string `json:"tags"`     // the first tag is the category: cloud, vcs, database, token, pii, generic
+
+	// shown with every finding of the rule: how to rotate or revoke the
+	// credential, documentation on it, and who maintains the rule
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+
+	// optional refinements, as found in gitleaks rules
+	Keywords    []string   `json:"keywords,omitempty"`     // only lines containing one of these are matched
+	SecretGroup int        `json:"secret_group,omitempty"` // capture group holding the secret
+	Entropy     float64    `json:"entropy,omitempty"`      // minimum Shannon entropy of the secret
+	Allowlist   *Allowlist `json:"allowlist,omitempty"`
+
+	// text the rule must and must not report, checked by "config validate"
+	PositiveExamples []string `json:"positive_examples,omitempty"`
+	NegativeExamples []string `json:"negative_examples,omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// matches that a pattern reports anyway are dropped when they hit the
+// allowlist. Unlike the whitelist, it only applies to its rule, and its
+// regexes can be anchored, e.g. "EXAMPLE$" or "^0+$".
+type Allowlist struct {
+	Regexes   []string `json:"regexes,omitempty"`   // matched against RegexTarget
+	Paths     []string `json:"paths,omitempty"`     // matched against the file path
+	Stopwords []string `json:"stopwords,omitempty"` // substrings of the secret
+
+	// what Regexes are matched against: the secret (default), the whole
+	// match of the pattern, or the line it is on
+	RegexTarget string `json:"regex_target,omitempty"`
+
+	regexes []*regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// accepts a list of regexes as well, short for {"regexes": [...]}
+func (a *Allowlist) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var regexes []string
+		if err := json.Unmarshal(trimmed, &regexes); err != nil {
+			return err
+		}
+		*a = Allowlist{Regexes: regexes}
+		return nil
+	}
+	type plain Allowlist
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// a value whose matches are not reported, matched as a substring ignoring
+// case. With Paths, it only applies in files matching one of the globs,
+// e.g. "docs/**" or "**/*_test.go", so example credentials in test
+// fixtures are not reported while the same values elsewhere still are.
+type WhitelistEntry struct {
+	Value string   `json:"value"`
+	Paths []string `json:"paths,omitempty"` // relative to the scanned directory or repository
+}
+
+// accepts a plain string as well, an entry for every path. The entry is
+// cleared first: decoded over the defaults, it would otherwise keep the
+// value of the default at its index. Unknown fields are refused, as a
+// misspelled "value" would leave an empty entry matching everything.
+func (e *WhitelistEntry) UnmarshalJSON(data []byte) error {
+	*e = WhitelistEntry{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		return json.Unmarshal(trimmed, &e.Value)
+	}
+	type plain WhitelistEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*plain)(e))
+}
+
+// writes an entry without paths as a plain string
+func (e WhitelistEntry) MarshalJSON() ([]byte, error) {
+	if len(e.Paths) == 0 {
+		return json.Marshal(e.Value)
+	}
+	type plain WhitelistEntry
+	return json.Marshal(plain(e))
+}
+
+// names the entry in whitelist statistics and logs: its value, and the
+// paths it is scoped to
+func (e WhitelistEntry) String() string {
+	if len(e.Paths) == 0 {
+		return e.Value
+	}
+	return e.Value + " (in " + strings.Join(e.Paths, ", ") + ")"
+}
+
+func (e WhitelistEntry) validate() error {
+	if e.Value == "" {
+		return fmt.Errorf("missing value")
+	}
+	for _, glob := range e.Paths {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid path %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// tunes the entropy detector, which is off unless enabled; thresholds are
+// in bits per character
+type EntropyConfig struct {
+	Enabled         bool     `json:"enabled"`
+	Base64Threshold float64  `json:"base64_threshold"`
+	HexThreshold    float64  `json:"hex_threshold"`
+	MinLength       int      `json:"min_length"`
+	ExcludePaths    []string `json:"exclude_paths"` // globs, e.g. lockfiles full of hashes
+}
+
+// recognizes placeholder values such as <YOUR_API_KEY>, ${VAR}, %s,
+// changeme or xxxxxxxx by built-in heuristics and the regexes in Patterns;
+// matches are reported as low, or suppressed with "action": "suppress"
+type PlaceholderConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Patterns []string `json:"patterns"` // matched against the secret
+	Action   string   `json:"action"`   // downgrade (default) or suppress
+
+	compiled []*regexp.Regexp
+}
+
+// locates the findings store
+type FindingsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // defaults to findings.json in the cache directory
+
+	// retention, applied whenever the store is saved: records not seen for
+	// max_age ("90d", "720h") are dropped, and beyond max_records the least
+	// recently seen go
+	MaxAge     string `json:"max_age"`
+	MaxRecords int    `json:"max_records"`
+}
+
+// selects notification sinks and what they receive
+type NotifyConfig struct {
+	OnlyNew     bool         `json:"only_new"`     // only findings the store has not seen before
+	MinSeverity string       `json:"min_severity"` // low, medium, high or critical
+	Sinks       []SinkConfig `json:"sinks"`
+
+	// deliveries that failed, kept for later runs to retry
+	Queue NotifyQueueConfig `json:"queue"`
+}
+
+// keeps notifications a sink could not take, so that an outage during a
+// scan delays them rather than dropping them
+type NotifyQueueConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // defaults to notify-queue.json in state_dir or the cache directory
+
+	// a delivery still failing after max_attempts tries, or older than
+	// max_age ("7d", "48h"), is moved to the dead letters
+	MaxAttempts int    `json:"max_attempts"`
+	MaxAge      string `json:"max_age"`
+}
+
+// one notification target
+type SinkConfig struct {
+	Type string `json:"type"` // webhook or slack
+	URL  string `json:"url"`
+}
+
+// an endpoint collecting triage decisions for rule tuning; nothing is
+// sent without one
+type FeedbackConfig struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token"` // bearer token, if the endpoint needs one
+}
+
+// turns the per-ecosystem lockfile ignores on or off
+type LockfileIgnoreConfig struct {
+	Enabled bool     `json:"enabled"`
+	Except  []string `json:"except"` // ecosystems to scan fully again: npm, go, cargo, python, php, ruby, nuget
+}
+
+// requires commits in a pushed range to be signed by allowed keys
+type SignatureConfig struct {
+	Enabled     bool     `json:"enabled"`
+	AllowedKeys []string `json:"allowed_keys"` // GPG fingerprints or key ids, SSH SHA256 fingerprints; empty allows any valid key
+	// file in ssh-keygen's allowed signers format, needed to check SSH
+	// signatures; defaults to git's gpg.ssh.allowedSignersFile
+	AllowedSignersFile string `json:"allowed_signers_file"`
+	Severity           string `json:"severity"`
+}
+
+type MetadataConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// MaxTextSize limits the text taken from one document, 1MB when 0
+type DocumentConfig struct {
+	Enabled     bool  `json:"enabled"`
+	MaxTextSize int64 `json:"max_text_size"`
+}
+
+// scans the objects Git LFS pointers stand for, within max_file_size:
+// those already in the local LFS store, and with Fetch those that git lfs
+// smudge downloads; otherwise pointers are skipped as lfs_pointer
+type LFSConfig struct {
+	Enabled bool `json:"enabled"`
+	Fetch   bool `json:"fetch"`
+}
+
+// flags binary files that pushed commits add, when enabled (off by
+// default, or -check-binaries); executables are always flagged, other
+// binaries when over MaxSize or of a listed type
+type BinaryConfig struct {
+	Enabled    bool     `json:"enabled"`
+	MaxSize    int64    `json:"max_size"`   // bytes; 0 flags no binary by size alone
+	Extensions []string `json:"extensions"` // archive and executable types, e.g. ".zip"
+	Allow      []string `json:"allow"`      // globs of paths never flagged
+}
+
+// selects which providers secrets are verified against
+type VerifyConfig struct {
+	Enabled bool `json:"enabled"`
+	Timeout int  `json:"timeout"` // seconds per request
+	Workers int  `json:"workers"` // concurrent requests, apart from max_concurrency
+	AWS     bool `json:"aws"`
+	GitHub  bool `json:"github"`
+	Slack   bool `json:"slack"`
 }
 
 // holds API configuration for vulnerability scanning
@@ -44,6 +408,79 @@ type DependencyConfig struct {
 	GitHubToken   string `json:"github_token"`
 	CacheEnabled  bool   `json:"cache_enabled"`
 	CacheDuration int    `json:"cache_duration"` // hours
+	OfflineDB     string `json:"offline_db"`     // file or directory of OSV advisories
+	DirectOnly    bool   `json:"direct_only"`    // skip lockfiles and indirect go.mod requirements
+
+	OSVWorkers   int     `json:"osv_workers"`    // concurrent OSV requests
+	OSVRateLimit float64 `json:"osv_rate_limit"` // OSV requests per second, 0 for no limit
+
+	// packages of private registries and module prefixes, which public
+	// advisory databases do not know: they skip the vulnerability lookup,
+	// or are looked up in an internal advisory endpoint instead
+	PrivatePackages []PrivatePackages `json:"private_packages"`
+
+	// internal advisories, e.g. known-bad versions of the organization's
+	// own libraries, merged with the public sources
+	AdvisoryFeeds []AdvisoryFeed `json:"advisory_feeds"`
+
+	// CISA's Known Exploited Vulnerabilities catalog (JSON) and FIRST's
+	// EPSS scores (CSV, gzipped or not), relative to the config file; they
+	// mark vulnerability findings known_exploited and give their epss
+	KEVFile  string `json:"kev_file"`
+	EPSSFile string `json:"epss_file"`
+}
+
+// a feed of advisories in OSV format or the simple format, one JSON file
+// or a directory of them, or fetched from a URL at every scan
+type AdvisoryFeed struct {
+	Path  string `json:"path"` // file or directory, relative to the config file
+	URL   string `json:"url"`
+	Token string `json:"token"` // bearer token for URL
+}
+
+// a set of private packages. Names are globs in which * matches any run of
+// characters, slashes included, e.g. "@myorg/*" or "git.example.com/*";
+// they are matched ignoring case.
+type PrivatePackages struct {
+	Ecosystem string   `json:"ecosystem"` // npm, Go, PyPI, Maven...; empty for any
+	Names     []string `json:"names"`
+
+	// an OSV-compatible querybatch URL to look the packages up in; empty
+	// skips the lookup
+	Advisories string `json:"advisories"`
+	Token      string `json:"token"` // bearer token for Advisories
+
+	compiled []*regexp.Regexp
+}
+
+// selects where cached data is kept
+type CacheConfig struct {
+	Backend string `json:"backend"` // file (default), memory, redis or http
+	Dir     string `json:"dir"`     // defaults to the user cache directory
+	URL     string `json:"url"`     // shared cache address for redis/http
+	Token   string `json:"token"`   // bearer token for the http backend
+
+	// reuse the findings of files unchanged since an earlier scan
+	Incremental bool `json:"incremental"`
+
+	// file backend size limit in megabytes; the entries closest to expiry
+	// are evicted when a scan leaves the cache larger
+	MaxSizeMB int `json:"max_size_mb"`
+}
+
+// checks container base images against known-bad lists and OSV
+type ImageConfig struct {
+	Enabled  bool        `json:"enabled"`
+	KnownBad []ImageRule `json:"known_bad"` // checked along with the built-in end-of-life list
+}
+
+// flags tags of an image; Versions is a constraint list such as "< 18",
+// and an empty one matches every tag
+type ImageRule struct {
+	Image    string `json:"image"` // e.g. "node" or "registry.example.com/team/base"
+	Versions string `json:"versions"`
+	Reason   string `json:"reason"`
+	Severity string `json:"severity"`
 }
 
 // holds social engineering detection settings
@@ -51,25 +488,53 @@ type SocialConfig struct {
 	Enabled              bool     `json:"enabled"`
 	SuspiciousKeywords   []string `json:"suspicious_keywords"`
 	RequireJustification bool     `json:"require_justification"`
+	AuditLog             string   `json:"audit_log"` // defaults to <git dir>/gitguardian/audit.jsonl
 }
 
-// loads configuration from file or returns default config
+// loads configuration from file or returns default config. The ignore
+// lists of a legacy .gitguardian.yml in the working directory, or given
+// as the config file, merge into disabled_rules and exclude_paths, so a
+// repository that has both keeps both applying; a .gitguardian.yml of
+// another tool, without those lists, is not read.
 func Load(configPath string) (*Config, error) {
+	return load(configPath, true)
+}
+
+// loads only the configuration file given, for embedding: no legacy file
+// in the working directory merges in, none is searched for when path is
+// empty, and nothing is written, so an installation_hash_key not created
+// yet is an error and no_write is set on the result
+func LoadFile(path string) (*Config, error) {
+	cfg, err := load(path, false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.NoWrite = true
+	return cfg, nil
+}
+
+// loads configPath, and with discover the files Load finds by itself
+func load(configPath string, discover bool) (*Config, error) {
 	cfg := DefaultConfig()
 
-	if configPath == "" {
-		// try to find config in common locations
-		possiblePaths := []string{
-			".gitguardian.json",
-			"gitguardian.json",
-			filepath.Join(os.Getenv("HOME"), ".gitguardian.json"),
+	legacyPath := ""
+	if IsLegacyFile(configPath) {
+		legacyPath, configPath = configPath, ""
+	} else if discover {
+		legacyPath = FindLegacy(".")
+		if configPath == "" {
+			configPath = FindFile()
 		}
-
-		for _, path := range possiblePaths {
-			if _, err := os.Stat(path); err == nil {
-				configPath = path
-				break
-			}
+	}
+	var legacy *LegacyConfig
+	if legacyPath != "" {
+		var err error
+		if legacy, err = LoadLegacy(legacyPath); err != nil {
+			return nil, err
+		}
+		if len(legacy.Unknown) > 0 {
+			cfg.Log().Warn("ignoring legacy configuration settings other than ignore_rules and ignore_paths",
+				"file", legacyPath, "settings", strings.Join(legacy.Unknown, ", "))
 		}
 	}
 
@@ -79,93 +544,340 @@ func Load(configPath string) (*Config, error) {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 
+		// secret_patterns replaces the built-in rules; decoded over them, a
+		// rule would keep every field it leaves out, such as a description
+		// or remediation, from the built-in at its index
+		var settings map[string]json.RawMessage
+		if json.Unmarshal(data, &settings) == nil && settings["secret_patterns"] != nil {
+			cfg.SecretPatterns = nil
+		}
 		if err := json.Unmarshal(data, cfg); err != nil {
 			return nil, fmt.Errorf("failed to parse config file: %w", err)
 		}
+		cfg.files = append(cfg.files, configPath)
+		if legacy != nil {
+			cfg.MergeLegacy(legacy)
+			cfg.files = append(cfg.files, legacyPath)
+		}
+
+		if err := cfg.loadRuleFiles(filepath.Dir(configPath)); err != nil {
+			return nil, err
+		}
+		if cfg.GitleaksRules != "" {
+			path := cfg.GitleaksRules
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(configPath), path)
+			}
+			rules, err := LoadGitleaksRules(path)
+			if err != nil {
+				return nil, err
+			}
+			cfg.files = append(cfg.files, path)
+			cfg.SecretPatterns = append(cfg.SecretPatterns, rules...)
+		}
+		if err := cfg.SelectPatterns(nil, cfg.DisabledRules); err != nil {
+			if legacy != nil {
+				return nil, fmt.Errorf("invalid disabled_rules, or ignore_rules in %s: %w", legacyPath, err)
+			}
+			return nil, fmt.Errorf("invalid disabled_rules: %w", err)
+		}
 
 		// compile patterns
 		if err := cfg.CompilePatterns(); err != nil {
 			return nil, fmt.Errorf("failed to compile patterns: %w", err)
 		}
+
+		if err := cfg.Placeholders.compile(); err != nil {
+			return nil, fmt.Errorf("invalid placeholders: %w", err)
+		}
+
+		if !vcs.Valid(cfg.VCS) {
+			return nil, fmt.Errorf("invalid vcs %q: use auto, git, hg, svn or none", cfg.VCS)
+		}
+
+		if _, err := ParseAge(cfg.Findings.MaxAge); err != nil {
+			return nil, fmt.Errorf("invalid findings.max_age: %w", err)
+		}
+		if _, err := ParseAge(cfg.Notify.Queue.MaxAge); err != nil {
+			return nil, fmt.Errorf("invalid notify.queue.max_age: %w", err)
+		}
+		for i, entry := range cfg.Whitelist {
+			if err := entry.validate(); err != nil {
+				return nil, fmt.Errorf("invalid whitelist[%d]: %w", i, err)
+			}
+		}
+		for i := range cfg.DependencyAPIs.AdvisoryFeeds {
+			feed := &cfg.DependencyAPIs.AdvisoryFeeds[i]
+			if err := feed.validate(); err != nil {
+				return nil, fmt.Errorf("invalid dependency_apis.advisory_feeds[%d]: %w", i, err)
+			}
+			if feed.Path != "" && !filepath.IsAbs(feed.Path) {
+				feed.Path = filepath.Join(filepath.Dir(configPath), feed.Path)
+			}
+		}
+		for _, file := range []*string{&cfg.DependencyAPIs.KEVFile, &cfg.DependencyAPIs.EPSSFile} {
+			if *file != "" && !filepath.IsAbs(*file) {
+				*file = filepath.Join(filepath.Dir(configPath), *file)
+			}
+		}
+		for i := range cfg.DependencyAPIs.PrivatePackages {
+			if err := cfg.DependencyAPIs.PrivatePackages[i].compile(); err != nil {
+				return nil, fmt.Errorf("invalid dependency_apis.private_packages[%d]: %w", i, err)
+			}
+		}
+		if err := cfg.loadInstallationKey(!discover); err != nil {
+			return nil, err
+		}
+	} else if legacy != nil {
+		cfg.MergeLegacy(legacy)
+		cfg.files = append(cfg.files, legacyPath)
+		if err := cfg.SelectPatterns(nil, cfg.DisabledRules); err != nil {
+			return nil, fmt.Errorf("invalid ignore_rules in %s: %w", legacyPath, err)
+		}
 	}
 
 	return cfg, nil
 }
 
+// returns the configuration file Load reads when given none, or "" if
+// there is none
+func FindFile() string {
+	for _, path := range []string{
+		".gitguardian.json",
+		"gitguardian.json",
+		filepath.Join(os.Getenv("HOME"), ".gitguardian.json"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// returns the files the configuration was loaded from, the config file
+// first; it is empty for the defaults
+func (c *Config) Files() []string {
+	return c.files
+}
+
+// appends the patterns of every rule pack matched by RuleFiles; a pack is
+// a JSON list of patterns or an object with a "rules" list
+func (c *Config) loadRuleFiles(baseDir string) error {
+	for _, pattern := range c.RuleFiles {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid rule_files pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("rule_files pattern %q matches no files", pattern)
+		}
+
+		for _, file := range matches {
+			rules, err := LoadRuleFile(file)
+			if err != nil {
+				return err
+			}
+			c.files = append(c.files, file)
+			c.SecretPatterns = append(c.SecretPatterns, rules...)
+		}
+	}
+	return nil
+}
+
+// reads a rule pack, a JSON list of patterns or an object with a "rules"
+// list; the patterns are not compiled
+func LoadRuleFile(file string) ([]SecretPattern, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule file: %w", err)
+	}
+
+	var pack struct {
+		Rules []SecretPattern `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &pack.Rules); err != nil {
+		if err := json.Unmarshal(data, &pack); err != nil {
+			return nil, fmt.Errorf("failed to parse rule file %s: %w", file, err)
+		}
+	}
+
+	for i := range pack.Rules {
+		if pack.Rules[i].Name == "" || pack.Rules[i].Pattern == "" {
+			return nil, fmt.Errorf("rule file %s: every rule needs a name and a pattern", file)
+		}
+		if pack.Rules[i].Severity == "" {
+			pack.Rules[i].Severity = "high"
+		}
+	}
+	return pack.Rules, nil
+}
+
 // returns a default configuration with compiled patterns
 func DefaultConfig() *Config {
 	cfg := &Config{
-		Verbose:        false,
-		MaxFileSize:    10 * 1024 * 1024, // 10MB
-		MaxConcurrency: 4,
+		Verbose:            false,
+		MaxFileSize:        10 * 1024 * 1024, // 10MB
+		MaxFindingsPerFile: 100,
+		MaxConcurrency:     4,
 		SecretPatterns: []SecretPattern{
 			{
 				Name:        "AWS Access Key",
 				Pattern:     `AKIA[0-9A-Z]{16}`,
 				Description: "Amazon Web Services Access Key",
 				Severity:    "critical",
+				Tags:        []string{"cloud"},
+				Remediation: "Deactivate the key in IAM, create a replacement and update its users, then delete the old key; review CloudTrail for its use meanwhile.",
+				References:  []string{"https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_access-keys.html#rotating_access_keys_console"},
 			},
 			{
 				Name:        "AWS Secret Key",
 				Pattern:     `aws_secret_access_key\s*=\s*["\']?([A-Za-z0-9+/]{40})["\']?`,
 				Description: "Amazon Web Services Secret Key",
 				Severity:    "critical",
+				Tags:        []string{"cloud"},
+				Remediation: "Deactivate the access key it belongs to in IAM, create a replacement and update its users, then delete the old key; review CloudTrail for its use meanwhile.",
+				References:  []string{"https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_access-keys.html#rotating_access_keys_console"},
 			},
 			{
 				Name:        "GitHub Token",
 				Pattern:     `ghp_[A-Za-z0-9]{36}`,
 				Description: "GitHub Personal Access Token",
 				Severity:    "high",
+				Tags:        []string{"vcs"},
+				Remediation: "Revoke the token in Settings > Developer settings > Personal access tokens and create a new one with only the scopes needed, or use a GitHub App or the workflow GITHUB_TOKEN.",
+				References:  []string{"https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/token-expiration-and-revocation"},
 			},
 			{
 				Name:        "GitHub Classic Token",
 				Pattern:     `[0-9a-f]{40}`,
 				Description: "GitHub Classic Personal Access Token",
 				Severity:    "high",
+				Tags:        []string{"vcs"},
+				Remediation: "If this is a token, revoke it in Settings > Developer settings > Personal access tokens and create a fine-grained one with only the permissions needed.",
+				References:  []string{"https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/token-expiration-and-revocation"},
 			},
 			{
 				Name:        "Slack Token",
 				Pattern:     `xox[baprs]-[0-9a-zA-Z\-]+`,
 				Description: "Slack API Token",
 				Severity:    "high",
+				Tags:        []string{"token"},
+				Remediation: "Revoke the token with auth.revoke or by regenerating it in the app settings, and reinstall the app where it is needed.",
+				References:  []string{"https://api.slack.com/methods/auth.revoke"},
 			},
 			{
 				Name:        "Generic API Key",
+				Pattern:     `([A-Za-z0-9]{32})`,
 				Description: "Generic alphanumeric API key",
-				Severity:    "high",              // or whatever your tests expect
-				Pattern:     `([A-Za-z0-9]{32})`, // adjust to the test’s exact regex
+				Severity:    "high",
+				Tags:        []string{"generic"},
+				Remediation: "Find the service the key belongs to, revoke it there and issue a new one, and read it from the environment or a secret manager instead of the source.",
 			},
 			{
 				Name:        "Generic Password",
 				Pattern:     `[Pp][Aa][Ss][Ss][Ww][Oo][Rr][Dd]\s*[:=]\s*["\']?([^"\'\s]{8,})["\']?`,
 				Description: "Generic Password Pattern",
 				Severity:    "medium",
+				Tags:        []string{"generic"},
+				Remediation: "Change the password wherever it is used, and read it from the environment or a secret manager instead of the source.",
 			},
 			{
 				Name:        "JWT Token",
 				Pattern:     `eyJ[A-Za-z0-9_\-]*\.eyJ[A-Za-z0-9_\-]*\.[A-Za-z0-9_\-]*`,
 				Description: "JSON Web Token",
 				Severity:    "medium",
+				Tags:        []string{"generic"},
+				Remediation: "Treat the token as leaked: revoke the session it belongs to, or rotate the signing key if it does not expire soon.",
+			},
+			{
+				Name:        "Database Connection String",
+				Pattern:     `(?i)\b(?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|amqp)://[^\s:@/]+:([^\s@/]+)@`,
+				Description: "Database URL with embedded credentials",
+				Severity:    "high",
+				Tags:        []string{"database"},
+				Remediation: "Change the database user's password, check the database logs for unknown clients, and read the connection string from the environment or a secret manager.",
+			},
+			{
+				Name:        "US Social Security Number",
+				Pattern:     `(?i)\b(?:ssn|social[_ .-]?security(?:[_ .-]?(?:number|no))?)\b["\']?\s*[:=]\s*["\']?(\d{3}-\d{2}-\d{4})\b`,
+				Description: "US Social Security Number assigned to an SSN field",
+				Severity:    "medium",
+				Tags:        []string{"pii"},
+				Remediation: "Remove the personal data from the repository and its history, and follow your data protection incident process.",
 			},
 			{
 				Name:        "Private Key",
 				Pattern:     `-----BEGIN\s+(RSA\s+)?PRIVATE KEY-----`,
 				Description: "Private Key",
 				Severity:    "critical",
+				Tags:        []string{"generic"},
+				Remediation: "Replace the key pair: revoke certificates issued for it, remove the public key from authorized_keys and deploy keys, and use a newly generated key.",
 			},
 		},
-		Whitelist: []string{
-			"example.com",
-			"localhost",
-			"127.0.0.1",
-			"test",
-			"demo",
-			"sample",
+		Whitelist: []WhitelistEntry{
+			{Value: "example.com"},
+			{Value: "localhost"},
+			{Value: "127.0.0.1"},
+			{Value: "test"},
+			{Value: "demo"},
+			{Value: "sample"},
+		},
+		Deduplicate: true,
+		Notify: NotifyConfig{
+			Queue: NotifyQueueConfig{
+				Enabled:     true,
+				MaxAttempts: 10,
+				MaxAge:      "7d",
+			},
+		},
+		Metadata: MetadataConfig{
+			Enabled: true,
+		},
+		Placeholders: PlaceholderConfig{
+			Enabled: true,
+		},
+		Entropy: EntropyConfig{
+			Enabled:         false,
+			Base64Threshold: 4.5,
+			HexThreshold:    3.0,
+			MinLength:       20,
+			ExcludePaths: []string{
+				"*.min.js",
+				"*.svg",
+			},
+		},
+		LockfileIgnores: LockfileIgnoreConfig{
+			Enabled: true,
+		},
+		Signatures: SignatureConfig{
+			Severity: "high",
+		},
+		Binaries: BinaryConfig{
+			MaxSize: 5 * 1024 * 1024, // 5MB
+			Extensions: []string{
+				".exe", ".dll", ".so", ".dylib", ".bin", ".msi", ".apk", ".dmg",
+				".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar", ".jar", ".war",
+			},
+		},
+		Verify: VerifyConfig{
+			Timeout: 10,
+			Workers: 4,
+			AWS:     true,
+			GitHub:  true,
+			Slack:   true,
 		},
 		DependencyAPIs: DependencyConfig{
 			OSVEnabled:    true,
 			CacheEnabled:  true,
 			CacheDuration: 24,
+			OSVWorkers:    4,
+		},
+		Images: ImageConfig{
+			Enabled: true,
 		},
 		SocialEngineering: SocialConfig{
 			Enabled: true,
@@ -196,15 +908,204 @@ func DefaultConfig() *Config {
 // compiles all regex patterns
 func (c *Config) CompilePatterns() error {
 	for i := range c.SecretPatterns {
-		compiled, err := regexp.Compile(c.SecretPatterns[i].Pattern)
+		if err := c.SecretPatterns[i].Compile(); err != nil {
+			return err
+		}
+		if err := c.checkPattern(c.SecretPatterns[i].Name, c.SecretPatterns[i].Pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compiles the pattern and its allowlist, without the safety checks of
+// CheckPattern
+func (p *SecretPattern) Compile() error {
+	compiled, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile pattern '%s': %w", p.Name, err)
+	}
+	p.compiled = compiled
+	if err := p.Allowlist.compile(); err != nil {
+		return fmt.Errorf("failed to compile allowlist of '%s': %w", p.Name, err)
+	}
+	return nil
+}
+
+func (a *Allowlist) compile() error {
+	if a == nil {
+		return nil
+	}
+	switch a.RegexTarget {
+	case "", "secret", "match", "line":
+	default:
+		return fmt.Errorf("unknown regex_target %q: use secret, match or line", a.RegexTarget)
+	}
+	a.regexes, a.paths = nil, nil
+	for _, expr := range a.Regexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		a.regexes = append(a.regexes, re)
+	}
+	for _, expr := range a.Paths {
+		re, err := regexp.Compile(expr)
 		if err != nil {
-			return fmt.Errorf("failed to compile pattern '%s': %w", c.SecretPatterns[i].Name, err)
+			return err
+		}
+		a.paths = append(a.paths, re)
+	}
+	return nil
+}
+
+func (p *PlaceholderConfig) compile() error {
+	if p.Action != "" && p.Action != "suppress" && p.Action != "downgrade" {
+		return fmt.Errorf("unknown action %q: use suppress or downgrade", p.Action)
+	}
+	p.compiled = nil
+	for _, expr := range p.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		p.compiled = append(p.compiled, re)
+	}
+	return nil
+}
+
+func (p *PrivatePackages) compile() error {
+	if len(p.Names) == 0 {
+		return fmt.Errorf("names is empty")
+	}
+	if p.Advisories != "" {
+		u, err := url.Parse(p.Advisories)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("advisories must be an http or https URL, not %q", p.Advisories)
+		}
+	}
+	p.compiled = nil
+	for _, name := range p.Names {
+		expr := strings.ReplaceAll(regexp.QuoteMeta(name), `\*`, ".*")
+		p.compiled = append(p.compiled, regexp.MustCompile("(?i)^"+expr+"$"))
+	}
+	return nil
+}
+
+func (f *AdvisoryFeed) validate() error {
+	switch {
+	case (f.Path == "") == (f.URL == ""):
+		return fmt.Errorf("set one of path and url")
+	case f.URL != "":
+		if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http or https URL, not %q", f.URL)
+		}
+	case f.Token != "":
+		return fmt.Errorf("token only applies to a url")
+	}
+	return nil
+}
+
+// reports whether a package of ecosystem is one of the private packages
+func (p *PrivatePackages) Matches(ecosystem, name string) bool {
+	if p.Ecosystem != "" && !strings.EqualFold(p.Ecosystem, ecosystem) {
+		return false
+	}
+	for _, re := range p.compiled {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether one of the configured patterns matches a secret
+func (p *PlaceholderConfig) MatchesPattern(secret string) bool {
+	for _, re := range p.compiled {
+		if re.MatchString(secret) {
+			return true
 		}
-		c.SecretPatterns[i].compiled = compiled
 	}
+	return false
+}
+
+// reports whether the allowlist excludes a whole file
+func (a *Allowlist) AllowsPath(filePath string) bool {
+	if a == nil {
+		return false
+	}
+	for _, re := range a.paths {
+		if re.MatchString(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether the allowlist excludes a secret, found in match on line
+func (a *Allowlist) AllowsSecret(secret, match, line string) bool {
+	if a == nil {
+		return false
+	}
+	target := secret
+	switch a.RegexTarget {
+	case "match":
+		target = match
+	case "line":
+		target = line
+	}
+	for _, re := range a.regexes {
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	lower := strings.ToLower(secret)
+	for _, word := range a.Stopwords {
+		if strings.Contains(lower, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compiles and appends a one-off pattern, e.g. from the command line
+func (c *Config) AddPattern(name, pattern string) error {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile pattern '%s': %w", name, err)
+	}
+	if err := c.checkPattern(name, pattern); err != nil {
+		return err
+	}
+
+	c.SecretPatterns = append(c.SecretPatterns, SecretPattern{
+		Name:        name,
+		Pattern:     pattern,
+		Description: fmt.Sprintf("Ad-hoc pattern: %s", name),
+		Severity:    "high",
+		compiled:    compiled,
+	})
 	return nil
 }
 
+// returns the pattern's category, its first tag
+func (sp *SecretPattern) Category() string {
+	if len(sp.Tags) == 0 {
+		return "generic"
+	}
+	return sp.Tags[0]
+}
+
+// reports whether the pattern carries a tag
+func (sp *SecretPattern) HasTag(tag string) bool {
+	for _, t := range sp.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 // returns the compiled regex for a pattern
 func (sp *SecretPattern) GetCompiledPattern() *regexp.Regexp {
 	return sp.compiled
@@ -223,3 +1124,83 @@ func (c *Config) Save(path string) error {
 
 	return nil
 }
+
+// restricts SecretPatterns to the named rules, dropping any excluded ones;
+// "tag:<name>" selects every rule carrying that tag
+func (c *Config) SelectPatterns(only, exclude []string) error {
+	onlySel, err := c.ruleSelector(only)
+	if err != nil {
+		return err
+	}
+	excludeSel, err := c.ruleSelector(exclude)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]SecretPattern, 0, len(c.SecretPatterns))
+	for _, p := range c.SecretPatterns {
+		if !onlySel.empty() && !onlySel.matches(p) {
+			continue
+		}
+		if excludeSel.matches(p) {
+			continue
+		}
+		selected = append(selected, p)
+	}
+
+	c.SecretPatterns = selected
+	return nil
+}
+
+// rule names and tags from a --rules style list
+type ruleSelector struct {
+	names map[string]bool
+	tags  map[string]bool
+}
+
+func (c *Config) ruleSelector(list []string) (ruleSelector, error) {
+	knownNames := make(map[string]bool, len(c.SecretPatterns))
+	knownTags := make(map[string]bool)
+	for _, p := range c.SecretPatterns {
+		knownNames[strings.ToLower(p.Name)] = true
+		for _, t := range p.Tags {
+			knownTags[strings.ToLower(t)] = true
+		}
+	}
+
+	sel := ruleSelector{names: make(map[string]bool), tags: make(map[string]bool)}
+	for _, item := range list {
+		key := strings.ToLower(strings.TrimSpace(item))
+		if key == "" {
+			continue
+		}
+		if tag, ok := strings.CutPrefix(key, "tag:"); ok {
+			if !knownTags[tag] {
+				return sel, fmt.Errorf("unknown rule tag: %s", tag)
+			}
+			sel.tags[tag] = true
+			continue
+		}
+		if !knownNames[key] {
+			return sel, fmt.Errorf("unknown rule: %s", item)
+		}
+		sel.names[key] = true
+	}
+	return sel, nil
+}
+
+func (sel ruleSelector) empty() bool {
+	return len(sel.names) == 0 && len(sel.tags) == 0
+}
+
+func (sel ruleSelector) matches(p SecretPattern) bool {
+	if sel.names[strings.ToLower(p.Name)] {
+		return true
+	}
+	for _, t := range p.Tags {
+		if sel.tags[strings.ToLower(t)] {
+			return true
+		}
+	}
+	return false
+}
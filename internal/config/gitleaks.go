@@ -0,0 +1,584 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// reads a gitleaks TOML rules file (the format of gitleaks.toml) and
+// converts its [[rules]] into secret patterns. The regex, keywords,
+// entropy, secretGroup and allowlist settings of each rule are kept, and
+// the global [allowlist] applies to every imported rule; rules that only
+// match on file paths have no regex and are left out.
+func LoadGitleaksRules(path string) ([]SecretPattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gitleaks rules: %w", err)
+	}
+
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gitleaks rules %s: %w", path, err)
+	}
+
+	global := gitleaksAllowlist(doc["allowlist"])
+	for _, table := range tomlTables(doc["allowlists"]) {
+		global = mergeAllowlists(global, gitleaksAllowlist(table))
+	}
+
+	var patterns []SecretPattern
+	for i, rule := range tomlTables(doc["rules"]) {
+		regex := tomlString(rule["regex"])
+		if regex == "" {
+			continue
+		}
+
+		id := tomlString(rule["id"])
+		if id == "" {
+			return nil, fmt.Errorf("gitleaks rules %s: rule %d has no id", path, i+1)
+		}
+
+		pattern := SecretPattern{
+			Name:        id,
+			Pattern:     regex,
+			Description: tomlString(rule["description"]),
+			Severity:    "high",
+			Tags:        append([]string{"generic"}, tomlStrings(rule["tags"])...),
+			Keywords:    tomlStrings(rule["keywords"]),
+			SecretGroup: int(tomlNumber(rule["secretGroup"])),
+			Entropy:     tomlNumber(rule["entropy"]),
+		}
+		if pattern.Description == "" {
+			pattern.Description = id
+		}
+
+		allowlist := mergeAllowlists(global, gitleaksAllowlist(rule["allowlist"]))
+		for _, table := range tomlTables(rule["allowlists"]) {
+			allowlist = mergeAllowlists(allowlist, gitleaksAllowlist(table))
+		}
+		pattern.Allowlist = allowlist
+
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// converts a gitleaks allowlist table; nil when there is nothing in it
+func gitleaksAllowlist(value any) *Allowlist {
+	table, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	a := &Allowlist{
+		Regexes:   tomlStrings(table["regexes"]),
+		Paths:     tomlStrings(table["paths"]),
+		Stopwords: tomlStrings(table["stopwords"]),
+	}
+	if len(a.Regexes) == 0 && len(a.Paths) == 0 && len(a.Stopwords) == 0 {
+		return nil
+	}
+	return a
+}
+
+func mergeAllowlists(a, b *Allowlist) *Allowlist {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &Allowlist{
+		Regexes:   append(append([]string{}, a.Regexes...), b.Regexes...),
+		Paths:     append(append([]string{}, a.Paths...), b.Paths...),
+		Stopwords: append(append([]string{}, a.Stopwords...), b.Stopwords...),
+	}
+}
+
+func tomlString(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+func tomlNumber(value any) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func tomlStrings(value any) []string {
+	list, _ := value.([]any)
+	var out []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// returns the tables of an array of tables, or a single table as a list
+func tomlTables(value any) []map[string]any {
+	switch v := value.(type) {
+	case []map[string]any:
+		return v
+	case map[string]any:
+		return []map[string]any{v}
+	}
+	return nil
+}
+
+// a parser for the subset of TOML that rule files use: tables, arrays of
+// tables, dotted keys, strings (basic, literal and multi-line), numbers,
+// booleans, arrays and inline tables. Tables are map[string]any, arrays of
+// tables []map[string]any, arrays []any and numbers int64 or float64;
+// dates are kept as strings.
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func parseTOML(src string) (map[string]any, error) {
+	p := &tomlParser{src: src, line: 1}
+	root := make(map[string]any)
+	current := root
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			array := strings.HasPrefix(p.src[p.pos:], "[[")
+			if array {
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			closing := "]"
+			if array {
+				closing = "]]"
+			}
+			p.skipSpace(false)
+			if !strings.HasPrefix(p.src[p.pos:], closing) {
+				return nil, p.errorf("expected %s", closing)
+			}
+			p.pos += len(closing)
+
+			if current, err = p.openTable(root, keys, array); err != nil {
+				return nil, err
+			}
+		} else {
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace(false)
+			if p.eof() || p.peek() != '=' {
+				return nil, p.errorf("expected = after key")
+			}
+			p.pos++
+			p.skipSpace(false)
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.assign(current, keys, value); err != nil {
+				return nil, err
+			}
+		}
+
+		p.skipSpace(false)
+		if !p.eof() && p.peek() != '\n' && p.peek() != '\r' {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+// finds or creates the table named by a [header] or [[header]]
+func (p *tomlParser) openTable(root map[string]any, keys []string, array bool) (map[string]any, error) {
+	table := root
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch v := table[key].(type) {
+		case nil:
+			if last && array {
+				next := make(map[string]any)
+				table[key] = []map[string]any{next}
+				return next, nil
+			}
+			next := make(map[string]any)
+			table[key] = next
+			table = next
+		case map[string]any:
+			if last && array {
+				return nil, p.errorf("%s is a table, not an array of tables", strings.Join(keys, "."))
+			}
+			table = v
+		case []map[string]any:
+			if last && array {
+				next := make(map[string]any)
+				table[key] = append(v, next)
+				return next, nil
+			}
+			// [rules.allowlist] belongs to the latest [[rules]]
+			table = v[len(v)-1]
+		default:
+			return nil, p.errorf("%s is not a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) assign(table map[string]any, keys []string, value any) error {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := table[key].(map[string]any)
+		if !ok {
+			if table[key] != nil {
+				return p.errorf("%s is not a table", key)
+			}
+			next = make(map[string]any)
+			table[key] = next
+		}
+		table = next
+	}
+	key := keys[len(keys)-1]
+	if _, exists := table[key]; exists {
+		return p.errorf("duplicate key %s", key)
+	}
+	table[key] = value
+	return nil
+}
+
+// parses a possibly dotted key of bare and quoted parts
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			return nil, p.errorf("expected key")
+		}
+
+		var key string
+		switch p.peek() {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected key")
+			}
+			key = p.src[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpace(false)
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value")
+	}
+
+	rest := p.src[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(rest, "'''"):
+		return p.parseMultilineString("'''")
+	case rest[0] == '"':
+		return p.parseBasicString()
+	case rest[0] == '\'':
+		return p.parseLiteralString()
+	case rest[0] == '[':
+		return p.parseArray()
+	case rest[0] == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(rest, "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(rest, "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(",]}#\r\n", rune(p.peek())) {
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.src[start:p.pos])
+	if raw == "" {
+		return nil, p.errorf("expected value")
+	}
+
+	number := strings.ReplaceAll(raw, "_", "")
+	if n, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	// dates and times
+	return raw, nil
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++ // [
+	list := make([]any, 0)
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return list, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++ // {
+	table := make(map[string]any)
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+
+		keys, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace(false)
+		if p.eof() || p.peek() != '=' {
+			return nil, p.errorf("expected = after key")
+		}
+		p.pos++
+		p.skipSpace(false)
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.assign(table, keys, value); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace(false)
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	// a newline right after the opening delimiter is trimmed
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			p.pos += len(delim)
+			// up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && !p.eof() && p.peek() == delim[0]; i++ {
+				b.WriteByte(delim[0])
+				p.pos++
+			}
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == '\n':
+			p.line++
+			b.WriteByte(c)
+		case c == '\\' && delim == `"""`:
+			// a backslash at the end of a line trims the following whitespace
+			rest := strings.TrimLeft(p.src[p.pos:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// decodes the escape sequence after a backslash
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unterminated escape")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+// skips spaces, tabs and comments, and newlines too when multiline is set
+func (p *tomlParser) skipSpace(multiline bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case multiline && (c == '\n' || c == '\r'):
+			if c == '\n' {
+				p.line++
+			}
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
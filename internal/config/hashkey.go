@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+)
+
+// the key secrets are hashed with when none is configured. It is fixed
+// and published, so hashes and fingerprints match on every machine, for
+// shards of one scan, shared caches and the findings store of ephemeral
+// CI runners; it only salts them, and a report can still be checked
+// against guessed secrets. Set secret_hash_key, or installation_hash_key,
+// where that matters.
+const DefaultSecretHashKey = "gitguardian/secret-hash/v1"
+
+// the file the installation's secret hash key is kept in, in the state
+// directory
+const hashKeyFile = "secret-hash.key"
+
+// returns the key secrets are hashed with: GITGUARDIAN_SECRET_HASH_KEY,
+// secret_hash_key, the installation's key when installation_hash_key is
+// set, or DefaultSecretHashKey
+func (c *Config) HashKey() []byte {
+	if key := os.Getenv("GITGUARDIAN_SECRET_HASH_KEY"); key != "" {
+		return []byte(key)
+	}
+	if c.SecretHashKey != "" {
+		return []byte(c.SecretHashKey)
+	}
+	if c.installationKey != "" {
+		return []byte(c.installationKey)
+	}
+	return []byte(DefaultSecretHashKey)
+}
+
+// reads, or on first use generates, the installation's secret hash key
+// when installation_hash_key asks for one. Failing is a configuration
+// error: a key made up for one run would give fingerprints no other scan
+// matches. With readOnly, as for LoadFile, a key not created yet is an
+// error too.
+func (c *Config) loadInstallationKey(readOnly bool) error {
+	if !c.InstallationHashKey || c.SecretHashKey != "" || os.Getenv("GITGUARDIAN_SECRET_HASH_KEY") != "" {
+		return nil
+	}
+	dir := c.StateDir
+	if v := os.Getenv("GITGUARDIAN_STATE_DIR"); v != "" {
+		dir = v
+	}
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return fmt.Errorf("installation_hash_key: %w", err)
+		}
+	}
+	noWrite := c.NoWrite || readOnly
+	if v, err := strconv.ParseBool(os.Getenv("GITGUARDIAN_NO_WRITE")); err == nil && v {
+		noWrite = true
+	}
+
+	key, err := installationKey(filepath.Join(dir, hashKeyFile), noWrite)
+	if err != nil {
+		return fmt.Errorf("installation_hash_key: %w; set secret_hash_key or GITGUARDIAN_SECRET_HASH_KEY instead", err)
+	}
+	c.installationKey = key
+	return nil
+}
+
+func installationKey(path string, noWrite bool) (string, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if key := strings.TrimSpace(string(data)); key != "" {
+			return key, nil
+		}
+		return "", errors.New(path + " is empty")
+	} else if !os.IsNotExist(err) {
+		return "", err
+	} else if noWrite {
+		return "", fmt.Errorf("%s does not exist, and creating it is not allowed (no_write, or the Go package)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(buf)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// another scan created it first
+		return installationKey(path, noWrite)
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(key + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return key, nil
+}
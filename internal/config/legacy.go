@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the names of the legacy configuration file, which only ignores rules
+// and paths
+var LegacyFiles = []string{".gitguardian.yml", ".gitguardian.yaml"}
+
+// the legacy .gitguardian.yml; its lists merge into disabled_rules and
+// exclude_paths
+type LegacyConfig struct {
+	IgnoreRules []string
+	IgnorePaths []string
+
+	// top-level settings other than the two lists, which are skipped, as
+	// other tools such as ggshield keep theirs in .gitguardian.yml too
+	Unknown []string
+
+	// whether the file has ignore_rules or ignore_paths at all; a file
+	// without either is another tool's
+	recognized bool
+}
+
+// reports whether path names a YAML file, which Load reads as a legacy
+// configuration rather than JSON; LoadLegacy checks its content
+func IsLegacyFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// returns the legacy configuration file in dir, or "" if there is none.
+// A .gitguardian.yml without ignore_rules or ignore_paths, such as
+// ggshield's, or one that does not parse, is another tool's and is left
+// alone.
+func FindLegacy(dir string) string {
+	for _, name := range LegacyFiles {
+		path := filepath.Join(dir, name)
+		if legacy, err := parseLegacy(path); err == nil && legacy.recognized {
+			return path
+		}
+	}
+	return ""
+}
+
+// reads a legacy configuration file: top-level ignore_rules and
+// ignore_paths, each a block list or a [flow, list] of scalars. Other
+// top-level settings, and whatever is nested under them, are skipped and
+// listed in Unknown; a file with neither list is refused.
+func LoadLegacy(path string) (*LegacyConfig, error) {
+	legacy, err := parseLegacy(path)
+	if err != nil {
+		return nil, err
+	}
+	if !legacy.recognized {
+		return nil, fmt.Errorf("%s is not a gitguardian configuration: it has no ignore_rules or ignore_paths", path)
+	}
+	return legacy, nil
+}
+
+func parseLegacy(path string) (*LegacyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	legacy := &LegacyConfig{}
+	lists := map[string]*[]string{
+		"ignore_rules": &legacy.IgnoreRules,
+		"ignore_paths": &legacy.IgnorePaths,
+	}
+	var current *[]string
+	skipping := false
+	for n, raw := range strings.Split(string(data), "\n") {
+		line := stripYAMLComment(strings.TrimRight(raw, "\r"))
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		line = strings.TrimSpace(line)
+
+		// the block of an unknown setting
+		if skipping && (indented || strings.HasPrefix(line, "-")) {
+			continue
+		}
+		skipping = false
+
+		if item, ok := strings.CutPrefix(line, "-"); ok && (indented || current != nil) {
+			if current == nil {
+				return nil, fmt.Errorf("%s: line %d: list item outside a list", path, n+1)
+			}
+			if item = yamlScalar(item); item != "" {
+				*current = append(*current, item)
+			}
+			continue
+		}
+		if indented {
+			return nil, fmt.Errorf("%s: line %d: expected a list item", path, n+1)
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s: line %d: expected key: value", path, n+1)
+		}
+		current, ok = lists[strings.TrimSpace(key)]
+		if !ok {
+			legacy.Unknown = append(legacy.Unknown, strings.TrimSpace(key))
+			skipping = true
+			continue
+		}
+		legacy.recognized = true
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = yamlScalar(item); item != "" {
+					*current = append(*current, item)
+				}
+			}
+			current = nil
+		default:
+			*current = append(*current, yamlScalar(value))
+			current = nil
+		}
+	}
+	return legacy, nil
+}
+
+// merges the legacy lists into disabled_rules and exclude_paths, leaving
+// out entries already there
+func (c *Config) MergeLegacy(legacy *LegacyConfig) {
+	c.DisabledRules = appendMissing(c.DisabledRules, legacy.IgnoreRules)
+	c.ExcludePaths = appendMissing(c.ExcludePaths, legacy.IgnorePaths)
+}
+
+// writes the legacy configuration file for ignore_rules and ignore_paths
+func (l *LegacyConfig) YAML() string {
+	var b strings.Builder
+	for _, list := range []struct {
+		key   string
+		items []string
+	}{
+		{"ignore_rules", l.IgnoreRules},
+		{"ignore_paths", l.IgnorePaths},
+	} {
+		if len(list.items) == 0 {
+			fmt.Fprintf(&b, "%s: []\n", list.key)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n", list.key)
+		for _, item := range list.items {
+			fmt.Fprintf(&b, "  - %s\n", strconv.Quote(item))
+		}
+	}
+	return b.String()
+}
+
+func appendMissing(list, items []string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		seen[item] = true
+	}
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// drops a trailing "# comment" that is not inside quotes
+func stripYAMLComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// a plain, 'single' or "double" quoted scalar
+func yamlScalar(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
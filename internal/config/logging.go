@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// builds the logger LogLevel and LogFormat ask for, writing to w, and
+// makes it the configuration's Logger
+func (c *Config) SetupLogger(w io.Writer) error {
+	level := slog.LevelInfo
+	switch strings.ToLower(c.LogLevel) {
+	case "":
+		if c.Verbose {
+			level = slog.LevelDebug
+		}
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return fmt.Errorf("invalid log level %q: use debug, info, warn or error", c.LogLevel)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(c.LogFormat) {
+	case "", "text":
+		// a terminal has no use for the time of every line
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+		c.Logger = slog.New(slog.NewTextHandler(w, opts))
+	case "json":
+		c.Logger = slog.New(slog.NewJSONHandler(w, opts))
+	default:
+		return fmt.Errorf("invalid log format %q: use text or json", c.LogFormat)
+	}
+	return nil
+}
+
+// returns the configured logger, or slog's default when none was set up
+func (c *Config) Log() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// limits on what a secret pattern may cost. Go's regexp never backtracks,
+// but matching time still grows with the size of the compiled program,
+// which runs on every line of every file, and rules are often shared with
+// backtracking engines such as gitleaks' or PCRE
+const (
+	maxPatternInstructions = 5000
+	maxPatternAlternatives = 500
+)
+
+// describes what makes a pattern unsafe to run on every commit: nested
+// unbounded quantifiers, which backtracking engines take exponential time
+// on, an alternation with too many branches, or a compiled program too
+// large to match quickly. A pattern that does not parse has no problems
+// here; compiling it reports the error.
+func CheckPattern(pattern string) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	var problems []string
+	if nested := nestedQuantifier(re); nested != "" {
+		problems = append(problems, fmt.Sprintf("nested unbounded quantifiers in %s can backtrack catastrophically", nested))
+	}
+	if n := widestAlternation(re); n > maxPatternAlternatives {
+		problems = append(problems, fmt.Sprintf("alternation of %d branches (at most %d)", n, maxPatternAlternatives))
+	}
+	if prog, err := syntax.Compile(re.Simplify()); err == nil && len(prog.Inst) > maxPatternInstructions {
+		problems = append(problems, fmt.Sprintf("compiles to %d instructions (at most %d); reduce counted repetition", len(prog.Inst), maxPatternInstructions))
+	}
+	return problems
+}
+
+// checks a pattern before it is used, rejecting unsafe ones unless
+// AllowUnsafePatterns is set, in which case they are only logged
+func (c *Config) checkPattern(name, pattern string) error {
+	problems := CheckPattern(pattern)
+	if len(problems) == 0 {
+		return nil
+	}
+	if !c.AllowUnsafePatterns {
+		return fmt.Errorf("unsafe pattern '%s': %s (allow_unsafe_patterns uses it anyway)", name, strings.Join(problems, "; "))
+	}
+	c.Log().Warn("using unsafe pattern", "rule", name, "problems", strings.Join(problems, "; "))
+	return nil
+}
+
+// returns the outermost unbounded repetition that contains another, as
+// in (a+)+ or (\s*=)*, or "" when there is none
+func nestedQuantifier(re *syntax.Regexp) string {
+	if unboundedRepeat(re) {
+		for _, sub := range re.Sub {
+			if containsUnboundedRepeat(sub) {
+				return re.String()
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		if nested := nestedQuantifier(sub); nested != "" {
+			return nested
+		}
+	}
+	return ""
+}
+
+func containsUnboundedRepeat(re *syntax.Regexp) bool {
+	if unboundedRepeat(re) {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if containsUnboundedRepeat(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func unboundedRepeat(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1
+	}
+	return false
+}
+
+// returns the number of branches of the largest alternation
+func widestAlternation(re *syntax.Regexp) int {
+	widest := 0
+	if re.Op == syntax.OpAlternate {
+		widest = len(re.Sub)
+	}
+	for _, sub := range re.Sub {
+		if n := widestAlternation(sub); n > widest {
+			widest = n
+		}
+	}
+	return widest
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// polls the files a configuration was loaded from and reloads it when one
+// changes; a reload that fails keeps the previous configuration
+type Watcher struct {
+	interval time.Duration
+	prepare  func(cfg *Config)
+	onReload func(cfg *Config, changes []string)
+	onError  func(err error)
+	stop     chan struct{}
+}
+
+// starts watching cfg's files every interval; onReload gets the new
+// configuration and a description of what changed, onError the reasons
+// reloads fail. Call Stop to end the watch.
+func Watch(cfg *Config, interval time.Duration, onReload func(*Config, []string), onError func(error)) *Watcher {
+	return WatchPrepared(cfg, interval, nil, onReload, onError)
+}
+
+// is Watch with prepare run on every configuration reloaded, before it is
+// compared with the previous one, e.g. to apply command-line flags over
+// it as they were over cfg
+func WatchPrepared(cfg *Config, interval time.Duration, prepare func(*Config), onReload func(*Config, []string), onError func(error)) *Watcher {
+	w := &Watcher{
+		interval: interval,
+		prepare:  prepare,
+		onReload: onReload,
+		onError:  onError,
+		stop:     make(chan struct{}),
+	}
+	go w.run(cfg)
+	return w
+}
+
+func (w *Watcher) Stop() {
+	close(w.stop)
+}
+
+func (w *Watcher) run(cfg *Config) {
+	if len(cfg.Files()) == 0 {
+		return
+	}
+	configPath := cfg.Files()[0]
+	state := fileState(cfg.Files())
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := fileState(cfg.Files())
+		if current == state {
+			continue
+		}
+		// remembered even when the reload fails, so a broken file is
+		// reported once rather than on every tick
+		state = current
+
+		next, err := Load(configPath)
+		if err != nil {
+			w.onError(err)
+			continue
+		}
+		if w.prepare != nil {
+			w.prepare(next)
+		}
+		changes := Diff(cfg, next)
+		cfg = next
+		state = fileState(cfg.Files())
+		if len(changes) > 0 {
+			w.onReload(cfg, changes)
+		}
+	}
+}
+
+// sums up the size and modification time of every file
+func fileState(files []string) string {
+	var state string
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			state += file + ":missing;"
+			continue
+		}
+		state += fmt.Sprintf("%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return state
+}
+
+// describes how next differs from prev: rules and whitelist entries added,
+// removed or changed, and the other settings that changed
+func Diff(prev, next *Config) []string {
+	var changes []string
+
+	prevRules := make(map[string]string)
+	for _, p := range prev.SecretPatterns {
+		prevRules[p.Name] = marshal(p)
+	}
+	nextRules := make(map[string]bool)
+	for _, p := range next.SecretPatterns {
+		nextRules[p.Name] = true
+		old, ok := prevRules[p.Name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("added rule %q", p.Name))
+		case old != marshal(p):
+			changes = append(changes, fmt.Sprintf("changed rule %q", p.Name))
+		}
+	}
+	for _, p := range prev.SecretPatterns {
+		if !nextRules[p.Name] {
+			changes = append(changes, fmt.Sprintf("removed rule %q", p.Name))
+		}
+	}
+
+	prevWhitelist := make(map[string]bool)
+	for _, entry := range prev.Whitelist {
+		prevWhitelist[entry.String()] = true
+	}
+	nextWhitelist := make(map[string]bool)
+	for _, entry := range next.Whitelist {
+		nextWhitelist[entry.String()] = true
+		if !prevWhitelist[entry.String()] {
+			changes = append(changes, fmt.Sprintf("added whitelist entry %q", entry))
+		}
+	}
+	for _, entry := range prev.Whitelist {
+		if !nextWhitelist[entry.String()] {
+			changes = append(changes, fmt.Sprintf("removed whitelist entry %q", entry))
+		}
+	}
+
+	var prevSettings, nextSettings map[string]json.RawMessage
+	json.Unmarshal([]byte(marshal(prev)), &prevSettings)
+	json.Unmarshal([]byte(marshal(next)), &nextSettings)
+	keys := make([]string, 0, len(nextSettings))
+	for key := range nextSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "secret_patterns" || key == "whitelist" {
+			continue
+		}
+		if string(prevSettings[key]) != string(nextSettings[key]) {
+			changes = append(changes, fmt.Sprintf("changed %s", key))
+		}
+	}
+	return changes
+}
+
+func marshal(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
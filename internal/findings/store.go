@@ -0,0 +1,410 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+)
+
+// remembers findings across scans so recurring ones can be told apart
+// from new ones
+type Store struct {
+	path    string
+	records map[string]*Record
+	scans   map[scanKey]Scan // the last scan of each scope and branch
+	dirty   bool
+}
+
+type scanKey struct {
+	scope, branch string
+}
+
+// the store file; stores written before scans were recorded hold just
+// the list of records
+type storeFile struct {
+	Records []*Record       `json:"records"`
+	Scans   json.RawMessage `json:"scans,omitempty"`
+}
+
+// the outcome of the last scan of a scope on a branch, as status badges
+// show it
+type Scan struct {
+	Scope      string    `json:"scope"`
+	Branch     string    `json:"branch,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	At         time.Time `json:"at"`
+	Critical   int       `json:"critical"`
+	High       int       `json:"high"`
+	Medium     int       `json:"medium"`
+	Low        int       `json:"low"`
+	Total      int       `json:"total"`
+	Incomplete bool      `json:"incomplete,omitempty"`
+}
+
+// one finding as the store knows it
+type Record struct {
+	Fingerprint string    `json:"fingerprint"`  // ID keyed by Scope
+	ID          string    `json:"id,omitempty"` // the issue's "id" in reports
+	Scope       string    `json:"scope"`        // the scanned repository
+	Rule        string    `json:"rule"`
+	File        string    `json:"file"`
+	Severity    string    `json:"severity"`
+	Verified    string    `json:"verified,omitempty"` // live or invalid, when checked
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Scans       int       `json:"scans"`
+
+	// the latest triage decision, recorded by "gitguardian feedback"
+	Triage *Triage `json:"triage,omitempty"`
+}
+
+// verdicts a finding can be triaged with
+const (
+	FalsePositive = "false-positive"
+	TruePositive  = "true-positive"
+)
+
+// a person's decision about a finding
+type Triage struct {
+	Verdict string    `json:"verdict"`
+	Reason  string    `json:"reason,omitempty"`
+	By      string    `json:"by,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// returns the default store location, e.g. ~/.cache/gitguardian/findings.json
+func DefaultPath() (string, error) {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "findings.json"), nil
+}
+
+// opens the store at path; a missing file is an empty store
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, records: make(map[string]*Record), scans: make(map[scanKey]Scan)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read findings store: %w", err)
+	}
+
+	var file storeFile
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &file.Records)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse findings store %s: %w", path, err)
+	}
+	for _, r := range file.Records {
+		s.records[r.Fingerprint] = r
+	}
+	scans, legacy, err := parseScans(file.Scans)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse findings store %s: %w", path, err)
+	}
+	for _, scan := range scans {
+		s.scans[scanKey{scan.Scope, scan.Branch}] = scan
+	}
+	if legacy {
+		s.countScans()
+	}
+	return s, nil
+}
+
+// reads the scans of a store file; stores written before scans had a
+// branch hold just the time of each scope's last scan, and are legacy
+func parseScans(data json.RawMessage) (scans []Scan, legacy bool, err error) {
+	if len(data) == 0 {
+		return nil, false, nil
+	}
+	if trimmed := strings.TrimSpace(string(data)); !strings.HasPrefix(trimmed, "{") {
+		err := json.Unmarshal(data, &scans)
+		return scans, false, err
+	}
+	var times map[string]time.Time
+	if err := json.Unmarshal(data, &times); err != nil {
+		return nil, true, err
+	}
+	for scope, at := range times {
+		scans = append(scans, Scan{Scope: scope, At: at})
+	}
+	return scans, true, nil
+}
+
+// fills in the counts of scans read from a legacy store from the
+// findings each still saw
+func (s *Store) countScans() {
+	for key, scan := range s.scans {
+		for _, r := range s.Current(scan.Scope) {
+			switch r.Severity {
+			case "critical":
+				scan.Critical++
+			case "high":
+				scan.High++
+			case "medium":
+				scan.Medium++
+			case "low":
+				scan.Low++
+			}
+			scan.Total++
+		}
+		s.scans[key] = scan
+	}
+}
+
+// records a finding seen at now and reports whether it was new
+func (s *Store) Observe(r Record, now time.Time) bool {
+	s.dirty = true
+
+	if existing, ok := s.records[r.Fingerprint]; ok {
+		if existing.LastSeen.Equal(now) {
+			// seen again within the same scan, as a repeat of a secret
+			return existing.FirstSeen.Equal(now)
+		}
+		existing.ID = r.ID
+		existing.LastSeen = now
+		existing.Severity = r.Severity
+		existing.Verified = r.Verified
+		existing.Scans++
+		return false
+	}
+
+	r.FirstSeen = now
+	r.LastSeen = now
+	r.Scans = 1
+	s.records[r.Fingerprint] = &r
+	return true
+}
+
+// records a scan, whatever it found, replacing the earlier one of its
+// scope and branch
+func (s *Store) ObserveScan(scan Scan) {
+	s.scans[scanKey{scan.Scope, scan.Branch}] = scan
+	s.dirty = true
+}
+
+// returns the last scan of scope on branch; an empty branch means the
+// last scan of scope on any
+func (s *Store) LastScan(scope, branch string) (Scan, bool) {
+	if branch != "" {
+		scan, ok := s.scans[scanKey{scope, branch}]
+		return scan, ok
+	}
+	var last Scan
+	found := false
+	for key, scan := range s.scans {
+		if key.scope == scope && (!found || scan.At.After(last.At)) {
+			last, found = scan, true
+		}
+	}
+	return last, found
+}
+
+// returns every scope with a scan recorded, sorted
+func (s *Store) ScannedScopes() []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for key := range s.scans {
+		if !seen[key.scope] {
+			seen[key.scope] = true
+			scopes = append(scopes, key.scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// returns the records of the findings the last scan of scope still saw,
+// leaving out those fixed since and those triaged as false positives
+func (s *Store) Current(scope string) []Record {
+	last, ok := s.LastScan(scope, "")
+	if !ok {
+		return nil
+	}
+	var list []Record
+	for _, r := range s.Records() {
+		if r.Scope != scope || r.LastSeen.Before(last.At) {
+			continue
+		}
+		if r.Triage != nil && r.Triage.Verdict == FalsePositive {
+			continue
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+// returns the record for a fingerprint
+func (s *Store) Get(fingerprint string) (Record, bool) {
+	r, ok := s.records[fingerprint]
+	if !ok {
+		return Record{}, false
+	}
+	return *r, true
+}
+
+// returns the records of scope, or of every scope when it is empty, whose
+// ID or fingerprint starts with prefix, so either can be given shortened
+// as long as it is unambiguous
+func (s *Store) Match(prefix, scope string) []Record {
+	if r, ok := s.records[prefix]; ok {
+		return []Record{*r}
+	}
+	var list []Record
+	for fingerprint, r := range s.records {
+		if scope != "" && r.Scope != scope {
+			continue
+		}
+		if strings.HasPrefix(fingerprint, prefix) || (r.ID != "" && strings.HasPrefix(r.ID, prefix)) {
+			list = append(list, *r)
+		}
+	}
+	return list
+}
+
+// records a triage decision for a finding, replacing any earlier one
+func (s *Store) SetTriage(fingerprint string, t Triage) error {
+	r, ok := s.records[fingerprint]
+	if !ok {
+		return fmt.Errorf("no finding with fingerprint %s", fingerprint)
+	}
+	r.Triage = &t
+	s.dirty = true
+	return nil
+}
+
+// returns every record, oldest first
+func (s *Store) Records() []Record {
+	list := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].FirstSeen.Equal(list[j].FirstSeen) {
+			return list[i].FirstSeen.Before(list[j].FirstSeen)
+		}
+		return list[i].Fingerprint < list[j].Fingerprint
+	})
+	return list
+}
+
+// removes the records last seen before cutoff, and the scans made
+// before it, returning how many records it removed
+func (s *Store) Prune(cutoff time.Time) int {
+	removed := 0
+	for fingerprint, r := range s.records {
+		if r.LastSeen.Before(cutoff) {
+			delete(s.records, fingerprint)
+			removed++
+		}
+	}
+	for key, scan := range s.scans {
+		if scan.At.Before(cutoff) {
+			delete(s.scans, key)
+			s.dirty = true
+		}
+	}
+	if removed > 0 {
+		s.dirty = true
+	}
+	return removed
+}
+
+// keeps the max most recently seen records, returning how many it removed
+func (s *Store) Trim(max int) int {
+	if max <= 0 || len(s.records) <= max {
+		return 0
+	}
+
+	list := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastSeen.Equal(list[j].LastSeen) {
+			return list[i].LastSeen.After(list[j].LastSeen)
+		}
+		return list[i].Fingerprint < list[j].Fingerprint
+	})
+	for _, r := range list[max:] {
+		delete(s.records, r.Fingerprint)
+	}
+	s.dirty = true
+	return len(list) - max
+}
+
+// returns the number of records
+func (s *Store) Len() int {
+	return len(s.records)
+}
+
+// writes the store back to disk if it changed
+func (s *Store) Save() error {
+	if !s.dirty {
+		return nil
+	}
+
+	records := s.Records()
+	file := storeFile{Records: make([]*Record, len(records))}
+	for i := range records {
+		file.Records[i] = &records[i]
+	}
+	scans := make([]Scan, 0, len(s.scans))
+	for _, scan := range s.scans {
+		scans = append(scans, scan)
+	}
+	sort.Slice(scans, func(i, j int) bool {
+		if scans[i].Scope != scans[j].Scope {
+			return scans[i].Scope < scans[j].Scope
+		}
+		return scans[i].Branch < scans[j].Branch
+	})
+	var err error
+	if len(scans) > 0 {
+		if file.Scans, err = json.Marshal(scans); err != nil {
+			return fmt.Errorf("failed to marshal findings store: %w", err)
+		}
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal findings store: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create findings directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "findings.*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write findings store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write findings store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write findings store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write findings store: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
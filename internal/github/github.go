@@ -0,0 +1,272 @@
+package github
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// the public GitHub API; GitHub Enterprise Server serves it at
+// https://HOST/api/v3
+const DefaultBaseURL = "https://api.github.com"
+
+// talks to the security endpoints of one repository's REST API
+type Client struct {
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	client  *http.Client
+}
+
+// creates a client for repository, given as "owner/name"
+func NewClient(baseURL, token, repository string) (*Client, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid repository %q: expected owner/name", repository)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a GitHub token is required (dependency_apis.github_token or GITHUB_TOKEN)")
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Owner:   owner,
+		Repo:    repo,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// uploads a SARIF log for code scanning so its results show in the
+// repository's Security tab; returns the id GitHub assigns the upload
+func (c *Client) UploadSARIF(sarif []byte, commit, ref string) (string, error) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(sarif); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	body := map[string]string{
+		"commit_sha": commit,
+		"ref":        ref,
+		"sarif":      base64.StdEncoding.EncodeToString(compressed.Bytes()),
+		"tool_name":  "gitguardian",
+	}
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(http.MethodPost, c.repoPath("code-scanning/sarifs"), body, &response); err != nil {
+		return "", fmt.Errorf("SARIF upload failed: %w", err)
+	}
+	return response.ID, nil
+}
+
+// a repository security advisory, as far as the sync needs one
+type advisory struct {
+	Summary         string                  `json:"summary"`
+	Description     string                  `json:"description,omitempty"`
+	Severity        string                  `json:"severity,omitempty"`
+	Vulnerabilities []advisoryVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type advisoryVulnerability struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+}
+
+// files a draft repository security advisory for every issue at or above
+// minSeverity that does not have one yet; advisories are matched to issues by the fingerprint
+// in their summary, so repeated syncs do not duplicate them. Returns the
+// number of advisories created.
+func (c *Client) SyncAdvisories(issues []scanner.Issue, minSeverity string) (int, error) {
+	existing, err := c.advisoryMarkers()
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, issue := range issues {
+		if scanner.SeverityRank(issue.Severity) < scanner.SeverityRank(minSeverity) {
+			continue
+		}
+		marker := advisoryMarker(issue)
+		if existing[marker] {
+			continue
+		}
+
+		adv := advisory{
+			Summary:     truncate(marker+" "+issue.Description, 1024),
+			Description: advisoryDescription(issue),
+			Severity:    issue.Severity,
+		}
+		// secrets and dependency findings belong to this repository rather
+		// than to a published package
+		var vuln advisoryVulnerability
+		vuln.Package.Ecosystem = "other"
+		vuln.Package.Name = c.Repo
+		adv.Vulnerabilities = []advisoryVulnerability{vuln}
+
+		if err := c.do(http.MethodPost, c.repoPath("security-advisories"), adv, nil); err != nil {
+			return created, fmt.Errorf("failed to create advisory for %s: %w", issue.File, err)
+		}
+		existing[marker] = true
+		created++
+	}
+	return created, nil
+}
+
+// returns the markers of the repository's advisories in every state, so
+// one triaged, published or closed since is not filed again. The list is
+// paged by cursor, through the Link header.
+func (c *Client) advisoryMarkers() (map[string]bool, error) {
+	markers := make(map[string]bool)
+	path := c.repoPath("security-advisories?per_page=100")
+	for path != "" {
+		var advisories []advisory
+		header, err := c.send(http.MethodGet, path, nil, &advisories)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list advisories: %w", err)
+		}
+		for _, adv := range advisories {
+			if marker, _, ok := strings.Cut(adv.Summary, "] "); ok && strings.HasPrefix(marker, "[gitguardian ") {
+				markers[marker+"]"] = true
+			}
+		}
+		path = ""
+		if next := nextLink(header.Get("Link")); next != "" {
+			if !strings.HasPrefix(next, c.BaseURL+"/") {
+				return nil, fmt.Errorf("failed to list advisories: unexpected next page %s", next)
+			}
+			path = strings.TrimPrefix(next, c.BaseURL)
+		}
+	}
+	return markers, nil
+}
+
+// returns the rel="next" URL of a Link header, or ""
+func nextLink(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		url, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(url), "<>")
+		}
+	}
+	return ""
+}
+
+// identifies the issue an advisory was filed for
+func advisoryMarker(issue scanner.Issue) string {
+	id := issue.ID
+	if id == "" {
+		id = issue.Fingerprint()
+	}
+	return "[gitguardian " + id[:12] + "]"
+}
+
+func advisoryDescription(issue scanner.Issue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", issue.Description)
+	fmt.Fprintf(&b, "- File: `%s`", issue.File)
+	if issue.Line > 0 {
+		fmt.Fprintf(&b, " line %d", issue.Line)
+	}
+	fmt.Fprintf(&b, "\n- Rule: %s\n", issue.Rule)
+	if issue.AdvisoryID != "" {
+		fmt.Fprintf(&b, "- Advisory: %s\n", issue.AdvisoryID)
+	}
+	if issue.Commit != "" {
+		fmt.Fprintf(&b, "- Commit: %s\n", issue.Commit)
+	}
+	if issue.Owner != "" {
+		fmt.Fprintf(&b, "- Owner: %s\n", issue.Owner)
+	}
+	if issue.Remediation != "" {
+		fmt.Fprintf(&b, "\n%s\n", issue.Remediation)
+	}
+	for _, ref := range issue.References {
+		fmt.Fprintf(&b, "- %s\n", ref)
+	}
+	b.WriteString("\nReported by gitguardian. Secrets are never included; rotate the credential and remove it from history.\n")
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func (c *Client) repoPath(path string) string {
+	return fmt.Sprintf("/repos/%s/%s/%s", c.Owner, c.Repo, path)
+}
+
+// sends a JSON request and decodes the JSON response into out, if given
+func (c *Client) do(method, path string, in, out interface{}) error {
+	_, err := c.send(method, path, in, out)
+	return err
+}
+
+// does as do, returning the response headers
+func (c *Client) send(method, path string, in, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return nil, fmt.Errorf("GitHub returned %d", resp.StatusCode)
+	}
+
+	if out != nil && len(data) > 0 {
+		return resp.Header, json.Unmarshal(data, out)
+	}
+	return resp.Header, nil
+}
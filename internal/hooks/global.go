@@ -0,0 +1,259 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// the global git settings InstallGlobal can point at its directories
+const (
+	hooksPathKey   = "core.hooksPath"
+	templateDirKey = "init.templateDir"
+)
+
+// returns the directory global hooks are managed in, gitguardian in the
+// user configuration directory
+func GlobalDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitguardian"), nil
+}
+
+// installs the hooks for every repository of the user. By default the
+// global core.hooksPath points at a managed directory, so every
+// repository, cloned already or not, runs them; they run each
+// repository's own hooks in .git/hooks first, which git otherwise skips
+// under core.hooksPath, and the hooks of an earlier global core.hooksPath.
+// Repositories that set core.hooksPath themselves, as husky does, keep
+// theirs. With template, init.templateDir points at a template whose
+// hooks git copies into repositories as they are cloned or initialized
+// instead. The settings replaced are kept for UninstallGlobal.
+func InstallGlobal(template, asciiOnly bool, logger *slog.Logger) error {
+	dir, err := GlobalDir()
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		if _, err := windowsHookShell(); err != nil {
+			return err
+		}
+	}
+
+	state, err := loadGlobalState(dir)
+	if err != nil {
+		return err
+	}
+
+	key, target := hooksPathKey, filepath.Join(dir, "hooks")
+	if template {
+		key, target = templateDirKey, filepath.Join(dir, "template")
+	}
+	previous := globalConfig(key)
+	if previous != "" && samePath(previous, target) {
+		previous = state[key]
+	} else if template && previous != "" {
+		// a template holds more than hooks, so it is not chained like a
+		// hooks directory
+		return fmt.Errorf("%s is already set to %s; copy the scripts of \"gitguardian hook script\" into its hooks directory instead", key, previous)
+	}
+
+	hooksDir := target
+	if template {
+		hooksDir = filepath.Join(target, "hooks")
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	for _, hook := range clientHooks {
+		script := GenerateHookScript(hook, "", asciiOnly)
+		if !template {
+			script = globalScript(script, hook, expandHome(previous))
+		}
+		if err := os.WriteFile(filepath.Join(hooksDir, hook), []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write hook file: %w", err)
+		}
+	}
+	// git skips every hook in .git/hooks under core.hooksPath, not only
+	// those GitGuardian has, so the others get scripts that just run them
+	if !template {
+		for _, hook := range passThroughHooks {
+			script := globalScript("#!/bin/sh\n", hook, expandHome(previous))
+			if err := os.WriteFile(filepath.Join(hooksDir, hook), []byte(script), 0755); err != nil {
+				return fmt.Errorf("failed to write hook file: %w", err)
+			}
+		}
+	}
+
+	if _, ok := state[key]; !ok {
+		state[key] = previous
+		if err := saveGlobalState(dir, state); err != nil {
+			return err
+		}
+	}
+	if err := setGlobalConfig(key, target); err != nil {
+		return err
+	}
+
+	if template {
+		logger.Info("GitGuardian hooks installed for repositories cloned or initialized from now on; run git init in existing ones to add them", "template", target)
+	} else {
+		logger.Info("GitGuardian hooks installed for every repository; bypass them when needed with --no-verify", "dir", target, "previous", previous)
+	}
+	return nil
+}
+
+// puts back the global settings InstallGlobal replaced, unless they were
+// changed since, and removes the managed hooks
+func UninstallGlobal(logger *slog.Logger) error {
+	dir, err := GlobalDir()
+	if err != nil {
+		return err
+	}
+	state, err := loadGlobalState(dir)
+	if err != nil {
+		return err
+	}
+
+	for key, target := range map[string]string{
+		hooksPathKey:   filepath.Join(dir, "hooks"),
+		templateDirKey: filepath.Join(dir, "template"),
+	} {
+		current := globalConfig(key)
+		previous := state[key]
+		switch {
+		case current == "":
+		case !samePath(current, target):
+			if _, ok := state[key]; ok {
+				logger.Warn("global setting changed since the hooks were installed; leaving it", "setting", key, "value", current)
+			}
+		case previous != "":
+			if err := setGlobalConfig(key, previous); err != nil {
+				return err
+			}
+			logger.Info("restored global setting", "setting", key, "value", previous)
+		default:
+			if err := exec.Command("git", "config", "--global", "--unset", key).Run(); err != nil {
+				return fmt.Errorf("failed to unset %s: %w", key, err)
+			}
+			logger.Info("removed global setting", "setting", key)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", target, err)
+		}
+	}
+
+	if err := os.Remove(globalStatePath(dir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	logger.Info("GitGuardian global hooks removed")
+	return nil
+}
+
+// the hooks of githooks(5) GitGuardian has none of, which the global
+// hooks directory runs the repository's own of; proc-receive and
+// fsmonitor-watchman speak a protocol with git, so two of them cannot
+// run one after the other
+var passThroughHooks = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-merge-commit", "prepare-commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-auto-gc",
+	"post-rewrite", "sendemail-validate", "post-index-change",
+	"reference-transaction", "push-to-checkout",
+	"pre-receive", "update", "post-receive", "post-update",
+}
+
+// makes a hook script for core.hooksPath run, first, the repository's
+// own hook of its name, unless that is only a GitGuardian hook too, and
+// the one in the directory core.hooksPath named before. A hook the
+// GitGuardian hook is chained into or wraps is the repository's, and runs.
+func globalScript(script, hookName, previous string) string {
+	first, rest, _ := strings.Cut(script, "\n")
+	lines := wrapStart + "\n" + callHook(hookName,
+		`$(git rev-parse --git-common-dir)/hooks/`+hookName,
+		`[ -x "$gitguardian_hook" ] && { ! grep -q GitGuardian "$gitguardian_hook" || grep -qF -e '`+chainStart+`' -e '`+wrapStart+`' "$gitguardian_hook"; }`, "")
+	if previous != "" {
+		lines += callHook(hookName, shellEscape(filepath.ToSlash(filepath.Join(previous, hookName))),
+			`[ -x "$gitguardian_hook" ]`, "")
+	}
+	return first + "\n" + lines + wrapEnd + "\n" + rest
+}
+
+// escapes s for a double-quoted shell string
+func shellEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
+
+// reads a global git setting, "" when it is not set
+func globalConfig(key string) string {
+	out, err := exec.Command("git", "config", "--global", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func setGlobalConfig(key, value string) error {
+	if out, err := exec.Command("git", "config", "--global", key, value).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set %s: %s", key, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// git expands a leading ~/ in paths it is configured with
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+func samePath(a, b string) bool {
+	return filepath.Clean(expandHome(a)) == filepath.Clean(b)
+}
+
+// the global settings InstallGlobal replaced, by key, with their values
+// before; a key is present once InstallGlobal has set it
+type globalState map[string]string
+
+func globalStatePath(dir string) string {
+	return filepath.Join(dir, "global-hooks.json")
+}
+
+func loadGlobalState(dir string) (globalState, error) {
+	data, err := os.ReadFile(globalStatePath(dir))
+	if os.IsNotExist(err) {
+		return globalState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global hooks state: %w", err)
+	}
+	state := globalState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", globalStatePath(dir), err)
+	}
+	return state, nil
+}
+
+func saveGlobalState(dir string, state globalState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(globalStatePath(dir), data, 0644); err != nil {
+		return fmt.Errorf("failed to write global hooks state: %w", err)
+	}
+	return nil
+}
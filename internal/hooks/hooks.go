@@ -2,11 +2,16 @@ package hooks
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
 )
 
 const (
@@ -18,14 +23,14 @@ const (
 GITGUARDIAN_BIN="gitguardian"
 
 # check if gitguardian is in PATH
-if ! command -v $GITGUARDIAN_BIN > /dev/null 2>&1; then
+if ! command -v "$GITGUARDIAN_BIN" > /dev/null 2>&1; then
     echo "Warning: gitguardian binary not found in PATH"
     echo "Please ensure GitGuardian is installed and available in your PATH"
     exit 0
 fi
 
 # get list of staged files
-STAGED_FILES=$(git diff --cached --name-only --diff-filter=ACM)
+STAGED_FILES=$(git diff --cached --name-only --diff-filter=ACMR)
 
 if [ -z "$STAGED_FILES" ]; then
     echo "No staged files to scan"
@@ -34,20 +39,10 @@ fi
 
 echo "🔍 Running GitGuardian security scan on staged files..."
 
-# create temporary directory for staged files
-TEMP_DIR=$(mktemp -d)
-trap "rm -rf $TEMP_DIR" EXIT
-
-# copy staged files to temp directory
-for file in $STAGED_FILES; do
-    if [ -f "$file" ]; then
-        mkdir -p "$TEMP_DIR/$(dirname "$file")"
-        git show ":$file" > "$TEMP_DIR/$file" 2>/dev/null || cp "$file" "$TEMP_DIR/$file"
-    fi
-done
-
-# run scan on temp directory
-$GITGUARDIAN_BIN -path "$TEMP_DIR" -format text
+# scan the staged blobs straight from the index, so partially staged and
+# renamed files are checked exactly as they will be committed;
+# GITGUARDIAN_ARGS can add flags such as -config or -exclude
+"$GITGUARDIAN_BIN" -staged -path . -format text $GITGUARDIAN_ARGS
 
 SCAN_RESULT=$?
 
@@ -74,198 +69,292 @@ exit 0
 GITGUARDIAN_BIN="gitguardian"
 
 # check if gitguardian is in PATH
-if ! command -v $GITGUARDIAN_BIN > /dev/null 2>&1; then
+if ! command -v "$GITGUARDIAN_BIN" > /dev/null 2>&1; then
     echo "Warning: gitguardian binary not found in PATH"
     echo "Please ensure GitGuardian is installed and available in your PATH"
     exit 0
 fi
 
-remote="$1"
-url="$2"
+echo "🔍 Running GitGuardian security scan on changed files..."
 
-z40=0000000000000000000000000000000000000000
+# scan the lines the pushed commits add, read from git rather than the
+# working tree; "<local ref> <local sha> <remote ref> <remote sha>" lines
+# arrive on stdin. GITGUARDIAN_ARGS can add flags such as -config
+"$GITGUARDIAN_BIN" scan-range -pre-push -path . -format text $GITGUARDIAN_ARGS
 
-while read local_ref local_sha remote_ref remote_sha
-do
-    if [ "$local_sha" = $z40 ]; then
-        # Handle delete
-        :
-    else
-        if [ "$remote_sha" = $z40 ]; then
-            # new branch
-            range="$local_sha"
-        else
-            # update to existing branch
-            range="$remote_sha..$local_sha"
-        fi
-
-        # get list of files changed in this push
-        CHANGED_FILES=$(git diff --name-only $range 2>/dev/null)
-
-        if [ -n "$CHANGED_FILES" ]; then
-            echo "🔍 Running GitGuardian security scan on changed files..."
-            
-            # create temp directory
-            TEMP_DIR=$(mktemp -d)
-            trap "rm -rf $TEMP_DIR" EXIT
-
-            # copy changed files to temp directory
-            for file in $CHANGED_FILES; do
-                if [ -f "$file" ]; then
-                    mkdir -p "$TEMP_DIR/$(dirname "$file")"
-                    cp "$file" "$TEMP_DIR/$file"
-                fi
-            done
-
-            # run scan
-            $GITGUARDIAN_BIN -path "$TEMP_DIR" -format text
-
-            SCAN_RESULT=$?
-
-            if [ $SCAN_RESULT -ne 0 ]; then
-                echo ""
-                echo "❌ Security issues found in files being pushed!"
-                echo "Please fix the issues above before pushing."
-                echo ""
-                echo "To bypass this check (NOT RECOMMENDED), use:"
-                echo "  git push --no-verify"
-                echo ""
-                exit 1
-            fi
-
-            echo "✅ No security issues found in changed files"
-        fi
-    fi
-done
+SCAN_RESULT=$?
+
+if [ $SCAN_RESULT -ne 0 ]; then
+    echo ""
+    echo "❌ Security issues found in files being pushed!"
+    echo "Please fix the issues above before pushing."
+    echo ""
+    echo "To bypass this check (NOT RECOMMENDED), use:"
+    echo "  git push --no-verify"
+    echo ""
+    exit 1
+fi
 
+echo "✅ No security issues found in changed files"
 exit 0
 `
 
 	commitMsgHook = `#!/bin/sh
 # GitGuardian commit-msg hook
-# this hook checks commit messages for suspicious words
+# this hook checks commit messages for secrets and suspicious words
 
 # get the binary path
 GITGUARDIAN_BIN="gitguardian"
 
 # check if gitguardian is in PATH
-if ! command -v $GITGUARDIAN_BIN > /dev/null 2>&1; then
+if ! command -v "$GITGUARDIAN_BIN" > /dev/null 2>&1; then
     exit 0
 fi
 
-# read the commit message
-COMMIT_MSG_FILE="$1"
-COMMIT_MSG=$(cat "$COMMIT_MSG_FILE")
-
-# check for suspicious words in message
-SUSPICIOUS_KEYWORDS="hack backdoor malware exploit bypass disable.security remove.check temporary.fix todo.security"
-
-for keyword in $SUSPICIOUS_KEYWORDS; do
-    # replace dots with spaces for pattern matching
-    pattern=$(echo "$keyword" | sed 's/\./ /g')
-    if echo "$COMMIT_MSG" | grep -qi "$pattern"; then
-        echo "⚠️  Warning: Suspicious keyword detected in commit message: '$pattern'"
-        echo "Commit message: $COMMIT_MSG"
-        echo ""
-        echo "Please review your commit message for security implications."
-        echo "If this is intentional, you can proceed or use --no-verify to bypass."
-        echo ""
-        read -p "Continue with this commit message? (y/N): " -n 1 -r
-        echo
-        if [[ ! $REPLY =~ ^[Yy]$ ]]; then
-            exit 1
-        fi
-    fi
-done
+# check the message for secrets and suspicious keywords
+exec "$GITGUARDIAN_BIN" hook run commit-msg "$1"
+`
 
-exit 0
+	preReceiveHook = `#!/bin/sh
+# GitGuardian pre-receive hook
+# this hook runs on the git server and rejects pushes that add secrets
+
+# get the binary path
+GITGUARDIAN_BIN="gitguardian"
+
+# unlike the client hooks, a missing binary rejects the push so that
+# enforcement cannot silently lapse
+if ! command -v "$GITGUARDIAN_BIN" > /dev/null 2>&1; then
+    echo "GitGuardian: scanner binary not found on the server, rejecting push"
+    exit 1
+fi
+
+# scan every commit in the pushed ranges; "<old> <new> <ref>" lines
+# arrive on stdin
+exec "$GITGUARDIAN_BIN" scan-push-range -secrets-only
 `
 )
 
-// installs hooks in the specified repo
-func Install(repoPath string) error {
-	// ensure we're in a git repo
-	gitDir := filepath.Join(repoPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return fmt.Errorf("not a git repository: %s", repoPath)
-	}
+// the hooks Install sets up in a working copy
+var clientHooks = []string{"pre-commit", "pre-push", "commit-msg"}
 
-	hooksDir := filepath.Join(gitDir, "hooks")
+// installs hooks in the specified repo, logging each step; asciiOnly
+// writes hook messages without emoji or accented letters. A hook the
+// project or a hook manager such as husky or pre-commit already has is
+// kept, with the GitGuardian script chained in front of it.
+func Install(repoPath string, asciiOnly bool, logger *slog.Logger) error {
+	target, err := findTarget(repoPath)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		if _, err := windowsHookShell(); err != nil {
+			return err
+		}
+	}
 
 	// create hooks directory if it doesn't exist
-	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+	if err := os.MkdirAll(target.dir, 0755); err != nil {
 		return fmt.Errorf("failed to create hooks directory: %w", err)
 	}
 
-	if err := installHook(hooksDir, "pre-commit", preCommitHook); err != nil {
+	if err := installHook(target, "pre-commit", localizeScript(preCommitHook, asciiOnly), logger); err != nil {
 		return fmt.Errorf("failed to install pre-commit hook: %w", err)
 	}
 
-	if err := installHook(hooksDir, "pre-push", prePushHook); err != nil {
+	if err := installHook(target, "pre-push", localizeScript(prePushHook, asciiOnly), logger); err != nil {
 		return fmt.Errorf("failed to install pre-push hook: %w", err)
 	}
 
-	if err := installHook(hooksDir, "commit-msg", commitMsgHook); err != nil {
+	if err := installHook(target, "commit-msg", localizeScript(commitMsgHook, asciiOnly), logger); err != nil {
 		return fmt.Errorf("failed to install commit-msg hook: %w", err)
 	}
 
-	fmt.Printf("✅ GitGuardian hooks installed successfully in %s\n", repoPath)
-	fmt.Println("\nInstalled hooks:")
-	fmt.Println("  - pre-commit: Scans staged files before commit")
-	fmt.Println("  - pre-push: Scans changed files before push")
-	fmt.Println("  - commit-msg: Checks commit messages for suspicious keywords")
-	fmt.Println("\nTo bypass hooks when needed, use --no-verify flag")
+	logger.Info("GitGuardian hooks installed; bypass them when needed with --no-verify", "repo", repoPath, "dir", target.dir)
 
 	return nil
 }
 
-// removes GitGuardian hooks from the repo
-func Uninstall(repoPath string) error {
-	gitDir := filepath.Join(repoPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return fmt.Errorf("not a git repository: %s", repoPath)
+// removes GitGuardian hooks from the repo, and the lines that chain them
+// into hooks it did not write
+func Uninstall(repoPath string, logger *slog.Logger) error {
+	target, err := findTarget(repoPath)
+	if err != nil {
+		return err
 	}
 
-	hooksDir := filepath.Join(gitDir, "hooks")
-	hooks := []string{"pre-commit", "pre-push", "commit-msg"}
-
-	for _, hook := range hooks {
-		hookPath := filepath.Join(hooksDir, hook)
+	for _, hook := range clientHooks {
+		hookPath := filepath.Join(target.dir, hook)
 
 		// check if it's our hook
-		if content, err := os.ReadFile(hookPath); err == nil {
-			if strings.Contains(string(content), "GitGuardian") {
-				if err := os.Remove(hookPath); err != nil {
-					fmt.Printf("Warning: failed to remove %s hook: %v\n", hook, err)
-				} else {
-					fmt.Printf("✅ Removed %s hook\n", hook)
-				}
+		content, err := os.ReadFile(hookPath)
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.Contains(string(content), chainStart):
+			rest := unchain(string(content))
+			if strings.TrimSpace(rest) == "" || strings.TrimSpace(rest) == "#!/bin/sh" {
+				err = os.Remove(hookPath)
+			} else {
+				err = os.WriteFile(hookPath, []byte(rest), 0755)
+			}
+		case strings.Contains(string(content), "GitGuardian"):
+			err = os.Remove(hookPath)
+		default:
+			continue
+		}
+		if err != nil {
+			logger.Warn("failed to remove hook", "hook", hook, "error", err)
+			continue
+		}
+		logger.Info("removed hook", "hook", hook)
+
+		// put back the hook it wrapped
+		wrappedPath := filepath.Join(target.dir, wrappedHooks, hook)
+		if _, err := os.Stat(wrappedPath); err == nil {
+			if err := os.Rename(wrappedPath, hookPath); err != nil {
+				logger.Warn("failed to restore wrapped hook", "hook", hook, "error", err)
+			} else {
+				logger.Info("restored wrapped hook", "hook", hook)
 			}
 		}
 	}
+	os.Remove(filepath.Join(target.dir, wrappedHooks))
 
+	if err := os.RemoveAll(target.scripts); err != nil {
+		logger.Warn("failed to remove chained hook scripts", "dir", target.scripts, "error", err)
+	}
+	// and the gitguardian directory, unless something else is in it
+	os.Remove(filepath.Dir(target.scripts))
 	return nil
 }
 
-// installs a single hook file
-func installHook(hooksDir, hookName, hookContent string) error {
-	hookPath := filepath.Join(hooksDir, hookName)
-
-	// check if hook already exists
-	if _, err := os.Stat(hookPath); err == nil {
-		// read existing hook
-		existing, err := os.ReadFile(hookPath)
-		if err == nil && strings.Contains(string(existing), "GitGuardian") {
-			fmt.Printf("✅ %s hook already installed\n", hookName)
-			return nil
+// returns the directory git runs the hooks of the repository at repoPath
+// from. Asking git rather than joining .git/hooks finds it from a
+// subdirectory, in a worktree, where .git is a file and the hooks are
+// those of the main repository, and under core.hooksPath.
+func HooksDir(repoPath string) (string, error) {
+	return gitPath(repoPath, "--git-path", "hooks")
+}
+
+// where Install puts the hooks of a repository
+type hookTarget struct {
+	dir     string // the hook scripts git, or the hook manager, runs
+	scripts string // the GitGuardian scripts chained into hooks it did not write
+	manager string // the hook manager that owns dir, if any
+}
+
+// finds where the hooks of the repository at repoPath go: core.hooksPath
+// when set, as git runs them from there. husky 9 points it at .husky/_,
+// whose generated stubs run the scripts in .husky the project commits,
+// so those are the hooks to chain into.
+func findTarget(repoPath string) (hookTarget, error) {
+	hooksDir, err := HooksDir(repoPath)
+	if err != nil {
+		return hookTarget{}, err
+	}
+	commonDir, err := gitPath(repoPath, "--git-common-dir")
+	if err != nil {
+		return hookTarget{}, err
+	}
+
+	target := hookTarget{dir: hooksDir, scripts: filepath.Join(commonDir, chainedScripts)}
+	switch {
+	case filepath.Base(hooksDir) == "_" && filepath.Base(filepath.Dir(hooksDir)) == ".husky":
+		target.dir, target.manager = filepath.Dir(hooksDir), "husky"
+	case filepath.Base(hooksDir) == ".husky":
+		target.manager = "husky"
+	}
+	return target, nil
+}
+
+// asks git for an absolute path, e.g. --git-common-dir. Git before 2.31
+// has no --path-format, which it echoes back, and answers relative to
+// where it runs; it is then asked from the top of the working tree, which
+// a relative core.hooksPath is relative to as well, and the answer joined
+// to it.
+func gitPath(repoPath string, args ...string) (string, error) {
+	out, err := revParse(repoPath, append([]string{"--path-format=absolute"}, args...)...)
+	if err != nil || !strings.HasPrefix(out, "--path-format") {
+		return out, err
+	}
+
+	dir := repoPath
+	if top, err := revParse(repoPath, "--show-toplevel"); err == nil && top != "" {
+		dir = top
+	}
+	if out, err = revParse(dir, args...); err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+	return out, nil
+}
+
+func revParse(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"rev-parse"}, args...)...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", repoPath)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// installs a single hook file, or chains it into the hook already there
+func installHook(target hookTarget, hookName, hookContent string, logger *slog.Logger) error {
+	hookPath := filepath.Join(target.dir, hookName)
+
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read existing hook: %w", err)
+	}
+
+	switch {
+	case strings.Contains(string(existing), chainStart):
+		// chained in a hook the project commits, e.g. by a teammate:
+		// this clone only lacks the script
+		if err := writeChainedScript(target, hookName, hookContent); err != nil {
+			return err
 		}
+		logger.Info("hook already installed", "hook", hookName)
+		return nil
 
-		// backup existing hook
+	case strings.Contains(string(existing), "GitGuardian"):
+		// installs before hooks were wrapped moved them to .backup, where
+		// they no longer ran
 		backupPath := hookPath + ".backup"
-		if err := os.Rename(hookPath, backupPath); err != nil {
-			return fmt.Errorf("failed to backup existing hook: %w", err)
+		if _, err := os.Stat(backupPath); err == nil && !strings.Contains(string(existing), wrapStart) {
+			return wrapHook(target, hookName, hookContent, backupPath, logger)
+		}
+		logger.Info("hook already installed", "hook", hookName)
+		return nil
+
+	case err == nil || target.manager != "":
+		// a hook someone else wrote, or one a hook manager would run
+		chained, ok := chain(string(existing), hookName)
+		if !ok {
+			break
+		}
+		if err := writeChainedScript(target, hookName, hookContent); err != nil {
+			return err
+		}
+		if err := os.WriteFile(hookPath, []byte(chained), 0755); err != nil {
+			return fmt.Errorf("failed to write hook file: %w", err)
 		}
-		fmt.Printf("📁 Backed up existing %s hook to %s\n", hookName, backupPath)
+		manager := target.manager
+		if manager == "" {
+			manager = detectManager(string(existing))
+		}
+		logger.Info("chained hook into existing hook", "hook", hookName, "path", hookPath, "manager", manager)
+		return nil
+	}
+
+	if existing != nil {
+		// not a shell script, so nothing can be chained into it
+		return wrapHook(target, hookName, hookContent, hookPath, logger)
 	}
 
 	// write the hook
@@ -273,19 +362,171 @@ func installHook(hooksDir, hookName, hookContent string) error {
 		return fmt.Errorf("failed to write hook file: %w", err)
 	}
 
-	fmt.Printf("✅ Installed %s hook\n", hookName)
+	logger.Info("installed hook", "hook", hookName)
 	return nil
 }
 
-// returns a list of changed files for different Git operations
+// moves the hook at original into hooks.d and installs the GitGuardian
+// hook in its place, running it first
+func wrapHook(target hookTarget, hookName, hookContent, original string, logger *slog.Logger) error {
+	wrappedPath := filepath.Join(target.dir, wrappedHooks, hookName)
+	if _, err := os.Stat(wrappedPath); err == nil {
+		return fmt.Errorf("cannot wrap the existing hook: %s already exists", wrappedPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(wrappedPath), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.Rename(original, wrappedPath); err != nil {
+		return fmt.Errorf("failed to move existing hook: %w", err)
+	}
+
+	hookPath := filepath.Join(target.dir, hookName)
+	if err := os.WriteFile(hookPath, []byte(wrap(hookContent, hookName)), 0755); err != nil {
+		return fmt.Errorf("failed to write hook file: %w", err)
+	}
+
+	logger.Info("installed hook, running the existing one first", "hook", hookName, "wrapped", wrappedPath)
+	return nil
+}
+
+// the hooks GitGuardian hooks wrap, in a directory of the hooks
+// directory, each run before the GitGuardian hook of its name
+const wrappedHooks = "hooks.d"
+
+// the lines that mark the call of the wrapped hook
+const (
+	wrapStart = "# >>> wrapped hook >>>"
+	wrapEnd   = "# <<< wrapped hook <<<"
+)
+
+// makes a GitGuardian hook script run the hook it wraps first, right
+// after its #! line
+func wrap(script, hookName string) string {
+	first, rest, _ := strings.Cut(script, "\n")
+	path := `$(dirname "$0")/` + wrappedHooks + `/` + hookName
+	return first + "\n" + wrapStart + "\n" + callHook(hookName, path, `[ -f "$gitguardian_hook" ]`, "") + wrapEnd + "\n" + rest
+}
+
+// the GitGuardian scripts chained into hooks it did not write, under the
+// common git directory, so they stay out of the working tree and are
+// shared by every worktree
+const chainedScripts = "gitguardian/hooks"
+
+// the lines that mark the chain in a hook
+const (
+	chainStart = "# >>> GitGuardian >>>"
+	chainEnd   = "# <<< GitGuardian <<<"
+)
+
+// the lines chained into a hook; they run the GitGuardian script and stop
+// the hook when it fails. The hook may be committed, as husky's are, so
+// the script is found through git rather than by an absolute path and
+// skipped in clones that have not installed it.
+func chainLines(hookName string) string {
+	path := `$(git rev-parse --git-common-dir)/` + chainedScripts + `/` + hookName
+	return chainStart + "\n" + callHook(hookName, path, `[ -f "$gitguardian_hook" ]`, "sh ") + chainEnd + "\n"
+}
+
+// the hooks git feeds on stdin
+var stdinHooks = map[string]bool{
+	"pre-push": true, "post-rewrite": true, "reference-transaction": true,
+	"pre-receive": true, "post-receive": true,
+}
+
+// shell lines that run the hook at path, a shell expression, with the
+// hook's arguments when test, a shell condition on $gitguardian_hook,
+// holds, stopping the hook when it fails. pre-push receives the pushed
+// refs on stdin, as some other hooks receive theirs, which what runs
+// after it, e.g. the rest of a pre-commit framework hook, reads as well,
+// so they are handed on.
+func callHook(hookName, path, test, invoke string) string {
+	lines := `gitguardian_hook="` + path + `"
+if ` + test + `; then
+`
+	if stdinHooks[hookName] {
+		lines += `    gitguardian_refs=$(cat)
+    printf '%s\n' "$gitguardian_refs" | ` + invoke + `"$gitguardian_hook" "$@" || exit $?
+    if [ -n "$gitguardian_refs" ]; then
+        exec <<GITGUARDIAN_REFS
+$gitguardian_refs
+GITGUARDIAN_REFS
+    else
+        exec </dev/null
+    fi
+`
+	} else {
+		lines += `    ` + invoke + `"$gitguardian_hook" "$@" || exit $?
+`
+	}
+	return lines + "fi\n"
+}
+
+var shellInterpreter = regexp.MustCompile(`^#!\s*(\S*/)?(env\s+)?(sh|bash|dash|ksh|zsh)(\s|$)`)
+
+// chains the GitGuardian hook in front of the rest of an existing one,
+// right after its #! line; husky's scripts have none, and an empty
+// script becomes a new one. Reports false when the hook is not a shell
+// script.
+func chain(existing, hookName string) (string, bool) {
+	if strings.TrimSpace(existing) == "" {
+		return "#!/bin/sh\n" + chainLines(hookName), true
+	}
+	if !strings.HasPrefix(existing, "#!") {
+		return chainLines(hookName) + existing, true
+	}
+	first, rest, _ := strings.Cut(existing, "\n")
+	if !shellInterpreter.MatchString(first) {
+		return "", false
+	}
+	return first + "\n" + chainLines(hookName) + rest, true
+}
+
+// removes the chained lines from a hook
+func unchain(content string) string {
+	start := strings.Index(content, chainStart)
+	end := strings.Index(content, chainEnd)
+	if start < 0 || end < start {
+		return content
+	}
+	return content[:start] + strings.TrimPrefix(content[end+len(chainEnd):], "\n")
+}
+
+// writes the GitGuardian script a chained hook runs
+func writeChainedScript(target hookTarget, hookName, hookContent string) error {
+	if err := os.MkdirAll(target.scripts, 0755); err != nil {
+		return fmt.Errorf("failed to create hook scripts directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(target.scripts, hookName), []byte(hookContent), 0755); err != nil {
+		return fmt.Errorf("failed to write hook file: %w", err)
+	}
+	return nil
+}
+
+// names the hook manager that wrote a hook, for the log
+func detectManager(content string) string {
+	switch {
+	case strings.Contains(content, "pre-commit.com") || strings.Contains(content, "generated by pre-commit"):
+		return "pre-commit"
+	case strings.Contains(content, "husky"):
+		return "husky"
+	case strings.Contains(content, "lefthook"):
+		return "lefthook"
+	default:
+		return "none"
+	}
+}
+
+// returns a list of changed files for different Git operations. Deleted
+// paths are left out; callers read the rest from git rather than disk,
+// since a sparse checkout may not have them.
 func GetChangedFiles(operation string) ([]string, error) {
 	var cmd *exec.Cmd
 
 	switch operation {
 	case "pre-commit":
-		cmd = exec.Command("git", "diff", "--cached", "--name-only", "--diff-filter=ACM")
+		cmd = exec.Command("git", "diff", "--cached", "--name-only", "--diff-filter=ACMR")
 	case "pre-push":
-		cmd = exec.Command("git", "diff", "--name-only", "HEAD")
+		cmd = exec.Command("git", "diff", "--name-only", "--diff-filter=ACMR", "HEAD")
 	default:
 		return nil, fmt.Errorf("unsupported operation: %s", operation)
 	}
@@ -308,11 +549,11 @@ func GetChangedFiles(operation string) ([]string, error) {
 	return result, nil
 }
 
-// checks if the given path is a git repo
+// checks if the given path is a git repo; in a worktree or a submodule
+// .git is a file pointing at the git directory
 func IsGitRepository(path string) bool {
-	gitDir := filepath.Join(path, ".git")
-	if info, err := os.Stat(gitDir); err == nil {
-		return info.IsDir()
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return true
 	}
 
 	// check if we're inside a git repo
@@ -338,13 +579,14 @@ func GetRepositoryRoot(path string) (string, error) {
 // checks if hooks are installed
 func CheckHooksInstalled(repoPath string) (map[string]bool, error) {
 	status := make(map[string]bool)
-	hooks := []string{"pre-commit", "pre-push", "commit-msg"}
 
-	gitDir := filepath.Join(repoPath, ".git")
-	hooksDir := filepath.Join(gitDir, "hooks")
+	target, err := findTarget(repoPath)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, hook := range hooks {
-		hookPath := filepath.Join(hooksDir, hook)
+	for _, hook := range clientHooks {
+		hookPath := filepath.Join(target.dir, hook)
 
 		if content, err := os.ReadFile(hookPath); err == nil {
 			status[hook] = strings.Contains(string(content), "GitGuardian")
@@ -356,8 +598,9 @@ func CheckHooksInstalled(repoPath string) (map[string]bool, error) {
 	return status, nil
 }
 
-// generates a hook script for a specific platform
-func GenerateHookScript(hookType, binaryPath string) string {
+// generates a hook script; the same sh script serves every platform,
+// Windows included (see windowsHookShell)
+func GenerateHookScript(hookType, binaryPath string, asciiOnly bool) string {
 	var script string
 
 	switch hookType {
@@ -367,53 +610,64 @@ func GenerateHookScript(hookType, binaryPath string) string {
 		script = prePushHook
 	case "commit-msg":
 		script = commitMsgHook
+	case "pre-receive":
+		script = preReceiveHook
 	default:
 		return ""
 	}
+	script = localizeScript(script, asciiOnly)
 
-	// replace binary path if specified
+	// replace binary path if specified; the sh of Git for Windows takes
+	// C:/tools/gitguardian.exe but not every backslash
 	if binaryPath != "" {
+		if runtime.GOOS == "windows" {
+			binaryPath = filepath.ToSlash(binaryPath)
+		}
 		script = strings.Replace(script, `GITGUARDIAN_BIN="gitguardian"`,
 			fmt.Sprintf(`GITGUARDIAN_BIN="%s"`, binaryPath), 1)
 	}
 
-	// adjust for windows if needed
-	if runtime.GOOS == "windows" {
-		// Convert to Windows batch script
-		script = convertToWindowsBatch(script)
-	}
-
 	return script
 }
 
-// converts shell script to Windows batch script
-func convertToWindowsBatch(shellScript string) string {
-	batchScript := `@echo off
-REM GitGuardian Windows hook
-REM This is a simplified Windows version of the hook
-
-set GITGUARDIAN_BIN=gitguardian.exe
-
-where %GITGUARDIAN_BIN% >nul 2>nul
-if %ERRORLEVEL% neq 0 (
-    echo Warning: gitguardian.exe not found in PATH
-    echo Please ensure GitGuardian is installed and available in your PATH
-    exit /b 0
-)
-
-echo Running GitGuardian security scan...
-%GITGUARDIAN_BIN% -path . -format text
-
-if %ERRORLEVEL% neq 0 (
-    echo.
-    echo Security issues found!
-    echo Please fix the issues above before proceeding.
-    echo.
-    exit /b 1
-)
+var scriptMessage = regexp.MustCompile(`(?m)^(\s*echo ")([^"$\x60\\]+)("\s*)$`)
+
+// translates the messages a hook script echoes into the language i18n
+// selects, and into plain ASCII if asked; hooks are written at install
+// time, so they keep the language they were installed in
+func localizeScript(script string, asciiOnly bool) string {
+	return scriptMessage.ReplaceAllStringFunc(script, func(line string) string {
+		m := scriptMessage.FindStringSubmatch(line)
+		message := i18n.T(m[2])
+		if asciiOnly {
+			message = ascii.String(message)
+		}
+		return m[1] + message + m[3]
+	})
+}
 
-echo No security issues found
-exit /b 0
-`
-	return batchScript
+// returns the sh that runs hooks on Windows. Git for Windows starts a
+// hook through the interpreter its #! line names, from its own usr/bin,
+// so the sh scripts above run there unchanged, staged scans, push ranges
+// and commit message checks included; "gitguardian" finds
+// gitguardian.exe on the PATH. The scripts must keep LF line endings.
+func windowsHookShell() (string, error) {
+	out, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		return "", fmt.Errorf("git not found: %w", err)
+	}
+	// e.g. C:/Program Files/Git/mingw64/libexec/git-core
+	root := filepath.Join(strings.TrimSpace(string(out)), "..", "..", "..")
+	for _, sh := range []string{
+		filepath.Join(root, "usr", "bin", "sh.exe"),
+		filepath.Join(root, "bin", "sh.exe"),
+	} {
+		if _, err := os.Stat(sh); err == nil {
+			return filepath.Clean(sh), nil
+		}
+	}
+	if sh, err := exec.LookPath("sh"); err == nil {
+		return sh, nil
+	}
+	return "", fmt.Errorf("no sh found to run git hooks with; install Git for Windows, which runs them with its own sh")
 }
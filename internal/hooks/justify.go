@@ -0,0 +1,114 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+var justificationTrailer = regexp.MustCompile(`(?im)^justification:[ \t]*(\S.*)$`)
+
+// returns the text of a "Justification:" trailer, if any
+func findJustification(message string) string {
+	match := justificationTrailer.FindStringSubmatch(message)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
+
+// lists staged changes that need a justification: suspicious keywords and
+// findings silenced by ignore comments
+func stagedJustificationReasons(ctx context.Context, cfg *config.Config) []string {
+	staged := *cfg
+	staged.DisabledDetectors = append(append([]string{}, cfg.DisabledDetectors...), "dependencies")
+
+	results, err := scanner.New(&staged).ScanStaged(ctx, ".", scanner.ScanTypeAll)
+	if err != nil {
+		return nil
+	}
+
+	var reasons []string
+	for _, issue := range results.Issues {
+		if issue.Type == "social" {
+			reasons = append(reasons, fmt.Sprintf("%s:%d: %s", issue.File, issue.Line, issue.Description))
+		}
+	}
+	for _, issue := range results.Suppressed {
+		// placeholders are recognized, not silenced by the author
+		if issue.SuppressedBy == "placeholder" {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("%s:%d: %s bypassed by %s ignore", issue.File, issue.Line, issue.Rule, issue.SuppressedBy))
+	}
+	return reasons
+}
+
+// adds a trailer to the commit message file
+func appendTrailer(msgFile string, original []byte, trailer string) error {
+	message := strings.TrimRight(string(original), "\n")
+	if err := os.WriteFile(msgFile, []byte(message+"\n\n"+trailer+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to update commit message: %w", err)
+	}
+	return nil
+}
+
+// one justified commit in the audit log
+type auditEntry struct {
+	Time          time.Time `json:"time"`
+	Author        string    `json:"author"`
+	Reasons       []string  `json:"reasons"`
+	Justification string    `json:"justification"`
+}
+
+// appends a JSON line to the audit log, by default
+// <git dir>/gitguardian/audit.jsonl, shared by the repository's worktrees
+func recordJustification(cfg *config.Config, reasons []string, justification string) error {
+	path := cfg.SocialEngineering.AuditLog
+	if path == "" {
+		out, err := exec.Command("git", "rev-parse", "--git-common-dir").Output()
+		if err != nil {
+			return fmt.Errorf("failed to locate git directory: %w", err)
+		}
+		path = filepath.Join(strings.TrimSpace(string(out)), "gitguardian", "audit.jsonl")
+	}
+
+	entry := auditEntry{
+		Time:          time.Now().UTC(),
+		Author:        gitIdentity(),
+		Reasons:       reasons,
+		Justification: justification,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// returns "Name <email>" from the git configuration
+func gitIdentity() string {
+	name, _ := exec.Command("git", "config", "user.name").Output()
+	email, _ := exec.Command("git", "config", "user.email").Output()
+	return strings.TrimSpace(fmt.Sprintf("%s <%s>", strings.TrimSpace(string(name)), strings.TrimSpace(string(email))))
+}
@@ -0,0 +1,28 @@
+package hooks
+
+import "strings"
+
+// the hooks GitGuardian offers the pre-commit framework (pre-commit.com).
+// With golang, pre-commit builds the scanner from the repository the
+// hooks come from; with system, gitguardian must be on the PATH, as for a
+// "repo: local" entry in .pre-commit-config.yaml.
+const preCommitManifest = `- id: gitguardian
+  name: GitGuardian
+  description: Scan the files being committed for secrets and vulnerable dependencies
+  entry: gitguardian hook run pre-commit
+  language: LANGUAGE
+  pass_filenames: true
+  stages: [pre-commit]
+- id: gitguardian-commit-msg
+  name: GitGuardian commit message
+  description: Check the commit message for secrets and suspicious keywords
+  entry: gitguardian hook run commit-msg
+  language: LANGUAGE
+  stages: [commit-msg]
+`
+
+// returns the entries of a .pre-commit-hooks.yaml for the given language,
+// golang or system
+func PreCommitManifest(language string) string {
+	return strings.ReplaceAll(preCommitManifest, "LANGUAGE", language)
+}
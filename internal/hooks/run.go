@@ -0,0 +1,172 @@
+package hooks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// checks a commit message for secrets and suspicious keywords; returns
+// false when the commit should be rejected
+func RunCommitMsg(ctx context.Context, cfg *config.Config, msgFile string, out io.Writer) (bool, error) {
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read commit message: %w", err)
+	}
+
+	message := stripCommitComments(string(data))
+	if strings.TrimSpace(message) == "" {
+		return true, nil
+	}
+
+	s := scanner.New(cfg)
+	results := s.ScanBlobs(ctx, []scanner.Blob{{Path: "COMMIT_EDITMSG", Content: message}}, scanner.ScanTypeAll)
+
+	var secrets, social []scanner.Issue
+	for _, issue := range dropObjectReferences(s, message, results.Issues) {
+		switch issue.Type {
+		case "secret":
+			secrets = append(secrets, issue)
+		case "social":
+			social = append(social, issue)
+		}
+	}
+
+	if len(secrets) > 0 {
+		i18n.Fprint(out, "❌ Secrets detected in commit message:\n")
+		for _, issue := range secrets {
+			fmt.Fprint(out, "  ")
+			i18n.Fprintf(out, "line %d: %s (%s)\n", issue.Line, issue.Description, issue.Content)
+		}
+		i18n.Fprint(out, "\nRemove the secret from the message before committing.\n")
+		return false, nil
+	}
+
+	var reasons []string
+	for _, issue := range social {
+		i18n.Fprintf(out, "⚠️  Warning: %s\n", issue.Description)
+		reasons = append(reasons, fmt.Sprintf("commit message: %s", issue.Description))
+	}
+
+	if !cfg.SocialEngineering.RequireJustification {
+		if len(reasons) > 0 {
+			i18n.Fprint(out, "\nPlease review your commit message for security implications.\n")
+		}
+		return true, nil
+	}
+
+	// the index still holds what is about to be committed
+	reasons = append(reasons, stagedJustificationReasons(ctx, cfg)...)
+	if len(reasons) == 0 {
+		return true, nil
+	}
+
+	justification := findJustification(message)
+	if justification == "" {
+		i18n.Fprint(out, "\nThis commit needs a justification:\n")
+		for _, reason := range reasons {
+			fmt.Fprintf(out, "  - %s\n", reason)
+		}
+
+		answer, interactive := promptLine(ascii.Label(out, i18n.T("Justification:"))+" ", out)
+		if !interactive {
+			// the trailer keyword is parsed, so it stays in English
+			i18n.Fprint(out, "\nAdd a \"Justification: <reason>\" trailer to the commit message,\n")
+			i18n.Fprint(out, "or use --no-verify to bypass.\n")
+			return false, nil
+		}
+		if answer == "" {
+			i18n.Fprint(out, "No justification given; rejecting commit.\n")
+			return false, nil
+		}
+
+		if err := appendTrailer(msgFile, data, "Justification: "+answer); err != nil {
+			return false, err
+		}
+		justification = answer
+	}
+
+	if err := recordJustification(cfg, reasons, justification); err != nil {
+		cfg.Log().Warn("failed to write audit log", "error", err)
+	}
+	return true, nil
+}
+
+// looks like a full SHA-1 or SHA-256 object name
+var objectNamePattern = regexp.MustCompile(`\b(?:[0-9a-f]{64}|[0-9a-f]{40})\b`)
+
+// drops secret findings that are commit or object ids named in the
+// message, as in "This reverts commit ...", which the hex token patterns
+// would otherwise flag; only ids of objects in the repository count
+func dropObjectReferences(s *scanner.Scanner, message string, issues []scanner.Issue) []scanner.Issue {
+	references := make(map[string]bool)
+	for _, name := range objectNamePattern.FindAllString(message, -1) {
+		if exec.Command("git", "cat-file", "-e", name).Run() == nil {
+			references[s.HashSecret(name)] = true
+		}
+	}
+	if len(references) == 0 {
+		return issues
+	}
+
+	var kept []scanner.Issue
+	for _, issue := range issues {
+		if issue.Type == "secret" && references[issue.SecretHash] {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
+
+// drops the comment lines git strips from the final message, stopping at
+// the scissors line used by `git commit -v`
+func stripCommitComments(message string) string {
+	var kept []string
+	for _, line := range strings.Split(message, "\n") {
+		trimmed := strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(trimmed, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// reads one line from the controlling terminal; hooks run with stdin
+// detached, so the terminal is opened directly and the second result is
+// false when there is none
+func promptLine(question string, out io.Writer) (string, bool) {
+	ttyPath := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		ttyPath = "CONIN$"
+	}
+
+	tty, err := os.Open(ttyPath)
+	if err != nil {
+		return "", false
+	}
+	defer tty.Close()
+
+	fmt.Fprint(out, question)
+	reply, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && reply == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(reply), true
+}
@@ -0,0 +1,130 @@
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+)
+
+// the language messages are written in, and the key of every catalog
+const DefaultLanguage = "en"
+
+var (
+	mu       sync.RWMutex
+	language string // empty until selected or detected
+)
+
+// selects the language of user-facing messages; an empty lang picks it
+// from LC_ALL, LC_MESSAGES or LANG, falling back to English when the
+// environment names a language without a catalog
+func SetLanguage(lang string) error {
+	if lang == "" {
+		mu.Lock()
+		language = detect()
+		mu.Unlock()
+		return nil
+	}
+
+	code := normalize(lang)
+	if code != DefaultLanguage && catalogs[code] == nil {
+		return fmt.Errorf("unsupported language %q: use %s", lang, strings.Join(Languages(), ", "))
+	}
+	mu.Lock()
+	language = code
+	mu.Unlock()
+	return nil
+}
+
+// returns the selected language, detecting it on first use
+func Language() string {
+	mu.RLock()
+	lang := language
+	mu.RUnlock()
+	if lang != "" {
+		return lang
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if language == "" {
+		language = detect()
+	}
+	return language
+}
+
+// lists the languages with a catalog
+func Languages() []string {
+	langs := []string{DefaultLanguage}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// translates a message into the selected language; leading and trailing
+// newlines are kept out of the lookup, and messages missing from the
+// catalog are returned as they are
+func T(msg string) string {
+	catalog := catalogs[Language()]
+	if catalog == nil {
+		return msg
+	}
+
+	core := strings.Trim(msg, "\n")
+	translated, ok := catalog[core]
+	if !ok {
+		return msg
+	}
+	start := strings.Index(msg, core)
+	return msg[:start] + translated + msg[start+len(core):]
+}
+
+// formats a translated message
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// writes a translated, formatted message to w; the message, not its
+// arguments, is converted for output that must be plain ASCII
+func Fprintf(w io.Writer, format string, args ...interface{}) (int, error) {
+	return fmt.Fprintf(w, ascii.Label(w, T(format)), args...)
+}
+
+// writes a translated message to w, as Fprintf does
+func Fprint(w io.Writer, msg string) (int, error) {
+	return io.WriteString(w, ascii.Label(w, T(msg)))
+}
+
+// the first supported language the locale variables name
+func detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if code := normalize(value); catalogs[code] != nil {
+			return code
+		}
+		// the first variable set decides, as in setlocale
+		return DefaultLanguage
+	}
+	return DefaultLanguage
+}
+
+// reduces a locale such as "de_DE.UTF-8" or "es-MX" to its language code
+func normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "c" || locale == "posix" {
+		return DefaultLanguage
+	}
+	return locale
+}
@@ -0,0 +1,143 @@
+package i18n
+
+// translations keyed by the English message; a message missing from a
+// catalog is shown in English. Messages echoed by hook scripts must not
+// contain double quotes, dollar signs, backquotes or backslashes.
+var catalogs = map[string]map[string]string{
+	"es": spanish,
+	"de": german,
+}
+
+var spanish = map[string]string{
+	// text report
+	"GitGuardian Security Scan Results": "Resultados del análisis de seguridad de GitGuardian",
+	"Scan completed at: %s":             "Análisis completado el: %s",
+	"Duration: %s":                      "Duración: %s",
+	"Commits scanned: %d":               "Commits analizados: %d",
+	"Revision: %s":                      "Revisión: %s",
+	"Files scanned: %d":                 "Archivos analizados: %d",
+	"Suppressed by ignore comments or as placeholders: %d":       "Suprimidos por comentarios de exclusión o como marcadores de posición: %d",
+	"Repeats of secrets listed once: %d":                         "Repeticiones de secretos listados una vez: %d",
+	"Findings on unchanged lines not reported: %d":               "Hallazgos en líneas sin cambios no reportados: %d",
+	"Risk score: %d/100 (%s)":                                    "Puntuación de riesgo: %d/100 (%s)",
+	"✅ No security issues found!":                                "✅ ¡No se encontraron problemas de seguridad!",
+	"⚠️  Scan incomplete: stopped before everything was scanned": "⚠️  Análisis incompleto: se detuvo antes de analizarlo todo",
+	"Summary:":     "Resumen:",
+	"Critical":     "Crítico",
+	"High":         "Alto",
+	"Medium":       "Medio",
+	"Low":          "Bajo",
+	"Total":        "Total",
+	"By type:":     "Por tipo:",
+	"By category:": "Por categoría:",
+	"%d (critical %d, high %d, medium %d, low %d)": "%d (crítico %d, alto %d, medio %d, bajo %d)",
+	"Issues Found:":           "Problemas encontrados:",
+	"[NEW]":                   "[NUEVO]",
+	"File":                    "Archivo",
+	"Rule":                    "Regla",
+	"Verified":                "Verificado",
+	"Exploitation":            "Explotación",
+	"known exploited (KEV)":   "explotada activamente (KEV)",
+	"Reused in %d places: %s": "Reutilizado en %d lugares: %s",
+	"Commit":                  "Commit",
+	"Content":                 "Contenido",
+	"Fingerprint":             "Huella",
+	"Owner":                   "Responsable",
+	"Remediation":             "Remediación",
+	"Reference":               "Referencia",
+
+	// commit-msg hook
+	"❌ Secrets detected in commit message:":                            "❌ Se detectaron secretos en el mensaje del commit:",
+	"line %d: %s (%s)":                                                 "línea %d: %s (%s)",
+	"Remove the secret from the message before committing.":            "Elimine el secreto del mensaje antes de hacer el commit.",
+	"⚠️  Warning: %s":                                                  "⚠️  Advertencia: %s",
+	"Please review your commit message for security implications.":     "Revise las implicaciones de seguridad del mensaje del commit.",
+	"This commit needs a justification:":                               "Este commit necesita una justificación:",
+	"Justification:":                                                   "Justificación:",
+	"Add a \"Justification: <reason>\" trailer to the commit message,": "Añada una línea final \"Justification: <motivo>\" al mensaje del commit,",
+	"or use --no-verify to bypass.":                                    "o use --no-verify para omitir la comprobación.",
+	"No justification given; rejecting commit.":                        "No se dio ninguna justificación; se rechaza el commit.",
+
+	// hook scripts
+	"Warning: gitguardian binary not found in PATH":                                         "Advertencia: no se encontró el binario gitguardian en el PATH",
+	"Please ensure GitGuardian is installed and available in your PATH":                     "Asegúrese de que GitGuardian esté instalado y disponible en su PATH",
+	"No staged files to scan":                                                               "No hay archivos preparados que analizar",
+	"🔍 Running GitGuardian security scan on staged files...":                                "🔍 Ejecutando el análisis de seguridad de GitGuardian en los archivos preparados...",
+	"❌ Security issues found in staged files!":                                              "❌ ¡Se encontraron problemas de seguridad en los archivos preparados!",
+	"Please fix the issues above before committing.":                                        "Corrija los problemas anteriores antes de hacer el commit.",
+	"To bypass this check (NOT RECOMMENDED), use:":                                          "Para omitir esta comprobación (NO RECOMENDADO), use:",
+	"✅ No security issues found in staged files":                                            "✅ No se encontraron problemas de seguridad en los archivos preparados",
+	"🔍 Running GitGuardian security scan on changed files...":                               "🔍 Ejecutando el análisis de seguridad de GitGuardian en los archivos modificados...",
+	"❌ Security issues found in files being pushed!":                                        "❌ ¡Se encontraron problemas de seguridad en los archivos que se envían!",
+	"Please fix the issues above before pushing.":                                           "Corrija los problemas anteriores antes de hacer push.",
+	"✅ No security issues found in changed files":                                           "✅ No se encontraron problemas de seguridad en los archivos modificados",
+	"GitGuardian: scanner binary not found on the server, rejecting push":                   "GitGuardian: no se encontró el escáner en el servidor, se rechaza el push",
+	"GitGuardian: push rejected, fix the issues above in the pushed commits and push again": "GitGuardian: push rechazado, corrija los problemas anteriores en los commits enviados y vuelva a hacer push",
+}
+
+var german = map[string]string{
+	// text report
+	"GitGuardian Security Scan Results": "Ergebnisse der GitGuardian-Sicherheitsprüfung",
+	"Scan completed at: %s":             "Prüfung abgeschlossen am: %s",
+	"Duration: %s":                      "Dauer: %s",
+	"Commits scanned: %d":               "Geprüfte Commits: %d",
+	"Revision: %s":                      "Revision: %s",
+	"Files scanned: %d":                 "Geprüfte Dateien: %d",
+	"Suppressed by ignore comments or as placeholders: %d":       "Durch Ignorier-Kommentare oder als Platzhalter unterdrückt: %d",
+	"Repeats of secrets listed once: %d":                         "Wiederholungen einmal aufgeführter Secrets: %d",
+	"Findings on unchanged lines not reported: %d":               "Nicht gemeldete Funde in unveränderten Zeilen: %d",
+	"Risk score: %d/100 (%s)":                                    "Risikowert: %d/100 (%s)",
+	"✅ No security issues found!":                                "✅ Keine Sicherheitsprobleme gefunden!",
+	"⚠️  Scan incomplete: stopped before everything was scanned": "⚠️  Scan unvollständig: vor dem Ende abgebrochen",
+	"Summary:":     "Zusammenfassung:",
+	"Critical":     "Kritisch",
+	"High":         "Hoch",
+	"Medium":       "Mittel",
+	"Low":          "Niedrig",
+	"Total":        "Gesamt",
+	"By type:":     "Nach Typ:",
+	"By category:": "Nach Kategorie:",
+	"%d (critical %d, high %d, medium %d, low %d)": "%d (kritisch %d, hoch %d, mittel %d, niedrig %d)",
+	"Issues Found:":           "Gefundene Probleme:",
+	"[NEW]":                   "[NEU]",
+	"File":                    "Datei",
+	"Rule":                    "Regel",
+	"Verified":                "Verifiziert",
+	"Exploitation":            "Ausnutzung",
+	"known exploited (KEV)":   "aktiv ausgenutzt (KEV)",
+	"Reused in %d places: %s": "An %d Stellen wiederverwendet: %s",
+	"Commit":                  "Commit",
+	"Content":                 "Inhalt",
+	"Fingerprint":             "Fingerabdruck",
+	"Owner":                   "Verantwortlich",
+	"Remediation":             "Behebung",
+	"Reference":               "Referenz",
+
+	// commit-msg hook
+	"❌ Secrets detected in commit message:":                            "❌ Geheimnisse in der Commit-Nachricht gefunden:",
+	"line %d: %s (%s)":                                                 "Zeile %d: %s (%s)",
+	"Remove the secret from the message before committing.":            "Entfernen Sie das Geheimnis vor dem Commit aus der Nachricht.",
+	"⚠️  Warning: %s":                                                  "⚠️  Warnung: %s",
+	"Please review your commit message for security implications.":     "Bitte prüfen Sie die Commit-Nachricht auf Sicherheitsfolgen.",
+	"This commit needs a justification:":                               "Dieser Commit braucht eine Begründung:",
+	"Justification:":                                                   "Begründung:",
+	"Add a \"Justification: <reason>\" trailer to the commit message,": "Fügen Sie der Commit-Nachricht eine Zeile \"Justification: <Grund>\" hinzu",
+	"or use --no-verify to bypass.":                                    "oder umgehen Sie die Prüfung mit --no-verify.",
+	"No justification given; rejecting commit.":                        "Keine Begründung angegeben; der Commit wird abgelehnt.",
+
+	// hook scripts
+	"Warning: gitguardian binary not found in PATH":                                         "Warnung: gitguardian wurde im PATH nicht gefunden",
+	"Please ensure GitGuardian is installed and available in your PATH":                     "Bitte stellen Sie sicher, dass GitGuardian installiert und im PATH verfügbar ist",
+	"No staged files to scan":                                                               "Keine vorgemerkten Dateien zu prüfen",
+	"🔍 Running GitGuardian security scan on staged files...":                                "🔍 GitGuardian prüft die vorgemerkten Dateien...",
+	"❌ Security issues found in staged files!":                                              "❌ Sicherheitsprobleme in vorgemerkten Dateien gefunden!",
+	"Please fix the issues above before committing.":                                        "Bitte beheben Sie die obigen Probleme vor dem Commit.",
+	"To bypass this check (NOT RECOMMENDED), use:":                                          "Um diese Prüfung zu umgehen (NICHT EMPFOHLEN), verwenden Sie:",
+	"✅ No security issues found in staged files":                                            "✅ Keine Sicherheitsprobleme in vorgemerkten Dateien gefunden",
+	"🔍 Running GitGuardian security scan on changed files...":                               "🔍 GitGuardian prüft die geänderten Dateien...",
+	"❌ Security issues found in files being pushed!":                                        "❌ Sicherheitsprobleme in den zu pushenden Dateien gefunden!",
+	"Please fix the issues above before pushing.":                                           "Bitte beheben Sie die obigen Probleme vor dem Push.",
+	"✅ No security issues found in changed files":                                           "✅ Keine Sicherheitsprobleme in geänderten Dateien gefunden",
+	"GitGuardian: scanner binary not found on the server, rejecting push":                   "GitGuardian: Scanner auf dem Server nicht gefunden, Push wird abgelehnt",
+	"GitGuardian: push rejected, fix the issues above in the pushed commits and push again": "GitGuardian: Push abgelehnt, beheben Sie die obigen Probleme in den Commits und pushen Sie erneut",
+}
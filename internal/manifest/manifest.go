@@ -0,0 +1,221 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// a portfolio of repositories scanned together
+type Manifest struct {
+	Repos []Repo `json:"repos"`
+}
+
+// one repository in a manifest; fields other than Path/URL override the
+// settings used for that repository only
+type Repo struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`      // local checkout
+	URL      string   `json:"url"`       // remote cloned for the scan
+	Ref      string   `json:"ref"`       // branch or tag to clone
+	Config   string   `json:"config"`    // configuration file for this repo
+	Include  []string `json:"include"`   // path globs to scan
+	Exclude  []string `json:"exclude"`   // path globs to skip
+	Rules    []string `json:"rules"`     // secret rules to run
+	ScanType string   `json:"scan_type"` // all, secrets or dependencies
+}
+
+// reads a manifest; .json files are parsed as JSON and anything else as
+// the YAML subset documented in the README
+func Load(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+	} else if m.Repos, err = parseYAML(string(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	// relative paths are relative to the manifest, not the working directory
+	base := filepath.Dir(file)
+	for i := range m.Repos {
+		repo := &m.Repos[i]
+		if repo.Path == "" && repo.URL == "" {
+			return nil, fmt.Errorf("repo %d: path or url is required", i+1)
+		}
+		if repo.Path != "" && !filepath.IsAbs(repo.Path) {
+			repo.Path = filepath.Join(base, repo.Path)
+		}
+		if repo.Config != "" && !filepath.IsAbs(repo.Config) {
+			repo.Config = filepath.Join(base, repo.Config)
+		}
+		if repo.Name == "" {
+			repo.Name = defaultName(*repo)
+		}
+	}
+
+	return m, nil
+}
+
+func defaultName(repo Repo) string {
+	if repo.Path != "" {
+		return filepath.Base(repo.Path)
+	}
+	return strings.TrimSuffix(path.Base(strings.TrimRight(repo.URL, "/")), ".git")
+}
+
+// parses the YAML subset a manifest needs: a top-level "repos" list of
+// mappings whose values are scalars or lists of scalars
+func parseYAML(text string) ([]Repo, error) {
+	var repos []Repo
+	var current *Repo
+	var listKey string // key whose block list is being read
+	inRepos := false
+
+	for n, raw := range strings.Split(text, "\n") {
+		lineNum := n + 1
+		line := stripComment(strings.TrimRight(raw, "\r"))
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(line)
+
+		if indent == 0 {
+			if line != "repos:" {
+				return nil, fmt.Errorf("line %d: expected \"repos:\"", lineNum)
+			}
+			inRepos = true
+			continue
+		}
+		if !inRepos {
+			return nil, fmt.Errorf("line %d: expected \"repos:\"", lineNum)
+		}
+
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			item := strings.TrimSpace(strings.TrimPrefix(line, "-"))
+
+			// a scalar item of a block list such as "exclude:"
+			if listKey != "" && !strings.Contains(item, ": ") && !strings.HasSuffix(item, ":") {
+				if err := current.set(listKey, []string{unquote(item)}, true); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				continue
+			}
+
+			repos = append(repos, Repo{})
+			current = &repos[len(repos)-1]
+			listKey = ""
+			if item == "" {
+				continue
+			}
+			line = item
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: expected a list item", lineNum)
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		listKey = ""
+		switch {
+		case value == "":
+			listKey = key
+			if err := current.set(key, nil, true); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					items = append(items, item)
+				}
+			}
+			if err := current.set(key, items, true); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		default:
+			if err := current.set(key, []string{unquote(value)}, false); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+	}
+
+	return repos, nil
+}
+
+// assigns a manifest field; list values are appended
+func (r *Repo) set(key string, values []string, list bool) error {
+	lists := map[string]*[]string{
+		"include": &r.Include,
+		"exclude": &r.Exclude,
+		"rules":   &r.Rules,
+	}
+	scalars := map[string]*string{
+		"name":      &r.Name,
+		"path":      &r.Path,
+		"url":       &r.URL,
+		"ref":       &r.Ref,
+		"config":    &r.Config,
+		"scan_type": &r.ScanType,
+	}
+
+	if field, ok := lists[key]; ok {
+		*field = append(*field, values...)
+		return nil
+	}
+	if field, ok := scalars[key]; ok {
+		if list {
+			return fmt.Errorf("%s must be a single value", key)
+		}
+		*field = values[0]
+		return nil
+	}
+	return fmt.Errorf("unknown field %q", key)
+}
+
+// drops a trailing "# comment" that is not inside quotes
+func stripComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
@@ -0,0 +1,230 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// delivers findings somewhere people will see them
+type Sink interface {
+	Name() string
+	// identifies the endpoint without revealing it, so queued deliveries
+	// find their sink again without webhook URLs being written to disk
+	Target() string
+	// the JSON body that tells the endpoint about issues
+	Message(results *scanner.Results, issues []scanner.Issue) ([]byte, error)
+	Deliver(ctx context.Context, body []byte) error
+}
+
+// creates the sinks described by the configuration
+func NewSinks(cfg config.NotifyConfig) ([]Sink, error) {
+	var sinks []Sink
+	for _, sc := range cfg.Sinks {
+		if sc.URL == "" {
+			return nil, fmt.Errorf("%s sink requires a url", sc.Type)
+		}
+		switch sc.Type {
+		case "webhook":
+			sinks = append(sinks, &WebhookSink{URL: sc.URL, client: newClient()})
+		case "slack":
+			sinks = append(sinks, &SlackSink{URL: sc.URL, client: newClient()})
+		default:
+			return nil, fmt.Errorf("unknown sink type: %s", sc.Type)
+		}
+	}
+	return sinks, nil
+}
+
+// sends issues to every sink; the issues chosen depend on OnlyNew and
+// MinSeverity, and nothing is sent when none remain. Deliveries queue
+// holds from earlier runs go first. A delivery failing with a transient
+// error (no connection, 408, 429 or 5xx) is retried a few times and then
+// kept in queue for the next run; one the endpoint refuses goes to its
+// dead letters. queue may be nil, and is not saved.
+func Send(ctx context.Context, cfg config.NotifyConfig, sinks []Sink, results *scanner.Results, queue *Queue) []error {
+	now := time.Now()
+	var errs []error
+	if queue != nil {
+		errs = queue.Flush(ctx, sinks, now)
+	}
+
+	var issues []scanner.Issue
+	for _, issue := range results.Issues {
+		if cfg.OnlyNew && !issue.New {
+			continue
+		}
+		if scanner.SeverityRank(issue.Severity) < scanner.SeverityRank(cfg.MinSeverity) {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	if len(issues) == 0 {
+		return errs
+	}
+
+	for _, sink := range sinks {
+		body, err := sink.Message(results, issues)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		if err := deliver(ctx, sink, body); err != nil {
+			if queue != nil {
+				err = queue.add(sink, body, err, now)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errs
+}
+
+// attempts and backoff of a delivery within one run; an endpoint's
+// Retry-After is waited for up to maxRetryAfter
+const (
+	deliveryAttempts = 3
+	retryBackoff     = time.Second
+	maxRetryAfter    = 30 * time.Second
+)
+
+// a delivery the endpoint did not accept
+type deliveryError struct {
+	err        error
+	transient  bool // worth trying again later
+	retryAfter time.Duration
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
+func (e *deliveryError) Unwrap() error { return e.err }
+
+func isTransient(err error) bool {
+	var derr *deliveryError
+	return errors.As(err, &derr) && derr.transient
+}
+
+// delivers body, retrying transient failures with exponential backoff
+func deliver(ctx context.Context, sink Sink, body []byte) error {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := sink.Deliver(ctx, body)
+		var derr *deliveryError
+		if err == nil || !errors.As(err, &derr) || !derr.transient || attempt == deliveryAttempts {
+			return err
+		}
+
+		delay := backoff
+		if derr.retryAfter > 0 {
+			delay = min(derr.retryAfter, maxRetryAfter)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func newClient() *http.Client {
+	return &http.Client{Timeout: 15 * time.Second}
+}
+
+// a short hash of an endpoint URL
+func target(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:8])
+}
+
+// posts findings as JSON to any HTTP endpoint
+type WebhookSink struct {
+	URL    string
+	client *http.Client
+}
+
+func (w *WebhookSink) Name() string   { return "webhook" }
+func (w *WebhookSink) Target() string { return target(w.URL) }
+
+func (w *WebhookSink) Message(results *scanner.Results, issues []scanner.Issue) ([]byte, error) {
+	payload := map[string]interface{}{
+		"scan_time": results.ScanTime,
+		"summary":   results.Summary,
+		"issues":    issues,
+	}
+	return json.Marshal(payload)
+}
+
+func (w *WebhookSink) Deliver(ctx context.Context, body []byte) error {
+	return postJSON(ctx, w.client, w.URL, body)
+}
+
+// posts a short message to a Slack incoming webhook
+type SlackSink struct {
+	URL    string
+	client *http.Client
+}
+
+func (s *SlackSink) Name() string   { return "slack" }
+func (s *SlackSink) Target() string { return target(s.URL) }
+
+func (s *SlackSink) Message(results *scanner.Results, issues []scanner.Issue) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, ":rotating_light: GitGuardian found %d issue(s)\n", len(issues))
+	for i, issue := range issues {
+		if i == 10 {
+			fmt.Fprintf(&b, "...and %d more\n", len(issues)-i)
+			break
+		}
+		fmt.Fprintf(&b, "• [%s] %s in `%s:%d`\n", strings.ToUpper(issue.Severity), issue.Description, issue.File, issue.Line)
+	}
+	return json.Marshal(map[string]string{"text": b.String()})
+}
+
+func (s *SlackSink) Deliver(ctx context.Context, body []byte) error {
+	return postJSON(ctx, s.client, s.URL, body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// the URL of a webhook is its credential; keep it out of errors,
+		// which are logged and queued
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return &deliveryError{err: err, transient: ctx.Err() == nil}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	derr := &deliveryError{
+		err:       fmt.Errorf("endpoint returned status %d", resp.StatusCode),
+		transient: resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		derr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return derr
+}
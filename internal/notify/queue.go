@@ -0,0 +1,242 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// a notification a sink did not take
+type Delivery struct {
+	Sink        string          `json:"sink"`
+	Target      string          `json:"target"` // the sink's Target
+	Body        json.RawMessage `json:"body"`
+	Created     time.Time       `json:"created"`
+	Attempts    int             `json:"attempts"` // runs that tried it
+	LastAttempt time.Time       `json:"last_attempt"`
+	LastError   string          `json:"last_error"`
+}
+
+// the most dead letters kept; older ones are dropped
+const maxDeadLetters = 100
+
+// keeps deliveries that failed on disk, pending ones for later runs to
+// retry and dead letters, the ones given up on, for people to look at
+type Queue struct {
+	path        string
+	maxAttempts int
+	maxAge      time.Duration
+	pending     []Delivery
+	dead        []Delivery
+	dirty       bool
+}
+
+// the queue file
+type queueFile struct {
+	Pending []Delivery `json:"pending"`
+	Dead    []Delivery `json:"dead"`
+}
+
+// opens the queue at path; a missing file is an empty queue
+func OpenQueue(path string, cfg config.NotifyQueueConfig) (*Queue, error) {
+	maxAge, err := config.ParseAge(cfg.MaxAge)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{path: path, maxAttempts: cfg.MaxAttempts, maxAge: maxAge}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("failed to read notification queue: %w", err)
+	}
+	var file queueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse notification queue %s: %w", path, err)
+	}
+	q.pending, q.dead = file.Pending, file.Dead
+	return q, nil
+}
+
+// returns the deliveries waiting to be retried, oldest first
+func (q *Queue) Pending() []Delivery {
+	return q.pending
+}
+
+// returns the deliveries given up on, oldest first
+func (q *Queue) Dead() []Delivery {
+	return q.dead
+}
+
+// queues a delivery that failed with err in its first run, or buries it
+// when err is not transient; returns err saying which
+func (q *Queue) add(sink Sink, body []byte, err error, now time.Time) error {
+	d := Delivery{
+		Sink:        sink.Name(),
+		Target:      sink.Target(),
+		Body:        body,
+		Created:     now,
+		Attempts:    1,
+		LastAttempt: now,
+		LastError:   err.Error(),
+	}
+	if !isTransient(err) || q.expired(d, now) {
+		q.bury(d)
+		return fmt.Errorf("%w; moved to the dead letters", err)
+	}
+	q.pending = append(q.pending, d)
+	q.dirty = true
+	return fmt.Errorf("%w; queued for retry", err)
+}
+
+// retries the pending deliveries in the order they were queued. One that
+// fails again stays queued until it has been tried in MaxAttempts runs
+// or is older than MaxAge, then it moves to the dead letters, as does one
+// the endpoint refuses or whose sink is no longer configured. Once a
+// sink fails, the rest of its deliveries wait for the next run. Returns
+// an error for every delivery buried.
+func (q *Queue) Flush(ctx context.Context, sinks []Sink, now time.Time) []error {
+	var errs []error
+	down := make(map[Sink]bool)
+	var kept []Delivery
+	for _, d := range q.pending {
+		sink := findSink(sinks, d)
+		if sink == nil {
+			d.LastError = "sink no longer configured"
+			q.bury(d)
+			errs = append(errs, deadLetterError(d))
+			continue
+		}
+		if down[sink] || ctx.Err() != nil {
+			kept = append(kept, d)
+			continue
+		}
+
+		q.dirty = true
+		err := sink.Deliver(ctx, d.Body)
+		if err == nil {
+			continue
+		}
+		d.Attempts++
+		d.LastAttempt = now
+		d.LastError = err.Error()
+		if !isTransient(err) || q.expired(d, now) {
+			q.bury(d)
+			errs = append(errs, deadLetterError(d))
+			continue
+		}
+		down[sink] = true
+		kept = append(kept, d)
+	}
+	q.pending = kept
+	return errs
+}
+
+// tries every dead letter once more, dropping those delivered; returns an
+// error for each that failed again
+func (q *Queue) RetryDead(ctx context.Context, sinks []Sink, now time.Time) []error {
+	var errs []error
+	var kept []Delivery
+	for _, d := range q.dead {
+		sink := findSink(sinks, d)
+		if sink == nil {
+			kept = append(kept, d)
+			errs = append(errs, fmt.Errorf("%s: sink no longer configured", d.Sink))
+			continue
+		}
+		q.dirty = true
+		if err := sink.Deliver(ctx, d.Body); err != nil {
+			d.Attempts++
+			d.LastAttempt = now
+			d.LastError = err.Error()
+			kept = append(kept, d)
+			errs = append(errs, fmt.Errorf("%s: %w", d.Sink, err))
+		}
+	}
+	q.dead = kept
+	return errs
+}
+
+// drops every dead letter, returning how many there were
+func (q *Queue) Purge() int {
+	n := len(q.dead)
+	if n > 0 {
+		q.dead = nil
+		q.dirty = true
+	}
+	return n
+}
+
+func (q *Queue) expired(d Delivery, now time.Time) bool {
+	if q.maxAttempts > 0 && d.Attempts >= q.maxAttempts {
+		return true
+	}
+	return q.maxAge > 0 && now.Sub(d.Created) > q.maxAge
+}
+
+func (q *Queue) bury(d Delivery) {
+	q.dead = append(q.dead, d)
+	if len(q.dead) > maxDeadLetters {
+		q.dead = q.dead[len(q.dead)-maxDeadLetters:]
+	}
+	q.dirty = true
+}
+
+func findSink(sinks []Sink, d Delivery) Sink {
+	for _, sink := range sinks {
+		if sink.Name() == d.Sink && sink.Target() == d.Target {
+			return sink
+		}
+	}
+	return nil
+}
+
+func deadLetterError(d Delivery) error {
+	return fmt.Errorf("%s: gave up on the notification of %s after %d attempt(s): %s; moved to the dead letters",
+		d.Sink, d.Created.Format(time.RFC3339), d.Attempts, d.LastError)
+}
+
+// writes the queue back to disk if it changed; it holds the findings
+// sent, masked as in reports, so only its owner may read it
+func (q *Queue) Save() error {
+	if !q.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(queueFile{Pending: q.pending, Dead: q.dead}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification queue: %w", err)
+	}
+
+	dir := filepath.Dir(q.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create notification queue directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "notify-queue.*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+
+	q.dirty = false
+	return nil
+}
@@ -0,0 +1,31 @@
+package report
+
+import (
+	"io"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+func init() {
+	Register("text", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputText(w)
+	}))
+	Register("json", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputJSON(w)
+	}))
+	Register("findings", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputFindings(w)
+	}))
+	Register("sarif", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputSARIF(w)
+	}))
+	Register("github", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputGitHub(w)
+	}))
+	Register("junit", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputJUnit(w)
+	}))
+	Register("html", ReporterFunc(func(w io.Writer, results *scanner.Results) error {
+		return results.OutputHTML(w)
+	}))
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// renders scan results in one output format
+type Reporter interface {
+	Report(w io.Writer, results *scanner.Results) error
+}
+
+// adapts a plain function to the Reporter interface
+type ReporterFunc func(w io.Writer, results *scanner.Results) error
+
+func (f ReporterFunc) Report(w io.Writer, results *scanner.Results) error {
+	return f(w, results)
+}
+
+var (
+	mu        sync.RWMutex
+	reporters = make(map[string]Reporter)
+)
+
+// makes a reporter available under a format name; builds can register
+// their own formats from an init function
+func Register(format string, r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := reporters[format]; exists {
+		panic(fmt.Sprintf("report: reporter already registered for format %q", format))
+	}
+	reporters[format] = r
+}
+
+// returns the reporter registered for a format
+func Get(format string) (Reporter, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	r, ok := reporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+	return r, nil
+}
+
+// lists the registered format names
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	formats := make([]string, 0, len(reporters))
+	for format := range reporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// writes results using the reporter registered for a format
+func Write(w io.Writer, format string, results *scanner.Results) error {
+	r, err := Get(format)
+	if err != nil {
+		return err
+	}
+	return r.Report(w, results)
+}
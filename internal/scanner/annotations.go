@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// outputs results as GitHub Actions workflow commands, which show up as
+// inline annotations on pull requests
+func (r *Results) OutputGitHub(w io.Writer) error {
+	for _, issue := range r.Occurrences() {
+		props := []string{"file=" + escapeProperty(filepath.ToSlash(issue.File))}
+		if issue.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", issue.Line))
+		}
+		if issue.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", issue.Column))
+		}
+		props = append(props, "title="+escapeProperty("GitGuardian: "+issue.Rule))
+
+		message := issue.Description
+		if issue.Content != "" {
+			message += "\n" + issue.Content
+		}
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", annotationLevel(issue.Severity), strings.Join(props, ","), escapeData(message)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "::notice title=GitGuardian::%s\n", escapeData(fmt.Sprintf(
+		"%d issues (critical %d, high %d, medium %d, low %d) in %d files",
+		r.Summary.Total, r.Summary.Critical, r.Summary.High, r.Summary.Medium, r.Summary.Low, r.FilesScanned)))
+	return err
+}
+
+func annotationLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
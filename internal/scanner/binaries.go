@@ -0,0 +1,221 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a file added by a commit, from git log --raw
+type addedBlob struct {
+	commit string
+	author string
+	path   string
+	mode   string
+	oid    string
+}
+
+// leading bytes of native executables
+var executableMagic = []struct {
+	format string
+	magic  []byte
+}{
+	{"ELF", []byte("\x7fELF")},
+	{"PE", []byte("MZ")},
+	{"Mach-O", []byte("\xcf\xfa\xed\xfe")},
+	{"Mach-O", []byte("\xce\xfa\xed\xfe")},
+}
+
+// flags executables, archives and large binaries added by the commits git
+// log selects with revs; text files are left to the content scan
+func (s *Scanner) checkAddedBinaries(ctx context.Context, repoPath string, revs []string) ([]Issue, error) {
+	args := []string{"log", "--raw", "--no-abbrev", "--no-renames", "--diff-filter=A",
+		"--format=%x00commit %H%x00%an <%ae>"}
+	args = append(args, revs...)
+	args = append(args, "--")
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to list added files: %w", err)
+	}
+
+	var added []addedBlob
+	var commit, author string
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, historyCommitMarker):
+			commit, author, _ = strings.Cut(strings.TrimPrefix(line, historyCommitMarker), "\x00")
+		case strings.HasPrefix(line, ":"):
+			// :000000 100644 0000000... <oid> A\t<path>
+			meta, path, ok := strings.Cut(line, "\t")
+			fields := strings.Fields(meta)
+			if !ok || len(fields) < 4 || fields[1] == "160000" {
+				continue // submodules have no blob
+			}
+			path = unquoteGitPath(path)
+			if s.binaryAllowed(path) {
+				continue
+			}
+			added = append(added, addedBlob{commit: commit, author: author, path: path, mode: fields[1], oid: fields[3]})
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	headers, sizes, err := readBlobHeaders(ctx, repoPath, added)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, blob := range added {
+		if problem, severity := s.classifyBinary(blob, headers[blob.oid], sizes[blob.oid]); problem != "" {
+			issues = append(issues, Issue{
+				Type:        "binary",
+				Severity:    severity,
+				File:        blob.path,
+				Description: fmt.Sprintf("Added %s (%s)", problem, formatSize(sizes[blob.oid])),
+				Rule:        "Binary Introduction",
+				Timestamp:   time.Now(),
+				Commit:      blob.commit,
+				Author:      blob.author,
+			})
+		}
+	}
+	return issues, nil
+}
+
+// returns what is suspicious about an added file and how severe it is, or
+// nothing for ordinary text files and small binaries
+func (s *Scanner) classifyBinary(blob addedBlob, header []byte, size int64) (string, string) {
+	cfg := s.config.Binaries
+
+	binary := isBinary(header)
+	for _, exe := range executableMagic {
+		if binary && bytes.HasPrefix(header, exe.magic) {
+			return exe.format + " executable", "high"
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(blob.path))
+	for _, listed := range cfg.Extensions {
+		if strings.EqualFold(ext, listed) {
+			return ext + " file", "medium"
+		}
+	}
+
+	if !binary {
+		return "", ""
+	}
+	if blob.mode == "100755" {
+		return "executable binary", "high"
+	}
+	if cfg.MaxSize > 0 && size > cfg.MaxSize {
+		return "large binary", "medium"
+	}
+	return "", ""
+}
+
+func (s *Scanner) binaryAllowed(path string) bool {
+	for _, pattern := range s.config.Binaries.Allow {
+		if matchGlob(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// reads the size and first 512 bytes of each blob through one
+// git cat-file --batch process
+func readBlobHeaders(ctx context.Context, repoPath string, blobs []addedBlob) (map[string][]byte, map[string]int64, error) {
+	var input strings.Builder
+	for _, blob := range blobs {
+		input.WriteString(blob.oid + "\n")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(input.String())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read added files: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read added files: %w", err)
+	}
+
+	headers := make(map[string][]byte)
+	sizes := make(map[string]int64)
+	r := bufio.NewReader(stdout)
+	var readErr error
+	for range blobs {
+		// <oid> <type> <size>, then the content and a newline
+		line, err := r.ReadString('\n')
+		if err != nil {
+			readErr = err
+			break
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue // "<oid> missing"
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			readErr = fmt.Errorf("unexpected output %q", strings.TrimSpace(line))
+			break
+		}
+
+		header := make([]byte, min(size, 512))
+		if _, err := io.ReadFull(r, header); err != nil {
+			readErr = err
+			break
+		}
+		if _, err := io.CopyN(io.Discard, r, size-int64(len(header))+1); err != nil {
+			readErr = err
+			break
+		}
+		headers[fields[0]] = header
+		sizes[fields[0]] = size
+	}
+
+	// git could still be writing to a pipe no one reads, so it is stopped
+	// rather than waited on
+	if readErr != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return nil, nil, fmt.Errorf("failed to read added files: %w", readErr)
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return nil, nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+	return headers, sizes, nil
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
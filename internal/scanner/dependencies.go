@@ -1,14 +1,13 @@
 package scanner
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +16,7 @@ type Dependency struct {
 	Version   string `json:"version"`
 	Ecosystem string `json:"ecosystem"`
 	File      string `json:"file"`
+	Line      int    `json:"line,omitempty"` // where the file names it, when known
 }
 
 type Vulnerability struct {
@@ -30,6 +30,9 @@ type Vulnerability struct {
 	Modified   string   `json:"modified"`
 	Aliases    []string `json:"aliases"`
 	Affected   []string `json:"affected"`
+
+	// the dependency this vulnerability was reported for
+	Dependency Dependency `json:"dependency"`
 }
 
 // represents the response from OSV API
@@ -48,11 +51,18 @@ type OSVVulnerability struct {
 	Affected   []OSVAffected  `json:"affected"`
 	Severity   []OSVSeverity  `json:"severity"`
 	References []OSVReference `json:"references"`
+
+	// the severity the publishing database gives, as GitHub and internal
+	// feeds do when there is no CVSS vector
+	DatabaseSpecific struct {
+		Severity string `json:"severity,omitempty"`
+	} `json:"database_specific"`
 }
 
 type OSVAffected struct {
-	Package OSVPackage `json:"package"`
-	Ranges  []OSVRange `json:"ranges"`
+	Package  OSVPackage `json:"package"`
+	Ranges   []OSVRange `json:"ranges"`
+	Versions []string   `json:"versions"`
 }
 
 type OSVPackage struct {
@@ -66,8 +76,9 @@ type OSVRange struct {
 }
 
 type OSVEvent struct {
-	Introduced string `json:"introduced,omitempty"`
-	Fixed      string `json:"fixed,omitempty"`
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"` // the last version still affected, when no fix exists
 }
 
 type OSVSeverity struct {
@@ -80,42 +91,140 @@ type OSVReference struct {
 	URL  string `json:"url"`
 }
 
-// scans a dependency file for vulnerabilities
-func (s *Scanner) scanDependencies(filePath, content string) ([]Issue, error) {
+// parses all dependency manifests concurrently into a shared inventory,
+// then checks the whole inventory for vulnerabilities in one pass
+func (s *Scanner) scanDependencyFiles(ctx context.Context, files []string, read ReadFunc) BatchResult {
+	parsed := make(chan []Dependency, len(files))
+	var wg sync.WaitGroup
+
+	// parsing takes the slots of the file scan running alongside, so the
+	// two together stay within max_concurrency
+	for _, file := range files {
+		wg.Add(1)
+		go func(f string) {
+			defer wg.Done()
+			s.slots <- struct{}{}
+			defer func() { <-s.slots }()
+
+			if ctx.Err() != nil {
+				return
+			}
+			content, ok := read(f)
+			if !ok {
+				return
+			}
+
+			deps, err := s.parseDependencies(f, content)
+			if err != nil {
+				s.logger.Warn("failed to parse dependencies", "file", f, "error", err)
+				return
+			}
+
+			parsed <- deps
+		}(file)
+	}
+
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	var result BatchResult
+	for deps := range parsed {
+		result.Dependencies = append(result.Dependencies, deps...)
+	}
+
+	result.Issues = s.scanDependencies(ctx, result.Dependencies)
+	return result
+}
+
+// checks the dependency inventory for vulnerabilities, querying each
+// distinct package version once and reporting it in every manifest
+func (s *Scanner) scanDependencies(ctx context.Context, deps []Dependency) []Issue {
 	var issues []Issue
 
-	// parse dependencies based on file type
-	deps, err := s.parseDependencies(filePath, content)
-	if err != nil {
-		return issues, fmt.Errorf("failed to parse dependencies: %w", err)
+	if len(deps) == 0 || (len(s.vulnSources) == 0 && len(s.private) == 0 && len(s.feeds) == 0) || ctx.Err() != nil {
+		return issues
 	}
 
-	if len(deps) == 0 {
-		return issues, nil
+	seen := make(map[string]bool)
+	var unique []Dependency
+	for _, dep := range deps {
+		key := dependencyKey(dep)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, dep)
+		}
 	}
 
-	// check vulnerabilities with OSV API
-	if s.config.DependencyAPIs.OSVEnabled {
-		vulns, err := s.checkOSVVulnerabilities(deps)
-		if err != nil && s.config.Verbose {
-			fmt.Printf("Warning: OSV API check failed: %v\n", err)
-		} else {
-			issues = append(issues, s.convertVulnsToIssues(vulns, filePath)...)
+	// private packages are not sent to the public sources, which would
+	// answer with errors or advisories for a public package of that name
+	public, private := s.routeDependencies(unique)
+	var vulns []Vulnerability
+	if len(public) > 0 && len(s.vulnSources) > 0 {
+		found, err := queryVulnSources(ctx, s.vulnSources, public)
+		if err != nil {
+			s.logger.Warn("vulnerability lookup incomplete", "error", err)
 		}
+		vulns = append(vulns, found...)
 	}
+	if len(private) > 0 {
+		found, err := s.queryPrivate(ctx, private)
+		if err != nil {
+			s.logger.Warn("private vulnerability lookup incomplete", "error", err)
+		}
+		vulns = append(vulns, found...)
+	}
+	// internal feeds mostly cover private packages, so they see them all;
+	// an advisory a public source has too is reported once
+	if len(s.feeds) > 0 {
+		found, err := queryVulnSources(ctx, s.feeds, unique)
+		if err != nil {
+			s.logger.Warn("advisory feed lookup incomplete", "error", err)
+		}
+		vulns = mergeVulnerabilities(append(vulns, found...))
+	}
+
+	byDependency := make(map[string][]Vulnerability)
+	for _, vuln := range vulns {
+		key := dependencyKey(vuln.Dependency)
+		byDependency[key] = append(byDependency[key], vuln)
+	}
+
+	for _, dep := range deps {
+		issues = append(issues, s.convertVulnsToIssues(byDependency[dependencyKey(dep)], dep)...)
+	}
+
+	return issues
+}
 
-	return issues, nil
+// identifies a package version independent of the manifest it came from
+func dependencyKey(dep Dependency) string {
+	return dep.Ecosystem + "|" + dep.Name + "|" + dep.Version
 }
 
 // parses dependencies from multiple file formats
 func (s *Scanner) parseDependencies(filePath, content string) ([]Dependency, error) {
 	filename := strings.ToLower(filepath.Base(filePath))
 
+	// lockfiles add nothing but transitive dependencies
+	if s.config.DependencyAPIs.DirectOnly && isLockfile(filename) {
+		return []Dependency{}, nil
+	}
+
 	switch {
 	case filename == "package.json":
 		return s.parsePackageJSON(content, filePath)
+	case filename == "package-lock.json" || filename == "npm-shrinkwrap.json":
+		return s.parsePackageLock(content, filePath)
+	case filename == "yarn.lock":
+		return s.parseYarnLock(content, filePath)
+	case filename == "pnpm-lock.yaml":
+		return s.parsePnpmLock(content, filePath)
 	case filename == "go.mod":
 		return s.parseGoMod(content, filePath)
+	case filename == "go.sum":
+		return s.parseGoSum(content, filePath)
 	case filename == "requirements.txt":
 		return s.parseRequirementsTxt(content, filePath)
 	case filename == "gemfile":
@@ -126,6 +235,12 @@ func (s *Scanner) parseDependencies(filePath, content string) ([]Dependency, err
 		return s.parsePomXML(content, filePath)
 	case filename == "cargo.toml":
 		return s.parseCargoToml(content, filePath)
+	case filename == "packages.config":
+		return s.parsePackagesConfig(content, filePath)
+	case filename == "packages.lock.json":
+		return s.parseNuGetLock(content, filePath)
+	case isProjectFile(filename) || filename == "directory.packages.props":
+		return s.parseProjectFile(content, filePath)
 	default:
 		return []Dependency{}, nil
 	}
@@ -166,6 +281,152 @@ func (s *Scanner) parsePackageJSON(content, filePath string) ([]Dependency, erro
 	return deps, nil
 }
 
+// parses npm package-lock.json; v2/v3 lockfiles list every installed
+// package under "packages", v1 nests them under "dependencies"
+func (s *Scanner) parsePackageLock(content, filePath string) ([]Dependency, error) {
+	type v1Dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages map[string]struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			Link    bool   `json:"link"`
+		} `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var deps []Dependency
+	add := func(name, version string) {
+		if name == "" || version == "" || seen[name+"@"+version] {
+			return
+		}
+		seen[name+"@"+version] = true
+		deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm", File: filePath})
+	}
+
+	if len(lock.Packages) > 0 {
+		for path, pkg := range lock.Packages {
+			// "" is the project itself; links point at workspace folders
+			if path == "" || pkg.Link {
+				continue
+			}
+			name := pkg.Name
+			if i := strings.LastIndex(path, "node_modules/"); i >= 0 && name == "" {
+				name = path[i+len("node_modules/"):]
+			}
+			add(name, pkg.Version)
+		}
+		return deps, nil
+	}
+
+	var walk func(map[string]json.RawMessage)
+	walk = func(nested map[string]json.RawMessage) {
+		for name, raw := range nested {
+			var dep v1Dependency
+			if err := json.Unmarshal(raw, &dep); err != nil {
+				continue
+			}
+			add(name, dep.Version)
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+
+	return deps, nil
+}
+
+// parses yarn.lock, both the classic format and the YAML one of yarn 2+
+func (s *Scanner) parseYarnLock(content, filePath string) ([]Dependency, error) {
+	seen := make(map[string]bool)
+	var deps []Dependency
+	name := ""
+
+	for _, line := range splitLines(content) {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// an unindented entry header lists the specs it resolves, e.g.
+		// "@babel/core@^7.0.0", "@babel/core@^7.1.0":
+		if !strings.HasPrefix(line, " ") {
+			spec := strings.TrimSuffix(line, ":")
+			spec, _, _ = strings.Cut(spec, ",")
+			spec = strings.Trim(strings.TrimSpace(spec), `"`)
+			name = ""
+			if at := strings.LastIndex(spec, "@"); at > 0 {
+				name = spec[:at]
+			}
+			continue
+		}
+
+		field := strings.TrimSpace(line)
+		if name == "" || !strings.HasPrefix(field, "version") {
+			continue
+		}
+		version := strings.TrimPrefix(field, "version")
+		version = strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(version), ":")), `"'`)
+
+		if version != "" && !seen[name+"@"+version] {
+			seen[name+"@"+version] = true
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm", File: filePath})
+		}
+		name = ""
+	}
+
+	return deps, nil
+}
+
+// parses the keys of the "packages" section of pnpm-lock.yaml, which are
+// "/name/1.0.0" in lockfile v5, "/name@1.0.0" in v6 and "name@1.0.0" in v9,
+// optionally followed by peer dependency suffixes
+func (s *Scanner) parsePnpmLock(content, filePath string) ([]Dependency, error) {
+	seen := make(map[string]bool)
+	var deps []Dependency
+	inPackages := false
+
+	for _, line := range splitLines(content) {
+		if line == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			inPackages = line == "packages:"
+			continue
+		}
+		// package keys are indented by exactly two spaces
+		if !inPackages || strings.HasPrefix(line, "   ") || !strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		key := strings.Trim(strings.TrimSuffix(strings.TrimSpace(line), ":"), `"'`)
+		key = strings.TrimPrefix(key, "/")
+		if i := strings.Index(key, "("); i > 0 {
+			key = key[:i]
+		}
+
+		var name, version string
+		if at := strings.LastIndex(key, "@"); at > 0 {
+			name, version = key[:at], key[at+1:]
+		} else if slash := strings.LastIndex(key, "/"); slash > 0 {
+			name, version = key[:slash], key[slash+1:]
+			version, _, _ = strings.Cut(version, "_")
+		}
+
+		if name != "" && version != "" && !seen[name+"@"+version] {
+			seen[name+"@"+version] = true
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm", File: filePath})
+		}
+	}
+
+	return deps, nil
+}
+
 // parses module file
 func (s *Scanner) parseGoMod(content, filePath string) ([]Dependency, error) {
 	var deps []Dependency
@@ -193,6 +454,10 @@ func (s *Scanner) parseGoMod(content, filePath string) ([]Dependency, error) {
 				line = strings.TrimPrefix(line, "require ")
 			}
 
+			if s.config.DependencyAPIs.DirectOnly && strings.Contains(line, "// indirect") {
+				continue
+			}
+
 			matches := requirePattern.FindStringSubmatch(line)
 			if len(matches) == 3 {
 				deps = append(deps, Dependency{
@@ -208,6 +473,52 @@ func (s *Scanner) parseGoMod(content, filePath string) ([]Dependency, error) {
 	return deps, nil
 }
 
+// parses go.sum, which covers the whole module graph; a module can be
+// listed at several versions, and the build uses the highest one whose
+// source (not just go.mod) was downloaded
+func (s *Scanner) parseGoSum(content, filePath string) ([]Dependency, error) {
+	selected := make(map[string]string)
+	var order []string
+
+	for _, line := range splitLines(content) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+
+		module, version := fields[0], strings.TrimPrefix(fields[1], "v")
+		current, ok := selected[module]
+		if !ok {
+			order = append(order, module)
+		}
+		if !ok || compareVersions(version, current) > 0 {
+			selected[module] = version
+		}
+	}
+
+	deps := make([]Dependency, 0, len(order))
+	for _, module := range order {
+		deps = append(deps, Dependency{
+			Name:      module,
+			Version:   selected[module],
+			Ecosystem: "Go",
+			File:      filePath,
+		})
+	}
+	return deps, nil
+}
+
+// reports whether a dependency file is a lockfile rather than a manifest
+func isLockfile(filename string) bool {
+	switch filename {
+	case "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+		"go.sum", "pipfile.lock", "poetry.lock", "gemfile.lock", "composer.lock",
+		"gradle.lockfile", "cargo.lock", "packages.lock.json":
+		return true
+	}
+	return false
+}
+
 // parses python requirements.txt
 func (s *Scanner) parseRequirementsTxt(content, filePath string) ([]Dependency, error) {
 	var deps []Dependency
@@ -358,136 +669,31 @@ func (s *Scanner) parseCargoToml(content, filePath string) ([]Dependency, error)
 	return deps, nil
 }
 
-// checks dependencies with OSV database
-func (s *Scanner) checkOSVVulnerabilities(deps []Dependency) ([]Vulnerability, error) {
-	var vulnerabilities []Vulnerability
-
-	// group dependencies by ecosystem
-	ecosystemDeps := make(map[string][]Dependency)
-	for _, dep := range deps {
-		ecosystemDeps[dep.Ecosystem] = append(ecosystemDeps[dep.Ecosystem], dep)
-	}
-
-	client := &http.Client{Timeout: 30 * time.Second}
-
-	for ecosystem, depList := range ecosystemDeps {
-		// create request for OSV API
-		var packages []map[string]interface{}
-		for _, dep := range depList {
-			packages = append(packages, map[string]interface{}{
-				"package": map[string]string{
-					"ecosystem": mapToOSVEcosystem(ecosystem),
-					"name":      dep.Name,
-				},
-				"version": dep.Version,
-			})
-		}
-
-		requestBody := map[string]interface{}{
-			"queries": packages,
-		}
-
-		jsonData, err := json.Marshal(requestBody)
-		if err != nil {
-			continue
-		}
-
-		resp, err := client.Post("https://api.osv.dev/v1/querybatch", "application/json", bytes.NewBuffer(jsonData))
-		if err != nil {
-			return vulnerabilities, fmt.Errorf("OSV API request failed: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return vulnerabilities, fmt.Errorf("OSV API returned status %d", resp.StatusCode)
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return vulnerabilities, fmt.Errorf("failed to read OSV response: %w", err)
-		}
-
-		var response struct {
-			Results []struct {
-				Vulns []OSVVulnerability `json:"vulns"`
-			} `json:"results"`
-		}
-
-		if err := json.Unmarshal(body, &response); err != nil {
-			return vulnerabilities, fmt.Errorf("failed to parse OSV response: %w", err)
-		}
-
-		// convert OSV vulnerabilities to the project format
-		for i, result := range response.Results {
-			if i < len(depList) {
-				dep := depList[i]
-				for _, vuln := range result.Vulns {
-					vulnerabilities = append(vulnerabilities, s.convertOSVVuln(vuln, dep))
-				}
-			}
-		}
-	}
-
-	return vulnerabilities, nil
-}
-
-// converts OSV vulnerability to project format
-func (s *Scanner) convertOSVVuln(osv OSVVulnerability, dep Dependency) Vulnerability {
-	vuln := Vulnerability{
-		ID:        osv.ID,
-		Summary:   osv.Summary,
-		Details:   osv.Details,
-		Published: osv.Published,
-		Modified:  osv.Modified,
-		Aliases:   osv.Aliases,
-		Severity:  "medium",
-	}
-
-	// extract CVSS score
-	for _, severity := range osv.Severity {
-		if severity.Type == "CVSS_V3" {
-			// Parse CVSS score (simplified)
-			if strings.Contains(severity.Score, "CVSS:3.1/AV:") {
-				vuln.Severity = s.extractCVSSSeverity(severity.Score)
-			}
-		}
-	}
-
-	// extract references
-	for _, ref := range osv.References {
-		vuln.References = append(vuln.References, ref.URL)
-	}
-
-	return vuln
-}
-
-// extracts severity from CVSS score
-func (s *Scanner) extractCVSSSeverity(cvssString string) string {
-	if strings.Contains(cvssString, "/AV:N/") && strings.Contains(cvssString, "/AC:L/") {
-		return "high"
-	}
-	if strings.Contains(cvssString, "/PR:N/") {
-		return "critical"
-	}
-	return "medium"
-}
-
 // converts vulnerabilities to issues
-func (s *Scanner) convertVulnsToIssues(vulns []Vulnerability, filePath string) []Issue {
+func (s *Scanner) convertVulnsToIssues(vulns []Vulnerability, dep Dependency) []Issue {
 	var issues []Issue
 
+	line := dep.Line
+	if line < 1 {
+		line = 1
+	}
+
+	exploits := s.exploitData()
 	for _, vuln := range vulns {
-		issues = append(issues, Issue{
+		issue := Issue{
 			Type:        "vulnerability",
 			Severity:    vuln.Severity,
-			File:        filePath,
-			Line:        1,
+			File:        dep.File,
+			Line:        line,
 			Column:      1,
 			Description: fmt.Sprintf("Vulnerability %s: %s", vuln.ID, vuln.Summary),
 			Content:     vuln.Details,
 			Rule:        "Dependency Vulnerability Check",
 			Timestamp:   time.Now(),
-		})
+			AdvisoryID:  vuln.ID,
+		}
+		exploits.annotate(&issue, vuln)
+		issues = append(issues, issue)
 	}
 
 	return issues
@@ -514,6 +720,7 @@ func mapToOSVEcosystem(ecosystem string) string {
 		"Maven":     "Maven",
 		"Packagist": "Packagist",
 		"crates.io": "crates.io",
+		"NuGet":     "NuGet",
 	}
 
 	if mapped, exists := mapping[ecosystem]; exists {
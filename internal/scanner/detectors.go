@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// inspects the content of one file at a time
+type Detector interface {
+	Name() string
+	// the scan type that selects this detector; ScanTypeAll detectors only
+	// run when everything is scanned
+	Type() ScanType
+	// root is the directory of a path scan, which whitelist paths are
+	// relative to, or "" when files are not scanned from one
+	Detect(root, filePath, content string) []Issue
+}
+
+// returns the content of a file to scan, or false if it should be skipped
+type ReadFunc func(filePath string) (string, bool)
+
+// inspects every file it wants in a single pass, for checks that need the
+// whole scan at once such as batched dependency lookups; DetectFiles
+// returns what it has found once ctx is done
+type BatchDetector interface {
+	Name() string
+	Type() ScanType
+	Wants(filePath string) bool
+	DetectFiles(ctx context.Context, files []string, read ReadFunc) BatchResult
+}
+
+// outcome of a BatchDetector run
+type BatchResult struct {
+	Issues       []Issue
+	Dependencies []Dependency
+}
+
+// timing and volume metrics for a detector over one scan
+type DetectorStats struct {
+	Name     string `json:"name"`
+	Files    int    `json:"files"`
+	Issues   int    `json:"issues"`
+	Duration string `json:"duration"`
+}
+
+// accumulates DetectorStats across concurrent file scans
+type detectorMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*detectorTiming
+}
+
+type detectorTiming struct {
+	files    int
+	issues   int
+	duration time.Duration
+}
+
+func newDetectorMetrics() *detectorMetrics {
+	return &detectorMetrics{stats: make(map[string]*detectorTiming)}
+}
+
+func (m *detectorMetrics) record(name string, files, issues int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.stats[name]
+	if !ok {
+		t = &detectorTiming{}
+		m.stats[name] = t
+	}
+	t.files += files
+	t.issues += issues
+	t.duration += elapsed
+}
+
+func (m *detectorMetrics) list() []DetectorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []DetectorStats
+	for name, t := range m.stats {
+		list = append(list, DetectorStats{
+			Name:     name,
+			Files:    t.files,
+			Issues:   t.issues,
+			Duration: t.duration.String(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// registers a per-file detector
+func (s *Scanner) RegisterDetector(d Detector) {
+	s.detectors = append(s.detectors, d)
+}
+
+// registers a whole-scan detector
+func (s *Scanner) RegisterBatchDetector(d BatchDetector) {
+	s.batchDetectors = append(s.batchDetectors, d)
+}
+
+// reports whether a detector should run for the given scan type
+func (s *Scanner) detectorEnabled(name string, detectorType, scanType ScanType) bool {
+	for _, disabled := range s.config.DisabledDetectors {
+		if disabled == name {
+			return false
+		}
+	}
+	return scanType == ScanTypeAll || scanType == detectorType
+}
+
+// finds secrets matching the configured patterns
+type secretDetector struct {
+	s *Scanner
+}
+
+func (d secretDetector) Name() string   { return "secrets" }
+func (d secretDetector) Type() ScanType { return ScanTypeSecrets }
+
+func (d secretDetector) Detect(root, filePath, content string) []Issue {
+	return d.s.scanSecrets(root, filePath, content)
+}
+
+// finds suspicious keywords
+type socialDetector struct {
+	s *Scanner
+}
+
+func (d socialDetector) Name() string   { return "social" }
+func (d socialDetector) Type() ScanType { return ScanTypeSocial }
+
+func (d socialDetector) Detect(root, filePath, content string) []Issue {
+	if !d.s.config.SocialEngineering.Enabled {
+		return nil
+	}
+	return d.s.scanSocialEngineering(filePath, content)
+}
+
+// checks dependency manifests against the vulnerability sources
+type dependencyDetector struct {
+	s *Scanner
+}
+
+func (d dependencyDetector) Name() string   { return "dependencies" }
+func (d dependencyDetector) Type() ScanType { return ScanTypeDependencies }
+
+func (d dependencyDetector) Wants(filePath string) bool {
+	return isDependencyFile(filePath)
+}
+
+func (d dependencyDetector) DetectFiles(ctx context.Context, files []string, read ReadFunc) BatchResult {
+	return d.s.scanDependencyFiles(ctx, files, read)
+}
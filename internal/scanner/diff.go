@@ -0,0 +1,220 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scans the lines a unified diff adds, as git diff, hg diff, svn diff or
+// diff -u print it, so changes can be checked without any version control
+// at hand; once ctx is done the files read so far are reported, marked
+// Incomplete
+func (s *Scanner) ScanDiff(ctx context.Context, r io.Reader, scanType ScanType) (*Results, error) {
+	startTime := time.Now()
+
+	results := &Results{
+		ScanTime: startTime,
+		Issues:   make([]Issue, 0),
+	}
+
+	var detectors []Detector
+	for _, d := range s.detectors {
+		if s.detectorEnabled(d.Name(), d.Type(), scanType) {
+			detectors = append(detectors, d)
+		}
+	}
+	metrics := newDetectorMetrics()
+	s.whitelist = newWhitelistCounter()
+	s.profile = nil
+	if s.profiling {
+		s.profile = newProfiler()
+	}
+
+	err := parseUnifiedDiff(r, int(s.config.MaxFileSize), func(added addedLines) {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.paths.allowFile(added.file) || !(shouldScanFile(added.file) || isDependencyFile(added.file)) {
+			return
+		}
+
+		results.FilesScanned++
+		results.addIssues(s.scanAddedLines(added, detectors, metrics)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results.Incomplete = ctx.Err() != nil
+	s.maskContents(results.Issues)
+	s.maskContents(results.Suppressed)
+	markSecretReuse(results.Issues)
+	s.dedupe(results, "")
+	results.Detectors = metrics.list()
+	results.Whitelist = s.whitelist.list()
+	results.Profile = s.profile.profile()
+	results.Summary = calculateSummary(results.Issues)
+	results.Duration = time.Since(startTime).String()
+
+	s.logger.Debug("diff scan finished", "files", results.FilesScanned, "issues", len(results.Issues), "duration", results.Duration)
+	s.logWhitelistStats(results.Whitelist)
+
+	return results, nil
+}
+
+// the new-file line numbers a diff adds or modifies, by file
+type changedLines map[string]map[int]bool
+
+func readChangedLines(r io.Reader, maxLine int) (changedLines, error) {
+	changed := make(changedLines)
+	err := parseUnifiedDiff(r, maxLine, func(added addedLines) {
+		lines := changed[added.file]
+		if lines == nil {
+			lines = make(map[int]bool)
+			changed[added.file] = lines
+		}
+		for _, n := range added.numbers {
+			lines[n] = true
+		}
+	})
+	return changed, err
+}
+
+// drops the issues on lines the diff did not change, counting them in
+// Unchanged, and the suppressed ones there too, so ignore comments the
+// diff did not touch are not audited again; scanning whole files and
+// filtering keeps the line numbers of the file and the context of
+// multi-line secrets. Issues about a whole file, with no line, are kept.
+func (c changedLines) filter(results *Results) {
+	var unchanged int
+	results.Issues, unchanged = c.keep(results.Issues)
+	results.Unchanged += unchanged
+	if len(results.Suppressed) > 0 {
+		results.Suppressed, _ = c.keep(results.Suppressed)
+	}
+}
+
+// splits issues into those on changed lines or about a whole file, and
+// the count of the rest
+func (c changedLines) keep(issues []Issue) ([]Issue, int) {
+	kept := make([]Issue, 0, len(issues))
+	dropped := 0
+	for _, issue := range issues {
+		if issue.Line == 0 || c[filepath.ToSlash(issue.File)][issue.Line] {
+			kept = append(kept, issue)
+		} else {
+			dropped++
+		}
+	}
+	return kept, dropped
+}
+
+// parses a unified diff, calling fn with the added lines of every file.
+// Unlike parseGitLog it does not rely on git's headers: a file starts at
+// its "+++ " line and each hunk ends after the line counts of its "@@"
+// header, so removed lines that look like headers are not mistaken for
+// them.
+func parseUnifiedDiff(r io.Reader, maxLine int, fn func(addedLines)) error {
+	sc := bufio.NewScanner(r)
+	if maxLine < 1024*1024 {
+		maxLine = 1024 * 1024
+	}
+	sc.Buffer(make([]byte, 64*1024), maxLine)
+
+	var current *addedLines
+	next := 0
+	// lines of the current hunk still to come, from either side
+	oldLeft, newLeft := 0, 0
+
+	flush := func() {
+		if current != nil && len(current.lines) > 0 {
+			fn(*current)
+		}
+		current = nil
+	}
+
+	for sc.Scan() {
+		line := sc.Text()
+
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if current != nil {
+					current.lines = append(current.lines, strings.TrimSuffix(line[1:], "\r"))
+					current.numbers = append(current.numbers, next)
+				}
+				next++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				next++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			flush()
+			if path := diffPath(strings.TrimPrefix(line, "+++ ")); path != "" {
+				current = &addedLines{file: path}
+			}
+
+		case strings.HasPrefix(line, "@@ "):
+			next = hunkStart(line)
+			oldLeft, newLeft = hunkCounts(line)
+		}
+	}
+	flush()
+
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("failed to read diff: %w", err)
+	}
+	return nil
+}
+
+// returns the path of a "+++ " header: git and hg prefix it with b/, svn
+// and diff -u follow it with a tab and a revision or timestamp. A deleted
+// file's /dev/null has none.
+func diffPath(header string) string {
+	path, _, _ := strings.Cut(header, "\t")
+	path = strings.TrimRight(path, " ")
+	if path == "/dev/null" {
+		return ""
+	}
+	path = unquoteGitPath(path)
+	return strings.TrimPrefix(strings.TrimPrefix(path, "b/"), "./")
+}
+
+// returns the old and new line counts of a "@@ -a,b +c,d @@" header; a
+// missing count is 1
+func hunkCounts(header string) (int, int) {
+	oldCount, newCount := 1, 1
+	for _, f := range strings.Fields(header)[1:] {
+		if f == "@@" {
+			break
+		}
+		_, count, ok := strings.Cut(f[1:], ",")
+		n := 1
+		if ok {
+			n, _ = strconv.Atoi(count)
+		}
+		switch f[0] {
+		case '-':
+			oldCount = n
+		case '+':
+			newCount = n
+		}
+	}
+	return oldCount, newCount
+}
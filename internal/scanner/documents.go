@@ -0,0 +1,471 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formats whose text is extracted with "documents" enabled, by extension
+var documentFormats = map[string]func(data []byte, limit int) string{
+	".pdf":  pdfText,
+	".docx": docxText,
+	".xlsx": xlsxText,
+	".pptx": pptxText,
+}
+
+// the default limit on the text extracted from one document
+const defaultDocumentTextSize = 1 << 20
+
+// the line separating a document's metadata from its text, where the
+// metadata detector stops
+const documentTextMarker = "\f"
+
+// collects extracted text up to a limit, after which writes are dropped
+type textBuffer struct {
+	strings.Builder
+	limit int
+}
+
+func (b *textBuffer) full() bool {
+	return b.Len() >= b.limit
+}
+
+func (b *textBuffer) add(text string) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(text) > room {
+			text = text[:room]
+		}
+		b.WriteString(text)
+	}
+}
+
+// ends the current line, unless it is empty
+func (b *textBuffer) newline() {
+	s := b.String()
+	if len(s) > 0 && s[len(s)-1] != '\n' {
+		b.add("\n")
+	}
+}
+
+// reads the zip parts of an Office Open XML file that match, each up to
+// limit bytes
+func zipParts(data []byte, limit int, match func(name string) bool) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		if !match(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		part, err := io.ReadAll(io.LimitReader(rc, int64(limit)))
+		rc.Close()
+		if err == nil {
+			parts[f.Name] = part
+		}
+	}
+	return parts
+}
+
+// returns the names of parts sorted by the number in them, e.g.
+// sheet1.xml, sheet2.xml, sheet10.xml
+func sortedParts(parts map[string][]byte) []string {
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ni, nj := partNumber(names[i]), partNumber(names[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+var partDigits = regexp.MustCompile(`\d+`)
+
+func partNumber(name string) int {
+	n, _ := strconv.Atoi(partDigits.FindString(path.Base(name)))
+	return n
+}
+
+// the paragraphs of a Word document, its headers and footers, footnotes
+// and comments, one per line
+func docxText(data []byte, limit int) string {
+	parts := zipParts(data, limit, func(name string) bool {
+		if !strings.HasPrefix(name, "word/") || !strings.HasSuffix(name, ".xml") {
+			return false
+		}
+		base := path.Base(name)
+		return base == "document.xml" || base == "footnotes.xml" || base == "endnotes.xml" || base == "comments.xml" ||
+			strings.HasPrefix(base, "header") || strings.HasPrefix(base, "footer")
+	})
+
+	b := &textBuffer{limit: limit}
+	// the body first, then the rest
+	names := sortedParts(parts)
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == "word/document.xml" && names[j] != names[i] })
+	for _, name := range names {
+		wordprocessingText(b, parts[name], "t", "p")
+		b.newline()
+	}
+	return b.String()
+}
+
+// the text of every slide, one paragraph per line
+func pptxText(data []byte, limit int) string {
+	parts := zipParts(data, limit, func(name string) bool {
+		return strings.HasPrefix(name, "ppt/slides/slide") && strings.HasSuffix(name, ".xml") ||
+			strings.HasPrefix(name, "ppt/notesSlides/") && strings.HasSuffix(name, ".xml")
+	})
+
+	b := &textBuffer{limit: limit}
+	for _, name := range sortedParts(parts) {
+		wordprocessingText(b, parts[name], "t", "p")
+		b.newline()
+	}
+	return b.String()
+}
+
+// writes the text of the textElem elements of an Office XML part, ending
+// a line at each paragraph element and at breaks
+func wordprocessingText(b *textBuffer, data []byte, textElem, paragraphElem string) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inText := false
+	for !b.full() {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case textElem:
+				inText = true
+			case "tab":
+				b.add("\t")
+			case "br", "cr":
+				b.add("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case textElem:
+				inText = false
+			case paragraphElem:
+				b.newline()
+			}
+		case xml.CharData:
+			if inText {
+				b.add(string(t))
+			}
+		}
+	}
+}
+
+// the cells of every worksheet, a row per line with cells separated by
+// tabs, shared strings resolved
+func xlsxText(data []byte, limit int) string {
+	parts := zipParts(data, limit, func(name string) bool {
+		return name == "xl/sharedStrings.xml" ||
+			strings.HasPrefix(name, "xl/worksheets/sheet") && strings.HasSuffix(name, ".xml")
+	})
+
+	var shared []string
+	if sst, ok := parts["xl/sharedStrings.xml"]; ok {
+		shared = sharedStrings(sst)
+		delete(parts, "xl/sharedStrings.xml")
+	}
+
+	b := &textBuffer{limit: limit}
+	for _, name := range sortedParts(parts) {
+		sheetText(b, parts[name], shared)
+		b.newline()
+	}
+	return b.String()
+}
+
+// the strings of a workbook's shared string table, in order; rich text
+// items join their runs
+func sharedStrings(data []byte) []string {
+	var strs []string
+	var current strings.Builder
+	inItem, inText := false, false
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return strs
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = true
+				current.Reset()
+			case "t":
+				inText = inItem
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = false
+				strs = append(strs, current.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+// writes the rows of one worksheet
+func sheetText(b *textBuffer, data []byte, shared []string) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var cellType string
+	var value strings.Builder
+	inValue, firstCell := false, true
+	for !b.full() {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				firstCell = true
+			case "c":
+				cellType = ""
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "t" {
+						cellType = attr.Value
+					}
+				}
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				text := value.String()
+				if cellType == "s" {
+					text = ""
+					if i, err := strconv.Atoi(strings.TrimSpace(value.String())); err == nil && i >= 0 && i < len(shared) {
+						text = shared[i]
+					}
+				}
+				if text == "" {
+					continue
+				}
+				if !firstCell {
+					b.add("\t")
+				}
+				b.add(text)
+				firstCell = false
+			case "row":
+				b.newline()
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// the start of every stream object's data, and its dictionary before it
+var pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+
+// the strings shown by the text operators of a PDF's content streams:
+// Tj, TJ, ' and ", with a line ended wherever the text moves to a new
+// line. Fonts with their own encodings, as CID fonts have, come out
+// garbled; most generated runbooks use standard ones.
+func pdfText(data []byte, limit int) string {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return ""
+	}
+
+	b := &textBuffer{limit: limit}
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if b.full() {
+			break
+		}
+		if bytes.HasSuffix(data[:loc[0]], []byte("end")) {
+			continue
+		}
+		// the stream's dictionary, from its object header
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte(" obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := data[dictStart:loc[0]]
+		end := bytes.Index(data[loc[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		stream := data[loc[1] : loc[1]+end]
+
+		// images, fonts and embedded files hold no page text
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/FontFile")) ||
+			bytes.Contains(dict, []byte("/Length1")) || bytes.Contains(dict, []byte("/EmbeddedFile")) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DCTDecode")) {
+				continue
+			}
+			stream = inflate(stream)
+		}
+		if !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		pdfContentText(b, stream)
+		b.newline()
+	}
+	return b.String()
+}
+
+// interprets the text operators of one content stream
+func pdfContentText(b *textBuffer, content []byte) {
+	var operands [][]byte
+	var lastNumbers []float64
+	inArray := false
+	var array [][]byte
+
+	for i := 0; i < len(content) && !b.full(); {
+		c := content[i]
+		switch {
+		case c == '(':
+			end := pdfLiteralEnd(content, i)
+			token := content[i:end]
+			if inArray {
+				array = append(array, token)
+			} else {
+				operands = append(operands, token)
+			}
+			i = end
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			token := content[i : i+end+1]
+			if inArray {
+				array = append(array, token)
+			} else {
+				operands = append(operands, token)
+			}
+			i += end + 1
+		case c == '<':
+			// a dictionary, as marked content operators take
+			i += 2
+		case c == '[':
+			inArray = true
+			array = nil
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			// a comment runs to the end of the line
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFDelimiter(c):
+			i++
+		default:
+			start := i
+			for i < len(content) && !isPDFDelimiter(content[i]) && content[i] != '(' && content[i] != '<' &&
+				content[i] != '[' && content[i] != ']' && content[i] != '%' {
+				i++
+			}
+			word := string(content[start:i])
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				if inArray {
+					// a large negative adjustment in a TJ array is a space
+					if n <= -200 {
+						array = append(array, []byte("( )"))
+					}
+				} else {
+					lastNumbers = append(lastNumbers, n)
+				}
+				continue
+			}
+
+			switch word {
+			case "Tj":
+				for _, op := range operands {
+					b.add(pdfString(op))
+				}
+			case "'", "\"":
+				b.newline()
+				for _, op := range operands {
+					b.add(pdfString(op))
+				}
+			case "TJ":
+				for _, op := range array {
+					b.add(pdfString(op))
+				}
+				array = nil
+			case "T*", "ET":
+				b.newline()
+			case "Td", "TD":
+				if len(lastNumbers) >= 2 && lastNumbers[len(lastNumbers)-1] != 0 {
+					b.newline()
+				}
+			case "Tm":
+				b.newline()
+			}
+			operands = nil
+			lastNumbers = nil
+		}
+	}
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '/', '{', '}', '>':
+		return true
+	}
+	return false
+}
+
+// returns the index just past the literal string starting at start,
+// which may hold balanced and escaped parentheses
+func pdfLiteralEnd(content []byte, start int) int {
+	depth := 0
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(content)
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=_-"
+	hexChars    = "0123456789abcdefABCDEF"
+)
+
+// finds random-looking tokens that no pattern describes, such as generic
+// API keys, by their Shannon entropy
+type entropyDetector struct {
+	s *Scanner
+}
+
+func (d entropyDetector) Name() string   { return "entropy" }
+func (d entropyDetector) Type() ScanType { return ScanTypeSecrets }
+
+func (d entropyDetector) Detect(root, filePath, content string) []Issue {
+	cfg := d.s.config.Entropy
+	if !cfg.Enabled {
+		return nil
+	}
+	for _, pattern := range cfg.ExcludePaths {
+		if matchGlob(pattern, filePath) {
+			return nil
+		}
+	}
+
+	var issues []Issue
+	for lineNum, line := range splitLines(content) {
+		for _, tok := range entropyTokens(line, cfg.MinLength) {
+			if isDigest(tok.text) || isSequential(tok.text) {
+				continue
+			}
+			var threshold float64
+			var kind string
+			if isHexToken(tok.text) {
+				threshold, kind = cfg.HexThreshold, "hex"
+			} else {
+				threshold, kind = cfg.Base64Threshold, "base64"
+			}
+
+			if shannonEntropy(tok.text) < threshold || d.matchesPattern(tok.text) || d.s.isWhitelisted(root, filePath, tok.text) {
+				continue
+			}
+
+			issues = append(issues, Issue{
+				Type:        "secret",
+				Severity:    "medium",
+				File:        filePath,
+				Line:        lineNum + 1,
+				Column:      column(line, tok.offset),
+				Description: "High entropy " + kind + " string",
+				Content:     d.s.maskSecret(tok.text),
+				Rule:        "High Entropy String",
+				Timestamp:   time.Now(),
+				Category:    "generic",
+				SecretHash:  d.s.HashSecret(tok.text),
+			})
+		}
+	}
+
+	return issues
+}
+
+// reports whether a secret pattern already covers the token, so the same
+// secret is not reported twice
+func (d entropyDetector) matchesPattern(token string) bool {
+	for _, pattern := range d.s.config.SecretPatterns {
+		if re := pattern.GetCompiledPattern(); re != nil && re.MatchString(token) {
+			return true
+		}
+	}
+	return false
+}
+
+type entropyToken struct {
+	text   string
+	offset int
+}
+
+// splits a line into runs of base64 characters at least minLength long
+func entropyTokens(line string, minLength int) []entropyToken {
+	var tokens []entropyToken
+	start := -1
+	for i := 0; i <= len(line); i++ {
+		if i < len(line) && strings.IndexByte(base64Chars, line[i]) >= 0 {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLength {
+			tokens = append(tokens, entropyToken{text: line[start:i], offset: start})
+		}
+		start = -1
+	}
+	return tokens
+}
+
+func isHexToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(hexChars, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reports whether s looks like a SHA-1 or SHA-256 hex digest, such as a
+// commit id or a checksum, which are random but not secret
+func isDigest(s string) bool {
+	return (len(s) == 40 || len(s) == 64) && isHexToken(s)
+}
+
+// reports whether s is mostly runs of consecutive characters, as in the
+// alphabets of encoders, e.g. "ABCDEF...abcdef...0123456789"
+func isSequential(s string) bool {
+	steps := 0
+	for i := 1; i < len(s); i++ {
+		if s[i] == s[i-1]+1 {
+			steps++
+		}
+	}
+	return steps*2 >= len(s)-1
+}
+
+// returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
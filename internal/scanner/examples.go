@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// an example a secret rule gets wrong: a positive example it does not
+// report, or a negative example it does
+type ExampleFailure struct {
+	Rule     string `json:"rule"`
+	Example  string `json:"example"`
+	Positive bool   `json:"positive"`
+}
+
+// runs every rule over its positive and negative examples, the way a scan
+// would (keywords, secret group, entropy, allowlists and the whitelist
+// all apply), returning the examples it gets wrong and how many it checked
+func CheckExamples(cfg *config.Config) ([]ExampleFailure, int) {
+	var failures []ExampleFailure
+	checked := 0
+
+	for _, pattern := range cfg.SecretPatterns {
+		if len(pattern.PositiveExamples) == 0 && len(pattern.NegativeExamples) == 0 {
+			continue
+		}
+
+		// a scanner with only this rule, so other rules' matches do not count
+		single := *cfg
+		single.SecretPatterns = []config.SecretPattern{pattern}
+		single.Verify.Enabled = false
+		s := New(&single)
+
+		for _, example := range pattern.PositiveExamples {
+			checked++
+			if len(s.scanSecrets("", "example", example)) == 0 {
+				failures = append(failures, ExampleFailure{Rule: pattern.Name, Example: example, Positive: true})
+			}
+		}
+		for _, example := range pattern.NegativeExamples {
+			checked++
+			if len(s.scanSecrets("", "example", example)) > 0 {
+				failures = append(failures, ExampleFailure{Rule: pattern.Name, Example: example})
+			}
+		}
+	}
+	return failures, checked
+}
@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+)
+
+// what is known of the exploitation of CVEs: whether CISA's Known
+// Exploited Vulnerabilities catalog lists them, and their EPSS
+// probability of exploitation in the next 30 days
+type exploitData struct {
+	kev  map[string]bool
+	epss map[string]float64
+}
+
+// reads kev_file and epss_file; either may be unset
+func loadExploitData(cfg config.DependencyConfig) (*exploitData, error) {
+	data := &exploitData{kev: make(map[string]bool), epss: make(map[string]float64)}
+	if cfg.KEVFile != "" {
+		if err := data.readKEV(cfg.KEVFile); err != nil {
+			return data, fmt.Errorf("invalid kev_file: %w", err)
+		}
+	}
+	if cfg.EPSSFile != "" {
+		if err := data.readEPSS(cfg.EPSSFile); err != nil {
+			return data, fmt.Errorf("invalid epss_file: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// reads the KEV catalog in CISA's JSON format
+func (d *exploitData) readKEV(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var catalog struct {
+		Vulnerabilities []struct {
+			CVE string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return err
+	}
+	for _, v := range catalog.Vulnerabilities {
+		d.kev[strings.ToUpper(v.CVE)] = true
+	}
+	return nil
+}
+
+// reads EPSS scores in FIRST's CSV format, "cve,epss,percentile" after a
+// "#model_version" comment, gzipped or not
+func (d *exploitData) readEPSS(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) < 2 || !strings.HasPrefix(strings.ToUpper(record[0]), "CVE-") {
+			continue // the header
+		}
+		score, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return fmt.Errorf("score of %s: %w", record[0], err)
+		}
+		d.epss[strings.ToUpper(record[0])] = score
+	}
+}
+
+// sets what is known of the exploitation of the advisory, by its ID and
+// aliases, on its issue
+func (d *exploitData) annotate(issue *Issue, vuln Vulnerability) {
+	for _, id := range append([]string{vuln.ID}, vuln.Aliases...) {
+		id = strings.ToUpper(id)
+		if d.kev[id] {
+			issue.KnownExploited = true
+		}
+		if score, ok := d.epss[id]; ok && score > issue.EPSS {
+			issue.EPSS = score
+		}
+	}
+}
+
+// describes what is known of the exploitation of a vulnerability issue,
+// e.g. "known exploited (KEV), EPSS 0.94"
+func (i Issue) exploitation() string {
+	var parts []string
+	if i.KnownExploited {
+		parts = append(parts, i18n.T("known exploited (KEV)"))
+	}
+	if i.EPSS > 0 {
+		parts = append(parts, fmt.Sprintf("EPSS %.2f", i.EPSS))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// the exploitation data of the configuration, read on first use; a file
+// that cannot be read is logged and leaves the findings without it
+func (s *Scanner) exploitData() *exploitData {
+	s.exploitsOnce.Do(func() {
+		data, err := loadExploitData(s.config.DependencyAPIs)
+		if err != nil {
+			s.logger.Warn("exploitation data incomplete", "error", err)
+		}
+		s.exploits = data
+	})
+	return s.exploits
+}
@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// an advisory in the simple feed format, for teams that do not write OSV:
+//
+//	{"id": "ACME-2024-001", "ecosystem": "npm", "package": "@acme/auth",
+//	 "versions": ">= 2.0, < 2.3.1", "severity": "high", "summary": "..."}
+//
+// versions is a comma-separated list of constraints, all of which must
+// hold; empty means every version
+type feedAdvisory struct {
+	ID         string   `json:"id"`
+	Ecosystem  string   `json:"ecosystem"`
+	Package    string   `json:"package"`
+	Versions   string   `json:"versions"`
+	Severity   string   `json:"severity"`
+	Summary    string   `json:"summary"`
+	Details    string   `json:"details"`
+	Aliases    []string `json:"aliases"`
+	References []string `json:"references"`
+}
+
+// matches dependencies against an internal advisory feed: OSV advisories
+// and simple ones, in a file, a directory or at a URL, read again at every
+// query so new advisories apply without a restart
+type FeedSource struct {
+	Path   string
+	URL    string
+	Token  string // sent as a bearer token to URL
+	client *http.Client
+}
+
+// builds a source for every advisory feed in the configuration
+func newFeedSources(cfg config.DependencyConfig) []VulnSource {
+	var sources []VulnSource
+	for _, feed := range cfg.AdvisoryFeeds {
+		sources = append(sources, NewFeedSource(feed))
+	}
+	return sources
+}
+
+func NewFeedSource(feed config.AdvisoryFeed) *FeedSource {
+	return &FeedSource{
+		Path:   feed.Path,
+		URL:    feed.URL,
+		Token:  feed.Token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (f *FeedSource) Name() string {
+	if f.URL != "" {
+		return "feed " + f.URL
+	}
+	return "feed " + f.Path
+}
+
+func (f *FeedSource) Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	var vulnerabilities []Vulnerability
+
+	osv, simple, err := f.load(ctx)
+	if err != nil {
+		return vulnerabilities, err
+	}
+
+	for _, dep := range deps {
+		for _, advisory := range osv {
+			if osvAffects(advisory, dep) {
+				vulnerabilities = append(vulnerabilities, convertOSVVuln(advisory, dep))
+			}
+		}
+		for _, advisory := range simple {
+			if advisory.affects(dep) {
+				vulnerabilities = append(vulnerabilities, advisory.vulnerability(dep))
+			}
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// reads the advisories of the feed
+func (f *FeedSource) load(ctx context.Context) ([]OSVVulnerability, []feedAdvisory, error) {
+	if f.URL != "" {
+		data, err := f.fetch(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch advisory feed: %w", err)
+		}
+		return parseFeed(data, f.URL)
+	}
+
+	files, err := advisoryFiles(f.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read advisory feed: %w", err)
+	}
+	var osv []OSVVulnerability
+	var simple []feedAdvisory
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read advisory %s: %w", file, err)
+		}
+		o, s, err := parseFeed(data, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		osv = append(osv, o...)
+		simple = append(simple, s...)
+	}
+	return osv, simple, nil
+}
+
+func (f *FeedSource) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.Token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parses a feed document: an advisory, a list of them, or an object with
+// an "advisories" or "vulns" list. Advisories with an "affected" list are
+// OSV, those with a "package" simple.
+func parseFeed(data []byte, name string) ([]OSVVulnerability, []feedAdvisory, error) {
+	var entries []json.RawMessage
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil, nil, nil
+	case data[0] == '[':
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse advisories %s: %w", name, err)
+		}
+	default:
+		var wrapper struct {
+			Advisories []json.RawMessage `json:"advisories"`
+			Vulns      []json.RawMessage `json:"vulns"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse advisories %s: %w", name, err)
+		}
+		entries = append(wrapper.Advisories, wrapper.Vulns...)
+		if entries == nil {
+			entries = []json.RawMessage{data}
+		}
+	}
+
+	var osv []OSVVulnerability
+	var simple []feedAdvisory
+	for i, entry := range entries {
+		var probe struct {
+			Affected json.RawMessage `json:"affected"`
+			Package  json.RawMessage `json:"package"`
+		}
+		if err := json.Unmarshal(entry, &probe); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse advisory %d of %s: %w", i+1, name, err)
+		}
+		switch {
+		case probe.Affected != nil:
+			var advisory OSVVulnerability
+			if err := json.Unmarshal(entry, &advisory); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse advisory %d of %s: %w", i+1, name, err)
+			}
+			osv = append(osv, advisory)
+		case probe.Package != nil:
+			var advisory feedAdvisory
+			if err := json.Unmarshal(entry, &advisory); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse advisory %d of %s: %w", i+1, name, err)
+			}
+			if advisory.ID == "" || advisory.Ecosystem == "" || advisory.Package == "" {
+				return nil, nil, fmt.Errorf("advisory %d of %s: id, ecosystem and package are required", i+1, name)
+			}
+			simple = append(simple, advisory)
+		default:
+			return nil, nil, fmt.Errorf("advisory %d of %s: neither OSV (affected) nor simple (package)", i+1, name)
+		}
+	}
+	return osv, simple, nil
+}
+
+func (a feedAdvisory) affects(dep Dependency) bool {
+	if !strings.EqualFold(a.Ecosystem, mapToOSVEcosystem(dep.Ecosystem)) || a.Package != dep.Name {
+		return false
+	}
+	return strings.TrimSpace(a.Versions) == "" || versionInRange(dep.Version, a.Versions)
+}
+
+func (a feedAdvisory) vulnerability(dep Dependency) Vulnerability {
+	return Vulnerability{
+		ID:         a.ID,
+		Summary:    a.Summary,
+		Details:    a.Details,
+		Severity:   normalizeSeverity(a.Severity),
+		Aliases:    a.Aliases,
+		References: a.References,
+		Dependency: dep,
+	}
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/pkg/report"
+)
+
+// copies the issue into the public finding model, with its file relative
+// to root when given
+func (i Issue) Finding(root string) report.Finding {
+	file := i.File
+	if root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			file = rel
+		}
+	}
+	return report.Finding{
+		Type:           i.Type,
+		Severity:       report.Severity(i.Severity),
+		Rule:           i.Rule,
+		Description:    i.Description,
+		File:           filepath.ToSlash(file),
+		Line:           i.Line,
+		Column:         i.Column,
+		Content:        i.Content,
+		SecretHash:     i.SecretHash,
+		Category:       i.Category,
+		AdvisoryID:     i.AdvisoryID,
+		KnownExploited: i.KnownExploited,
+		EPSS:           i.EPSS,
+		Verified:       i.Verified,
+		Remediation:    i.Remediation,
+		References:     i.References,
+		Owner:          i.Owner,
+		Commit:         i.Commit,
+		Author:         i.Author,
+		Fingerprint:    i.ID,
+	}
+}
+
+// the public report of the results: every occurrence, repeats of a secret
+// included, with files relative to root when given
+func (r *Results) Report(root string) *report.Report {
+	issues := r.Occurrences()
+	findings := make([]report.Finding, 0, len(issues))
+	for _, issue := range issues {
+		findings = append(findings, issue.Finding(root))
+	}
+	duration, _ := time.ParseDuration(r.Duration)
+	return &report.Report{
+		Findings:     findings,
+		FilesScanned: r.FilesScanned,
+		Duration:     duration,
+		Incomplete:   r.Incomplete,
+	}
+}
+
+// outputs the public report of the results as JSON, the model the Go
+// package and the server's scan responses share
+func (r *Results) OutputFindings(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.Report(""))
+}
@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// one line of a .gitignore file
+type ignoreRule struct {
+	pattern  string
+	base     string // directory of the .gitignore, relative to the repository root
+	negate   bool   // "!pattern" re-includes what earlier rules ignored
+	dirOnly  bool   // "pattern/" only matches directories
+	anchored bool   // a slash before the end ties the pattern to base
+}
+
+// the ignore rules in effect during a walk, in git's order of precedence:
+// the global excludes file, .git/info/exclude, then each directory's
+// .gitignore from the repository root down; later rules win. A nil
+// gitignore ignores nothing.
+type gitignore struct {
+	prefix string // the scanned directory, relative to the repository root
+	rules  []ignoreRule
+}
+
+// loads the rules that apply above root: the global and repository
+// excludes and the .gitignore files of root's parents up to the
+// repository root. Those of root and below are added by enter.
+func loadGitignore(root string) *gitignore {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+
+	top := abs
+	for dir := abs; ; {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			top = dir
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	g := &gitignore{}
+	if rel, err := filepath.Rel(top, abs); err == nil && rel != "." {
+		g.prefix = filepath.ToSlash(rel)
+	}
+
+	g.load(globalExcludesFile(top), "")
+	g.load(repoExcludeFile(top), "")
+	if g.prefix != "" {
+		g.load(filepath.Join(top, ".gitignore"), "")
+		dir := ""
+		for _, segment := range strings.Split(path.Dir(g.prefix), "/") {
+			if segment == "." {
+				continue
+			}
+			dir = path.Join(dir, segment)
+			g.load(filepath.Join(top, filepath.FromSlash(dir), ".gitignore"), dir)
+		}
+	}
+	return g
+}
+
+// adds the .gitignore of a directory the walk enters; rel is relative to
+// the scanned directory
+func (g *gitignore) enter(dir, rel string) {
+	if g == nil {
+		return
+	}
+	g.load(filepath.Join(dir, ".gitignore"), g.full(rel))
+}
+
+// reports whether git ignores a path relative to the scanned directory
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+	full := g.full(rel)
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.matches(full, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// makes a path relative to the scanned directory relative to the
+// repository root
+func (g *gitignore) full(rel string) string {
+	if rel == "." {
+		rel = ""
+	}
+	return path.Join(g.prefix, rel)
+}
+
+// reads the rules of one ignore file; a missing file has none
+func (g *gitignore) load(file, base string) {
+	if file == "" {
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range splitLines(string(data)) {
+		if rule, ok := parseIgnoreRule(line, base); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	rule.pattern = strings.TrimPrefix(line, "/")
+	if rule.pattern == "" {
+		return ignoreRule{}, false
+	}
+	return rule, true
+}
+
+// matches a path relative to the repository root
+func (r ignoreRule) matches(full string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	name := full
+	if r.base != "" {
+		if !strings.HasPrefix(full, r.base+"/") {
+			return false
+		}
+		name = full[len(r.base)+1:]
+	}
+
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(name, "/"))
+}
+
+// returns the repository's info/exclude. In a worktree .git is a file and
+// the exclude file is the main repository's, so git is asked for it.
+func repoExcludeFile(top string) string {
+	out, err := gitOutput(context.Background(), top, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return filepath.Join(top, ".git", "info", "exclude")
+	}
+	file := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(top, file)
+	}
+	return file
+}
+
+// returns git's core.excludesFile, or its default under the XDG config
+// directory
+func globalExcludesFile(repoPath string) string {
+	if out, err := gitOutput(context.Background(), repoPath, "config", "--path", "core.excludesFile"); err == nil {
+		if file := strings.TrimSpace(string(out)); file != "" {
+			return file
+		}
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// limits which commits a history scan walks
+type HistoryOptions struct {
+	Branch     string // ref to start from, HEAD when empty
+	Since      string // anything git log --since accepts
+	MaxCommits int    // 0 means no limit
+}
+
+// lines added to one file by one commit
+type addedLines struct {
+	commit  string
+	author  string
+	file    string
+	lines   []string
+	numbers []int // line number in the commit's version of the file
+}
+
+// scans every commit reachable from the branch for secrets introduced in
+// its diff, so keys that were later removed are still found; once ctx is
+// done the commits read so far are reported, marked Incomplete
+func (s *Scanner) ScanHistory(ctx context.Context, repoPath string, scanType ScanType, opts HistoryOptions) (*Results, error) {
+	var revs []string
+	if opts.Since != "" {
+		revs = append(revs, "--since="+opts.Since)
+	}
+	if opts.MaxCommits > 0 {
+		revs = append(revs, "--max-count="+strconv.Itoa(opts.MaxCommits))
+	}
+	branch := opts.Branch
+	if branch == "" {
+		branch = "HEAD"
+	}
+	revs = append(revs, branch)
+
+	return s.scanLog(ctx, repoPath, scanType, revs)
+}
+
+// scans the lines added by every commit git log selects with revs
+func (s *Scanner) scanLog(ctx context.Context, repoPath string, scanType ScanType, revs []string) (*Results, error) {
+	startTime := time.Now()
+
+	results := &Results{
+		ScanTime: startTime,
+		Issues:   make([]Issue, 0),
+	}
+
+	var detectors []Detector
+	for _, d := range s.detectors {
+		if s.detectorEnabled(d.Name(), d.Type(), scanType) {
+			detectors = append(detectors, d)
+		}
+	}
+	metrics := newDetectorMetrics()
+	s.whitelist = newWhitelistCounter()
+	s.profile = nil
+	if s.profiling {
+		s.profile = newProfiler()
+	}
+
+	commits := make(map[string]bool)
+	walk := func(mergeDiffs string) (stderr string, err error) {
+		args := []string{"log", "-p", "-U0", "--no-color", "--no-renames", mergeDiffs,
+			"--format=%x00commit %H%x00%an <%ae>"}
+		args = append(args, revs...)
+		args = append(args, "--")
+
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repoPath
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return "", fmt.Errorf("failed to run git log: %w", err)
+		}
+		var errOut strings.Builder
+		cmd.Stderr = &errOut
+		if err := cmd.Start(); err != nil {
+			return "", fmt.Errorf("failed to run git log: %w", err)
+		}
+
+		err = parseGitLog(stdout, int(s.config.MaxFileSize), func(commit string) {
+			if ctx.Err() == nil {
+				commits[commit] = true
+			}
+		}, func(added addedLines) {
+			if ctx.Err() != nil {
+				return
+			}
+			if !s.paths.allowFile(added.file) || !(shouldScanFile(added.file) || isDependencyFile(added.file)) {
+				return
+			}
+
+			results.FilesScanned++
+			results.addIssues(s.scanAddedLines(added, detectors, metrics)...)
+		})
+		waitErr := cmd.Wait()
+		if err == nil && waitErr != nil {
+			err = fmt.Errorf("git log failed: %s", strings.TrimSpace(errOut.String()))
+		}
+		return errOut.String(), err
+	}
+
+	// merges are diffed against their first parent, so lines a merge adds
+	// itself, as when resolving a conflict, are scanned too; git before
+	// 2.31 only has -m, which diffs against every parent
+	stderr, err := walk("--diff-merges=first-parent")
+	if err != nil && len(commits) == 0 && strings.Contains(stderr, "diff-merges") {
+		stderr, err = walk("-m")
+	}
+	// git is killed when ctx is done, which cuts its output short
+	results.Incomplete = ctx.Err() != nil
+	if err != nil && !results.Incomplete {
+		return nil, err
+	}
+
+	results.CommitsScanned = len(commits)
+	s.maskContents(results.Issues)
+	s.maskContents(results.Suppressed)
+	markSecretReuse(results.Issues)
+	s.dedupe(results, "")
+	results.Detectors = metrics.list()
+	results.Whitelist = s.whitelist.list()
+	results.Profile = s.profile.profile()
+	results.Summary = calculateSummary(results.Issues)
+	results.Duration = time.Since(startTime).String()
+
+	s.logger.Debug("history scan finished", "commits", results.CommitsScanned, "issues", len(results.Issues), "duration", results.Duration)
+	s.logWhitelistStats(results.Whitelist)
+
+	return results, nil
+}
+
+// runs the per-file detectors over the lines a commit added to a file
+func (s *Scanner) scanAddedLines(added addedLines, detectors []Detector, metrics *detectorMetrics) []Issue {
+	content := strings.Join(added.lines, "\n")
+
+	var issues []Issue
+	fileStart := time.Now()
+	for _, d := range detectors {
+		start := time.Now()
+		found := d.Detect("", added.file, content)
+		metrics.record(d.Name(), 1, len(found), time.Since(start))
+		markInlineIgnores(content, found)
+
+		for _, issue := range found {
+			if issue.Line >= 1 && issue.Line <= len(added.numbers) {
+				issue.Line = added.numbers[issue.Line-1]
+			}
+			issue.Commit = added.commit
+			issue.Author = added.author
+			issues = append(issues, issue)
+		}
+	}
+	s.profile.recordFile(shortCommit(added.commit)+":"+added.file, len(content), time.Since(fileStart))
+	issues = s.limitFileIssues(added.file, issues)
+	s.verifySecrets(issues)
+	return issues
+}
+
+// starts each commit header, matching the --format passed to git log
+const historyCommitMarker = "\x00commit "
+
+// parses `git log -p -U0` output, calling commitFn with every commit and fn
+// with the added lines of every file in it
+func parseGitLog(r io.Reader, maxLine int, commitFn func(string), fn func(addedLines)) error {
+	sc := bufio.NewScanner(r)
+	if maxLine < 1024*1024 {
+		maxLine = 1024 * 1024
+	}
+	sc.Buffer(make([]byte, 64*1024), maxLine)
+
+	var commit, author string
+	var current *addedLines
+	next := 0
+	// between "diff --git" and the first hunk, where ---/+++ are headers
+	inHeader := false
+
+	flush := func() {
+		if current != nil && len(current.lines) > 0 {
+			fn(*current)
+		}
+		current = nil
+	}
+
+	for sc.Scan() {
+		line := sc.Text()
+
+		switch {
+		case strings.HasPrefix(line, historyCommitMarker):
+			flush()
+			commit, author, _ = strings.Cut(strings.TrimPrefix(line, historyCommitMarker), "\x00")
+			commitFn(commit)
+
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			inHeader = true
+
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			flush()
+			path := strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				continue
+			}
+			current = &addedLines{
+				commit: commit,
+				author: author,
+				file:   strings.TrimPrefix(unquoteGitPath(path), "b/"),
+			}
+
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+			next = hunkStart(line)
+
+		case !inHeader && strings.HasPrefix(line, "+"):
+			if current != nil {
+				current.lines = append(current.lines, strings.TrimSuffix(line[1:], "\r"))
+				current.numbers = append(current.numbers, next)
+			}
+			next++
+		}
+	}
+	flush()
+
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("failed to read git log: %w", err)
+	}
+	return nil
+}
+
+// returns the first new-file line number of a "@@ -a,b +c,d @@" header
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	for _, f := range fields {
+		if strings.HasPrefix(f, "+") {
+			start, _, _ := strings.Cut(f[1:], ",")
+			n, err := strconv.Atoi(start)
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// undoes git's C-style quoting of paths with special characters
+func unquoteGitPath(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
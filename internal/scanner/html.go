@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// a self-contained page: styles and the filtering script are inline, so
+// the report can be attached to a release or audit record as one file.
+// Secrets appear as the scanner masked them.
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>GitGuardian Security Scan Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; background: #f6f8fa; }
+h1 { font-size: 1.6rem; margin: 0 0 .25rem; }
+h2 { font-size: 1.15rem; margin: 0 0 .75rem; }
+.meta { color: #59636e; margin: 0 0 1.5rem; }
+.meta span { margin-right: 1.5rem; }
+.cards { display: flex; flex-wrap: wrap; gap: 1rem; margin-bottom: 1.5rem; }
+.card { background: #fff; border: 1px solid #d1d9e0; border-radius: 6px; padding: 1rem 1.25rem; min-width: 8rem; }
+.card .count { font-size: 1.8rem; font-weight: 600; }
+.charts { display: flex; flex-wrap: wrap; gap: 1rem; margin-bottom: 1.5rem; }
+.chart { background: #fff; border: 1px solid #d1d9e0; border-radius: 6px; padding: 1rem 1.25rem; flex: 1; min-width: 18rem; }
+.bar { display: flex; align-items: center; margin: .35rem 0; }
+.bar .label { width: 8rem; }
+.bar .track { flex: 1; background: #eff2f5; border-radius: 3px; height: .9rem; margin: 0 .75rem; }
+.bar .fill { height: 100%; border-radius: 3px; background: #54aeff; }
+.bar .value { width: 3rem; text-align: right; }
+.critical { color: #a40e26; } .fill.critical { background: #cf222e; }
+.high { color: #bc4c00; } .fill.high { background: #fb8500; }
+.medium { color: #9a6700; } .fill.medium { background: #d4a72c; }
+.low { color: #0969da; } .fill.low { background: #54aeff; }
+.filters { display: flex; flex-wrap: wrap; gap: .75rem; margin-bottom: .75rem; }
+.filters select, .filters input { font: inherit; padding: .3rem .5rem; border: 1px solid #d1d9e0; border-radius: 6px; }
+.filters input { flex: 1; min-width: 14rem; }
+table { width: 100%; border-collapse: collapse; background: #fff; border: 1px solid #d1d9e0; }
+th, td { text-align: left; padding: .5rem .75rem; border-bottom: 1px solid #d1d9e0; vertical-align: top; }
+th { background: #f6f8fa; }
+td.severity { font-weight: 600; white-space: nowrap; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: .85em; word-break: break-all; }
+.new { background: #dafbe1; color: #116329; border-radius: 3px; padding: 0 .3rem; font-size: .8em; margin-left: .3rem; }
+.empty { background: #fff; border: 1px solid #d1d9e0; border-radius: 6px; padding: 2rem; text-align: center; }
+</style>
+</head>
+<body>
+<h1>GitGuardian Security Scan Report</h1>
+<p class="meta">
+<span>Scanned {{.Results.ScanTime.Format "2006-01-02 15:04:05"}}</span>
+<span>Duration {{.Results.Duration}}</span>
+<span>{{.Results.FilesScanned}} files</span>
+{{- if .Results.CommitsScanned}}<span>{{.Results.CommitsScanned}} commits</span>{{end}}
+{{- if .Results.Revision}}<span>Revision <code>{{.Results.Revision}}</code></span>{{end}}
+{{- if .Results.Shard}}<span>Shard {{.Results.Shard}}</span>{{end}}
+{{- if .Results.Suppressed}}<span>{{len .Results.Suppressed}} suppressed by ignore comments</span>{{end}}
+</p>
+{{if not .Results.Issues}}
+<div class="empty">No security issues found.</div>
+{{else}}
+<div class="cards">
+<div class="card"><div class="count">{{.Results.Summary.Total}}</div>Total</div>
+{{- range .Severities}}
+<div class="card"><div class="count {{.Name}}">{{.Count}}</div>{{.Label}}</div>
+{{- end}}
+</div>
+<div class="charts">
+<div class="chart">
+<h2>By severity</h2>
+{{- range .Severities}}
+<div class="bar"><span class="label">{{.Label}}</span><span class="track"><span class="fill {{.Name}}" style="display:block;width:{{.Percent}}%"></span></span><span class="value">{{.Count}}</span></div>
+{{- end}}
+</div>
+<div class="chart">
+<h2>By type</h2>
+{{- range .Types}}
+<div class="bar"><span class="label">{{.Label}}</span><span class="track"><span class="fill" style="display:block;width:{{.Percent}}%"></span></span><span class="value">{{.Count}}</span></div>
+{{- end}}
+</div>
+{{- if .Categories}}
+<div class="chart">
+<h2>By category</h2>
+{{- range .Categories}}
+<div class="bar"><span class="label">{{.Label}}</span><span class="track"><span class="fill" style="display:block;width:{{.Percent}}%"></span></span><span class="value">{{.Count}}</span></div>
+{{- end}}
+</div>
+{{- end}}
+</div>
+<div class="filters">
+<select id="severity"><option value="">All severities</option>{{range .Severities}}{{if .Count}}<option value="{{.Name}}">{{.Label}}</option>{{end}}{{end}}</select>
+<select id="type"><option value="">All types</option>{{range .Types}}<option value="{{.Label}}">{{.Label}}</option>{{end}}</select>
+<input id="search" type="search" placeholder="Filter by file, rule or description">
+<span id="shown"></span>
+</div>
+<table>
+<thead><tr><th>Severity</th><th>Type</th><th>Rule</th><th>Location</th><th>Description</th><th>Content</th></tr></thead>
+<tbody>
+{{- range .Issues}}
+<tr data-severity="{{.Severity}}" data-type="{{.Type}}">
+<td class="severity {{.Severity}}">{{upper .Severity}}{{if .New}}<span class="new">NEW</span>{{end}}</td>
+<td>{{.Type}}</td>
+<td>{{.Rule}}</td>
+<td><code>{{.Location}}</code>{{if .Commit}}<br>commit <code>{{.Commit}}</code> {{.Author}}{{end}}</td>
+<td>{{.Description}}{{if .Verified}} ({{.Verified}}){{end}}{{if .KnownExploited}} (known exploited, KEV){{end}}{{if .EPSS}} (EPSS {{printf "%.2f" .EPSS}}){{end}}{{if .Locations}}<br>Reused in {{len .Locations}} places{{end}}{{if .Remediation}}<br><em>Remediation:</em> {{.Remediation}}{{end}}{{range .References}}<br><a href="{{.}}">{{.}}</a>{{end}}{{if .Owner}}<br>Owner: {{.Owner}}{{end}}</td>
+<td>{{if .Content}}<code>{{.Content}}</code>{{end}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+(function () {
+  var severity = document.getElementById("severity");
+  var type = document.getElementById("type");
+  var search = document.getElementById("search");
+  var shown = document.getElementById("shown");
+  var rows = document.querySelectorAll("tbody tr");
+  function apply() {
+    var text = search.value.toLowerCase(), count = 0;
+    rows.forEach(function (row) {
+      var visible = (!severity.value || row.dataset.severity === severity.value) &&
+        (!type.value || row.dataset.type === type.value) &&
+        (!text || row.textContent.toLowerCase().indexOf(text) >= 0);
+      row.style.display = visible ? "" : "none";
+      if (visible) count++;
+    });
+    shown.textContent = count + " of " + rows.length + " findings";
+  }
+  [severity, type, search].forEach(function (el) { el.addEventListener("input", apply); });
+  apply();
+})();
+</script>
+{{end}}
+</body>
+</html>
+`))
+
+// one bar of a summary chart
+type htmlBar struct {
+	Name    string
+	Label   string
+	Count   int
+	Percent int
+}
+
+type htmlIssue struct {
+	Issue
+	Location string
+}
+
+// outputs results as a standalone HTML page with summary charts and a
+// filterable table of findings
+func (r *Results) OutputHTML(w io.Writer) error {
+	total := r.Summary.Total
+	if total == 0 {
+		total = len(r.Issues)
+	}
+	bar := func(name, label string, count int) htmlBar {
+		percent := 0
+		if total > 0 {
+			percent = count * 100 / total
+		}
+		return htmlBar{Name: name, Label: label, Count: count, Percent: percent}
+	}
+
+	data := struct {
+		Results    *Results
+		Severities []htmlBar
+		Types      []htmlBar
+		Categories []htmlBar
+		Issues     []htmlIssue
+	}{
+		Results: r,
+		Severities: []htmlBar{
+			bar("critical", "Critical", r.Summary.Critical),
+			bar("high", "High", r.Summary.High),
+			bar("medium", "Medium", r.Summary.Medium),
+			bar("low", "Low", r.Summary.Low),
+		},
+	}
+	for _, issueType := range summaryTypes(r.Summary.ByType) {
+		data.Types = append(data.Types, bar(issueType, issueType, r.Summary.ByType[issueType].Total))
+	}
+	for _, category := range summaryTypes(r.Summary.ByCategory) {
+		data.Categories = append(data.Categories, bar(category, category, r.Summary.ByCategory[category].Total))
+	}
+
+	for _, issue := range r.Issues {
+		location := filepath.ToSlash(issue.File)
+		if issue.Line > 0 {
+			location += ":" + strconv.Itoa(issue.Line)
+		}
+		data.Issues = append(data.Issues, htmlIssue{Issue: issue, Location: location})
+	}
+
+	return htmlReport.Execute(w, data)
+}
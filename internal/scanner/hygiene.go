@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// files that hold secrets by nature; each pattern, matched against the
+// base name, is also the .gitignore entry suggested for it
+var sensitiveFiles = []struct {
+	pattern string
+	kind    string
+}{
+	{".env", "environment files"},
+	{".env.*", "environment files"},
+	{"*.pem", "PEM keys and certificates"},
+	{"*.key", "private keys"},
+	{"*.p12", "PKCS#12 keystores"},
+	{"*.pfx", "PKCS#12 keystores"},
+	{"id_rsa", "SSH private keys"},
+	{"id_dsa", "SSH private keys"},
+	{"id_ecdsa", "SSH private keys"},
+	{"id_ed25519", "SSH private keys"},
+	{".npmrc", "registry credentials"},
+	{".pypirc", "registry credentials"},
+	{".netrc", "credential files"},
+}
+
+// files whose names say they are safe to commit
+var sensitiveFileExceptions = []string{".env.example", ".env.sample", ".env.template", "*.pub"}
+
+// suggests .gitignore entries for the kinds of sensitive file that scan
+// findings were made in, when root's .gitignore does not already cover
+// them; each suggestion is an "advisory" issue whose Content is the entry
+func (s *Scanner) gitignoreAdvice(root string, issues []Issue) []Issue {
+	ignore := readGitignore(root)
+	found := make(map[string][]string) // entry -> files
+	kinds := make(map[string]string)
+
+	for _, issue := range issues {
+		if issue.Type != "secret" {
+			continue
+		}
+		rel, err := filepath.Rel(root, issue.File)
+		if err != nil {
+			rel = issue.File
+		}
+		rel = filepath.ToSlash(rel)
+		base := path.Base(rel)
+
+		if matchesAny(sensitiveFileExceptions, base) || gitignoreCovers(ignore, rel) {
+			continue
+		}
+		for _, sf := range sensitiveFiles {
+			if ok, _ := path.Match(sf.pattern, base); !ok {
+				continue
+			}
+			if !contains(found[sf.pattern], rel) {
+				found[sf.pattern] = append(found[sf.pattern], rel)
+			}
+			kinds[sf.pattern] = sf.kind
+			break
+		}
+	}
+
+	entries := make([]string, 0, len(found))
+	for entry := range found {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	var advice []Issue
+	for _, entry := range entries {
+		advice = append(advice, Issue{
+			Type:        "advisory",
+			Severity:    "low",
+			File:        filepath.Join(root, ".gitignore"),
+			Description: fmt.Sprintf("Add %q to .gitignore to keep %s out of the repository (secrets found in %s)", entry, kinds[entry], strings.Join(found[entry], ", ")),
+			Content:     entry,
+			Rule:        "Gitignore Hygiene",
+			Timestamp:   time.Now(),
+		})
+	}
+	return advice
+}
+
+// appends the entries suggested by "Gitignore Hygiene" issues to root's
+// .gitignore, returning the entries added
+func AppendGitignore(root string, issues []Issue) ([]string, error) {
+	var entries []string
+	for _, issue := range issues {
+		if issue.Rule == "Gitignore Hygiene" && issue.Content != "" && !contains(entries, issue.Content) {
+			entries = append(entries, issue.Content)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	file := filepath.Join(root, ".gitignore")
+	existing, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	var b strings.Builder
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n# added by gitguardian: files that hold secrets\n")
+	for _, entry := range entries {
+		b.WriteString(entry + "\n")
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		return nil, fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	return entries, nil
+}
+
+// returns the patterns of root's .gitignore, without comments and negations
+func readGitignore(root string) []string {
+	data, err := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	for _, line := range splitLines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimSuffix(strings.TrimPrefix(line, "/"), "/"))
+	}
+	return patterns
+}
+
+// reports whether a .gitignore pattern matches the file or a directory
+// it is in
+func gitignoreCovers(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		for dir := rel; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if matchGlob(pattern, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
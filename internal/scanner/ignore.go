@@ -0,0 +1,54 @@
+package scanner
+
+import "strings"
+
+const (
+	ignorePragma         = "gitguardian:ignore"
+	ignoreNextLinePragma = "gitguardian:ignore-next-line"
+)
+
+// marks issues on lines carrying a gitguardian:ignore pragma, or following
+// a gitguardian:ignore-next-line pragma, as suppressed; the pragma works
+// with any comment syntax since only the marker text is matched
+func markInlineIgnores(content string, issues []Issue) {
+	ignored := ignoredLines(content)
+	if ignored == nil {
+		return
+	}
+	for i := range issues {
+		if ignored[issues[i].Line] {
+			issues[i].SuppressedBy = "inline"
+		}
+	}
+}
+
+// returns the numbers of the lines ignore comments cover, or nil when
+// content has none
+func ignoredLines(content string) map[int]bool {
+	if !strings.Contains(content, ignorePragma) {
+		return nil
+	}
+
+	ignored := make(map[int]bool)
+	for i, line := range splitLines(content) {
+		switch {
+		case strings.Contains(line, ignoreNextLinePragma):
+			ignored[i+2] = true
+		case strings.Contains(line, ignorePragma):
+			ignored[i+1] = true
+		}
+	}
+	return ignored
+}
+
+// appends issues to the results, keeping suppressed ones apart so they are
+// auditable but do not count as findings
+func (r *Results) addIssues(issues ...Issue) {
+	for _, issue := range issues {
+		if issue.SuppressedBy != "" {
+			r.Suppressed = append(r.Suppressed, issue)
+		} else {
+			r.Issues = append(r.Issues, issue)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// base images past their upstream end of life, as of October 2026; the
+// "images.known_bad" configuration adds to this list
+var defaultImageRules = []config.ImageRule{
+	{Image: "node", Versions: "< 22", Reason: "Node.js 20 and older are end of life", Severity: "medium"},
+	{Image: "python", Versions: "< 3.10", Reason: "Python 3.9 and older are end of life", Severity: "medium"},
+	{Image: "ruby", Versions: "< 3.3", Reason: "Ruby 3.2 and older are end of life", Severity: "medium"},
+	{Image: "php", Versions: "< 8.2", Reason: "PHP 8.1 and older are end of life", Severity: "medium"},
+	{Image: "ubuntu", Versions: "< 22.04", Reason: "Ubuntu 20.04 and older are out of standard support", Severity: "medium"},
+	{Image: "debian", Versions: "< 12", Reason: "Debian 11 and older are end of life", Severity: "medium"},
+	{Image: "alpine", Versions: "< 3.21", Reason: "Alpine 3.20 and older are end of life", Severity: "medium"},
+	{Image: "centos", Reason: "CentOS Linux is end of life", Severity: "high"},
+	{Image: "openjdk", Reason: "the openjdk image is deprecated and no longer updated; use eclipse-temurin", Severity: "medium"},
+}
+
+// release codenames used as tags, by image
+var imageCodenames = map[string]map[string]string{
+	"debian": {
+		"jessie": "8", "stretch": "9", "buster": "10",
+		"bullseye": "11", "bookworm": "12", "trixie": "13",
+	},
+	"ubuntu": {
+		"trusty": "14.04", "xenial": "16.04", "bionic": "18.04",
+		"focal": "20.04", "jammy": "22.04", "noble": "24.04",
+	},
+}
+
+// images whose tag is the version of a package OSV tracks
+var imagePackages = map[string]struct{ ecosystem, name string }{
+	"golang": {"Go", "stdlib"},
+}
+
+// a base image named by a Dockerfile FROM line or a compose image: entry
+type imageRef struct {
+	name    string // repository, without docker.io/library/
+	tag     string
+	version string // from the tag; empty when it names no version
+	file    string
+	line    int
+}
+
+// reports whether a file is a Dockerfile or a docker-compose file
+func isImageFile(filePath string) bool {
+	base := strings.ToLower(filepath.Base(filePath))
+	switch {
+	case base == "dockerfile", strings.HasPrefix(base, "dockerfile."), strings.HasSuffix(base, ".dockerfile"):
+		return true
+	case base == "compose.yml", base == "compose.yaml":
+		return true
+	case strings.HasPrefix(base, "docker-compose") && (strings.HasSuffix(base, ".yml") || strings.HasSuffix(base, ".yaml")):
+		return true
+	}
+	return false
+}
+
+// checks base images against the known-bad list and, for images that
+// package a runtime OSV tracks, against the vulnerability sources
+type imageDetector struct {
+	s *Scanner
+}
+
+func (d imageDetector) Name() string   { return "images" }
+func (d imageDetector) Type() ScanType { return ScanTypeDependencies }
+
+func (d imageDetector) Wants(filePath string) bool {
+	return d.s.config.Images.Enabled && isImageFile(filePath)
+}
+
+func (d imageDetector) DetectFiles(ctx context.Context, files []string, read ReadFunc) BatchResult {
+	var result BatchResult
+	rules := append(append([]config.ImageRule{}, defaultImageRules...), d.s.config.Images.KnownBad...)
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		content, ok := read(file)
+		if !ok {
+			continue
+		}
+
+		var refs []imageRef
+		if strings.Contains(strings.ToLower(filepath.Base(file)), "compose") {
+			refs = parseComposeImages(file, content)
+		} else {
+			refs = parseDockerfileImages(file, content)
+		}
+
+		for _, ref := range refs {
+			result.Issues = append(result.Issues, checkImageRules(ref, rules)...)
+
+			if pkg, ok := imagePackages[ref.name]; ok && strings.Count(ref.version, ".") >= 2 {
+				result.Dependencies = append(result.Dependencies, Dependency{
+					Name:      pkg.name,
+					Version:   ref.version,
+					Ecosystem: pkg.ecosystem,
+					File:      ref.file,
+					Line:      ref.line,
+				})
+			}
+		}
+	}
+
+	result.Issues = append(result.Issues, d.s.scanDependencies(ctx, result.Dependencies)...)
+	return result
+}
+
+// reports every rule the image matches
+func checkImageRules(ref imageRef, rules []config.ImageRule) []Issue {
+	var issues []Issue
+	for _, rule := range rules {
+		if normalizeImageName(rule.Image) != ref.name {
+			continue
+		}
+		if rule.Versions != "" && (ref.version == "" || !versionInRange(ref.version, rule.Versions)) {
+			continue
+		}
+
+		severity := rule.Severity
+		if severity == "" {
+			severity = "medium"
+		}
+		issues = append(issues, Issue{
+			Type:        "vulnerability",
+			Severity:    severity,
+			File:        ref.file,
+			Line:        ref.line,
+			Column:      1,
+			Description: fmt.Sprintf("Base image %s:%s: %s", ref.name, ref.tag, rule.Reason),
+			Rule:        "Base Image Check",
+			Timestamp:   time.Now(),
+		})
+	}
+	return issues
+}
+
+var (
+	dockerfileArg  = regexp.MustCompile(`(?i)^\s*ARG\s+([A-Za-z_][A-Za-z0-9_]*)(?:=(\S*))?`)
+	dockerfileFrom = regexp.MustCompile(`(?i)^\s*FROM\s+(.*)$`)
+	composeImage   = regexp.MustCompile(`^\s*image:\s*["']?([^"'\s#]+)`)
+	dockerVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::?-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// finds the base images of a Dockerfile; ARG defaults declared before
+// FROM are substituted, and stages that build on earlier stages or on
+// scratch are left out
+func parseDockerfileImages(file, content string) []imageRef {
+	var refs []imageRef
+	args := make(map[string]string)
+	stages := make(map[string]bool)
+
+	for i, line := range splitLines(content) {
+		if m := dockerfileArg.FindStringSubmatch(line); m != nil {
+			args[m[1]] = strings.Trim(m[2], `"'`)
+			continue
+		}
+
+		m := dockerfileFrom.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		var image string
+		fields := strings.Fields(m[1])
+		for j, field := range fields {
+			if strings.HasPrefix(field, "--") {
+				continue
+			}
+			if image == "" {
+				image = field
+				continue
+			}
+			if strings.EqualFold(field, "as") && j+1 < len(fields) {
+				stages[strings.ToLower(fields[j+1])] = true
+			}
+			break
+		}
+
+		image = expandDockerVariables(image, args)
+		if image == "" || strings.EqualFold(image, "scratch") || stages[strings.ToLower(image)] || strings.Contains(image, "$") {
+			continue
+		}
+		refs = append(refs, newImageRef(image, file, i+1))
+	}
+	return refs
+}
+
+// finds the image: entries of a docker-compose file
+func parseComposeImages(file, content string) []imageRef {
+	var refs []imageRef
+	for i, line := range splitLines(content) {
+		m := composeImage.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		image := expandDockerVariables(m[1], nil)
+		if strings.Contains(image, "$") {
+			continue
+		}
+		refs = append(refs, newImageRef(image, file, i+1))
+	}
+	return refs
+}
+
+// substitutes ${VAR}, ${VAR:-default} and $VAR; unknown variables without
+// a default are kept so callers can tell the reference is unresolved
+func expandDockerVariables(s string, vars map[string]string) string {
+	return dockerVariable.ReplaceAllStringFunc(s, func(match string) string {
+		m := dockerVariable.FindStringSubmatch(match)
+		name := m[1] + m[3]
+		if value, ok := vars[name]; ok && value != "" {
+			return value
+		}
+		if m[2] != "" {
+			return m[2]
+		}
+		return match
+	})
+}
+
+// splits an image reference into repository and tag and works out the
+// version the tag names
+func newImageRef(image, file string, line int) imageRef {
+	ref := imageRef{file: file, line: line}
+
+	image, _, _ = strings.Cut(image, "@") // digests pin content, not a version
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	ref.name = normalizeImageName(name)
+	ref.tag = tag
+	ref.version = imageVersion(ref.name, tag)
+	return ref
+}
+
+// drops the implied Docker Hub registry and library namespace
+func normalizeImageName(name string) string {
+	name = strings.ToLower(name)
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return strings.TrimPrefix(name, "library/")
+}
+
+var tagVersion = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)`)
+
+// returns the version a tag names: "3.9-slim" gives 3.9 and, for images
+// tagged by codename, "bullseye-slim" gives 11; "latest" gives nothing
+func imageVersion(name, tag string) string {
+	if codenames, ok := imageCodenames[name]; ok {
+		codename, _, _ := strings.Cut(tag, "-")
+		if version, ok := codenames[codename]; ok {
+			return version
+		}
+	}
+	if m := tagVersion.FindStringSubmatch(tag); m != nil {
+		return m[1]
+	}
+	return ""
+}
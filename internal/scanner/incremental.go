@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+)
+
+// how long the findings of an unchanged file are reused before it is
+// scanned again
+const resultsCacheTTL = 7 * 24 * time.Hour
+
+// reuses the per-file detector findings of files whose path, content and
+// rules are unchanged since an earlier scan, so rescans only process
+// modified files; findings are stored masked, never in plaintext
+func (s *Scanner) SetResultsCache(backend cache.Backend) {
+	s.results = cache.NewStore(backend, "files")
+}
+
+// identifies everything besides the file itself that decides the
+// per-file detectors' findings
+func (s *Scanner) resultsDigest(detectors []Detector) string {
+	var names []string
+	for _, d := range detectors {
+		names = append(names, d.Name())
+	}
+	data, _ := json.Marshal(struct {
+		Detectors       []string
+		Patterns        interface{}
+		Whitelist       interface{}
+		Entropy         interface{}
+		Social          interface{}
+		LockfileIgnores interface{}
+		NoPlaintext     bool
+		MaxFindings     int
+		HashKey         string
+	}{
+		names,
+		s.config.SecretPatterns,
+		s.config.Whitelist,
+		s.config.Entropy,
+		s.config.SocialEngineering,
+		s.config.LockfileIgnores,
+		s.config.NoPlaintext,
+		s.config.MaxFindingsPerFile,
+		// identifies the key without revealing it
+		s.HashSecret(""),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func resultsKey(digest, filePath, content string) string {
+	h := sha256.New()
+	h.Write([]byte(digest))
+	h.Write([]byte{0})
+	h.Write([]byte(filePath))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// returns the cached findings for a file, when the cache has them
+func (s *Scanner) cachedIssues(key string) ([]Issue, bool) {
+	data, ok := s.results.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var issues []Issue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, false
+	}
+	s.cacheHits.Add(1)
+	return issues, true
+}
+
+// stores a file's findings, which scanFile has already masked
+func (s *Scanner) cacheIssues(key string, issues []Issue) {
+	data, err := json.Marshal(issues)
+	if err != nil {
+		return
+	}
+	if err := s.results.Set(key, data, resultsCacheTTL); err != nil {
+		s.logger.Debug("failed to cache findings", "error", err)
+	}
+}
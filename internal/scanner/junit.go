@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JUnit XML as read by Jenkins, GitLab and CircleCI test reports
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// outputs results as JUnit XML: a test suite per rule with a failing test
+// case per file it fired in, so CI test report views list scan failures;
+// a clean scan is a single passing test case
+func (r *Results) OutputJUnit(w io.Writer) error {
+	byRule := make(map[string]map[string][]Issue) // rule -> file -> issues
+	for _, issue := range r.Issues {
+		rule := sarifRuleID(issue)
+		if byRule[rule] == nil {
+			byRule[rule] = make(map[string][]Issue)
+		}
+		file := filepath.ToSlash(issue.File)
+		byRule[rule][file] = append(byRule[rule][file], issue)
+	}
+
+	doc := junitTestSuites{Name: "gitguardian", Time: junitSeconds(r.Duration)}
+	for _, rule := range sortedKeys(byRule) {
+		suite := junitTestSuite{Name: rule}
+		for _, file := range sortedKeys(byRule[rule]) {
+			issues := byRule[rule][file]
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      file,
+				ClassName: "gitguardian." + sarifRuleName(rule),
+				File:      file,
+				Failure:   junitFailureFor(issues),
+			})
+		}
+		suite.Tests = len(suite.Cases)
+		suite.Failures = len(suite.Cases)
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if len(doc.Suites) == 0 {
+		doc.Suites = []junitTestSuite{{
+			Name:  "gitguardian",
+			Tests: 1,
+			Cases: []junitTestCase{{
+				Name:      fmt.Sprintf("%d files scanned", r.FilesScanned),
+				ClassName: "gitguardian.Scan",
+			}},
+		}}
+	}
+	for _, suite := range doc.Suites {
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// one failure for every finding of a rule in a file, typed by the most
+// severe of them
+func junitFailureFor(issues []Issue) *junitFailure {
+	severity := issues[0].Severity
+	var b strings.Builder
+	for _, issue := range issues {
+		if SeverityRank(issue.Severity) > SeverityRank(severity) {
+			severity = issue.Severity
+		}
+		location := filepath.ToSlash(issue.File)
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", location, issue.Line, issue.Column)
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", strings.ToUpper(issue.Severity), location, issue.Description)
+		if issue.Content != "" {
+			fmt.Fprintf(&b, "  %s\n", issue.Content)
+		}
+	}
+
+	message := issues[0].Description
+	if len(issues) > 1 {
+		message = fmt.Sprintf("%s (%d findings)", message, len(issues))
+	}
+	return &junitFailure{Message: message, Type: severity, Text: b.String()}
+}
+
+// converts a Go duration string to the seconds JUnit expects
+func junitSeconds(duration string) string {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return "0"
+	}
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Git LFS pointers are small text files naming the object they stand for
+const (
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	lfsPointerMaxSize = 1024
+)
+
+// the object a Git LFS pointer stands for
+type lfsPointer struct {
+	text string
+	oid  string // SHA-256 of the content, in hex
+	size int64
+}
+
+// recognizes a Git LFS pointer file:
+//
+//	version https://git-lfs.github.com/spec/v1
+//	oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+//	size 12345
+func parseLFSPointer(content []byte) (lfsPointer, bool) {
+	if len(content) > lfsPointerMaxSize || !bytes.HasPrefix(content, []byte(lfsPointerVersion+"\n")) {
+		return lfsPointer{}, false
+	}
+
+	pointer := lfsPointer{text: string(content), size: -1}
+	for _, line := range splitLines(pointer.text) {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "oid":
+			hash, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || len(hash) != 64 || strings.Trim(hash, hexChars) != "" {
+				return lfsPointer{}, false
+			}
+			pointer.oid = strings.ToLower(hash)
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return lfsPointer{}, false
+			}
+			pointer.size = size
+		}
+	}
+	return pointer, pointer.oid != "" && pointer.size >= 0
+}
+
+// returns the content a pointer in the repository at dir stands for, or
+// why it was skipped: from the local LFS store, or with lfs.fetch from
+// git lfs smudge, which downloads it
+func (s *Scanner) readLFSObject(ctx context.Context, dir, path string, pointer lfsPointer) (string, string) {
+	cfg := s.config.LFS
+	if !cfg.Enabled {
+		s.logger.Debug("skipping Git LFS pointer", "file", path)
+		return "", SkipLFSPointer
+	}
+	if pointer.size > s.config.MaxFileSize {
+		s.logger.Debug("skipping large Git LFS object", "file", path, "bytes", pointer.size)
+		return "", SkipTooLarge
+	}
+
+	data, err := readLocalLFSObject(ctx, dir, pointer.oid)
+	if err != nil && cfg.Fetch {
+		data, err = smudgeLFSObject(ctx, dir, pointer)
+	}
+	if err != nil {
+		s.logger.Warn("Git LFS object unavailable, skipping", "file", path, "error", err)
+		return "", SkipLFSPointer
+	}
+	defer clear(data)
+
+	if isMetadataFile(path) {
+		return s.readMetadata(path, data)
+	}
+	if isBinary(data) {
+		return "", SkipBinary
+	}
+	return string(data), ""
+}
+
+// replaces the content of blobs that are Git LFS pointers with the
+// objects they stand for, dropping those it cannot read
+func (s *Scanner) resolveLFSBlobs(ctx context.Context, repoPath string, blobs []Blob) []Blob {
+	kept := blobs[:0]
+	for _, blob := range blobs {
+		if pointer, ok := parseLFSPointer([]byte(blob.Content)); ok {
+			content, reason := s.readLFSObject(ctx, repoPath, blob.Path, pointer)
+			if reason != "" {
+				continue
+			}
+			blob.Content = content
+		}
+		kept = append(kept, blob)
+	}
+	return kept
+}
+
+// reads an object from the LFS store of the repository at dir, where git
+// lfs keeps what it has downloaded: <git dir>/lfs/objects/4d/7a/4d7a...
+func readLocalLFSObject(ctx context.Context, dir, oid string) ([]byte, error) {
+	out, err := gitOutput(ctx, dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return nil, err
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return os.ReadFile(filepath.Join(gitDir, "lfs", "objects", oid[:2], oid[2:4], oid))
+}
+
+// has git lfs turn a pointer into its content, downloading the object
+func smudgeLFSObject(ctx context.Context, dir string, pointer lfsPointer) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "lfs", "smudge")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(pointer.text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git lfs smudge: %s", msg)
+		}
+		return nil, fmt.Errorf("git lfs smudge: %w", err)
+	}
+	if int64(len(out)) != pointer.size {
+		clear(out)
+		return nil, fmt.Errorf("git lfs smudge returned %d bytes, the pointer says %d", len(out), pointer.size)
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+)
+
+// the rule of the issue standing in for the matches past
+// max_findings_per_file
+const findingLimitRule = "Finding Limit"
+
+// keeps the first max_findings_per_file secret matches of a file; the rest,
+// as a generated fixtures file can produce by the thousand, become one issue
+// counting them, at the line of the first left out and the highest severity
+// among them, so fail_on still sees it. scanSecrets only counts the matches
+// past the limit, handing them over as such an issue, which is merged here
+// with the other detectors' matches past it. Suppressed issues do not count
+// toward the limit and other issues, vulnerabilities or social engineering,
+// are kept.
+func (s *Scanner) limitFileIssues(filePath string, issues []Issue) []Issue {
+	limit := s.config.MaxFindingsPerFile
+	if limit <= 0 {
+		return issues
+	}
+
+	var overflow *Issue
+	kept := issues[:0]
+	reported := 0
+	for _, issue := range issues {
+		switch {
+		case issue.Rule == findingLimitRule && issue.dropped > 0:
+			overflow = tallyOverflow(overflow, issue, issue.dropped)
+			continue
+		case issue.Type == "secret" && issue.SuppressedBy == "":
+			if reported == limit {
+				overflow = tallyOverflow(overflow, issue, 1)
+				continue
+			}
+			reported++
+		}
+		kept = append(kept, issue)
+	}
+	if overflow == nil {
+		return kept
+	}
+
+	overflow.File = filePath
+	overflow.Description = fmt.Sprintf("More than %d secret matches in one file; the rest are not reported", limit)
+	overflow.Content = fmt.Sprintf("%d additional matches suppressed", overflow.dropped)
+	s.logger.Warn("too many secret matches in file; reporting the first", "file", filePath, "reported", limit, "suppressed", overflow.dropped)
+	return append(kept, *overflow)
+}
+
+// counts n matches left out, issue the first of them, into the issue
+// standing in for them all
+func tallyOverflow(overflow *Issue, issue Issue, n int) *Issue {
+	if overflow == nil {
+		return &Issue{
+			Type:      "secret",
+			Severity:  issue.Severity,
+			File:      issue.File,
+			Line:      issue.Line,
+			Column:    issue.Column,
+			Rule:      findingLimitRule,
+			Timestamp: time.Now(),
+			Commit:    issue.Commit,
+			Author:    issue.Author,
+			dropped:   n,
+		}
+	}
+	if SeverityRank(issue.Severity) > SeverityRank(overflow.Severity) {
+		overflow.Severity = issue.Severity
+	}
+	overflow.dropped += n
+	return overflow
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// generated hash lines in an ecosystem's lockfiles, where hash-like rules
+// only ever find checksums
+type lockfileIgnore struct {
+	ecosystem string
+	files     []string
+	lines     *regexp.Regexp
+}
+
+var defaultLockfileIgnores = []lockfileIgnore{
+	{"npm", []string{"package-lock.json", "npm-shrinkwrap.json"}, regexp.MustCompile(`"(integrity|resolved)":`)},
+	{"npm", []string{"yarn.lock"}, regexp.MustCompile(`^\s*"?(integrity|resolved|checksum)"?[\s:]`)},
+	{"npm", []string{"pnpm-lock.yaml"}, regexp.MustCompile(`\b(integrity|resolution|tarball):`)},
+	{"go", []string{"go.sum"}, regexp.MustCompile(`.`)},
+	{"cargo", []string{"Cargo.lock"}, regexp.MustCompile(`^\s*(checksum|source)\s*=`)},
+	{"python", []string{"poetry.lock", "Pipfile.lock"}, regexp.MustCompile(`\b(hash|sha256)\b|"sha256:`)},
+	{"php", []string{"composer.lock"}, regexp.MustCompile(`"(shasum|reference)":`)},
+	{"ruby", []string{"Gemfile.lock"}, regexp.MustCompile(`^\s*(revision|ref):|sha256=`)},
+	{"nuget", []string{"packages.lock.json"}, regexp.MustCompile(`"contentHash":`)},
+}
+
+// rules that match any long hex or base64 run, and so fire on checksums
+var hashRules = map[string]bool{
+	"High Entropy String":  true,
+	"GitHub Classic Token": true,
+	"Generic API Key":      true,
+}
+
+// drops hash-like findings on the checksum lines of known lockfiles; other
+// findings in lockfiles, such as tokens in registry URLs, are kept
+func (s *Scanner) dropLockfileHashes(filePath, content string, issues []Issue) []Issue {
+	if len(issues) == 0 {
+		return issues
+	}
+	ignore := s.lockfileIgnoreFor(filePath)
+	if ignore == nil {
+		return issues
+	}
+
+	lines := splitLines(content)
+	kept := issues[:0]
+	for _, issue := range issues {
+		if issue.Line >= 1 && issue.Line <= len(lines) && ignore.drops(issue.Rule, lines[issue.Line-1]) {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
+
+// returns the checksum lines to ignore in a file, or nil when it is not a
+// known lockfile or lockfile_ignores are off
+func (s *Scanner) lockfileIgnoreFor(filePath string) *lockfileIgnore {
+	cfg := s.config.LockfileIgnores
+	if !cfg.Enabled {
+		return nil
+	}
+
+	base := path.Base(filepath.ToSlash(filePath))
+	var ignore *lockfileIgnore
+	for i := range defaultLockfileIgnores {
+		candidate := &defaultLockfileIgnores[i]
+		if containsFold(cfg.Except, candidate.ecosystem) {
+			continue
+		}
+		for _, name := range candidate.files {
+			if base == name {
+				ignore = candidate
+			}
+		}
+	}
+	return ignore
+}
+
+// reports whether a finding of rule on line is a checksum
+func (l *lockfileIgnore) drops(rule, line string) bool {
+	return l != nil && hashRules[rule] && l.lines.MatchString(line)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
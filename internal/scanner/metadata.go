@@ -0,0 +1,576 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// formats whose metadata is scanned, by extension
+var metadataFormats = map[string]func([]byte) []metadataField{
+	".jpg":  jpegMetadata,
+	".jpeg": jpegMetadata,
+	".png":  pngMetadata,
+	".webp": webpMetadata,
+	".tif":  tiffMetadata,
+	".tiff": tiffMetadata,
+	".pdf":  pdfMetadata,
+	".docx": officeMetadata,
+	".xlsx": officeMetadata,
+	".pptx": officeMetadata,
+	".odt":  officeMetadata,
+	".ods":  officeMetadata,
+	".odp":  officeMetadata,
+}
+
+// the parts of an Office Open XML or OpenDocument file holding its
+// properties
+var officeMetadataParts = map[string]bool{
+	"docProps/core.xml":   true,
+	"docProps/app.xml":    true,
+	"docProps/custom.xml": true,
+	"meta.xml":            true,
+}
+
+// one named value from a file's metadata, e.g. the EXIF Artist
+type metadataField struct {
+	name  string
+	value string
+}
+
+// reports whether a file is an image or document whose metadata can be
+// scanned
+func isMetadataFile(filePath string) bool {
+	_, ok := metadataFormats[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// returns the metadata of an image or document as "name: value" lines for
+// the detectors, followed with "documents" enabled by the text of PDF and
+// Office documents, or why the file is skipped: such files are binary, so
+// with both off, or nothing to read, they are skipped as such
+func (s *Scanner) readMetadata(filePath string, data []byte) (string, string) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	extractText := documentFormats[ext]
+	if !s.config.Documents.Enabled {
+		extractText = nil
+	}
+	if !s.config.Metadata.Enabled && extractText == nil {
+		return "", SkipBinary
+	}
+
+	var fields []metadataField
+	var text string
+	func() {
+		// a malformed file must not crash the scan
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Debug("unreadable metadata", "file", filePath)
+				fields, text = nil, ""
+			}
+		}()
+		if s.config.Metadata.Enabled {
+			fields = metadataFormats[ext](data)
+		}
+		if extractText != nil {
+			limit := int(s.config.Documents.MaxTextSize)
+			if limit <= 0 {
+				limit = defaultDocumentTextSize
+			}
+			text = extractText(data, limit)
+			if len(text) >= limit {
+				s.logger.Debug("document text truncated", "file", filePath, "bytes", limit)
+			}
+		}
+	}()
+
+	var b strings.Builder
+	for _, f := range fields {
+		value := strings.Join(strings.Fields(strings.ToValidUTF8(f.value, "")), " ")
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f.name, value)
+	}
+	if text = strings.ToValidUTF8(text, ""); strings.TrimSpace(text) != "" {
+		b.WriteString(documentTextMarker + "\n" + text)
+	}
+	if b.Len() == 0 {
+		return "", SkipBinary
+	}
+	return b.String(), ""
+}
+
+// EXIF in an APP1 segment, XMP in another, and COM comments, all before
+// the image data starts at SOS
+func jpegMetadata(data []byte) []metadataField {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	var fields []metadataField
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[pos+4 : end]
+
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			fields = append(fields, tiffMetadata(segment[6:])...)
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte(xmpNamespace+"\x00")):
+			fields = append(fields, xmlMetadata("XMP", segment[len(xmpNamespace)+1:])...)
+		case marker == 0xFE:
+			fields = append(fields, metadataField{"Comment", string(segment)})
+		}
+		pos = end
+	}
+	return fields
+}
+
+const xmpNamespace = "http://ns.adobe.com/xap/1.0/"
+
+// tEXt, zTXt and iTXt chunks, whose keywords name the values, XMP in
+// iTXt, and EXIF in eXIf
+func pngMetadata(data []byte) []metadataField {
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return nil
+	}
+	var fields []metadataField
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if length < 0 || end+4 > len(data) || kind == "IEND" {
+			break
+		}
+		chunk := data[pos+8 : end]
+		pos = end + 4 // CRC
+
+		keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+		switch kind {
+		case "tEXt":
+			if ok {
+				fields = append(fields, metadataField{string(keyword), latin1(rest)})
+			}
+		case "zTXt":
+			if ok && len(rest) > 1 {
+				fields = append(fields, metadataField{string(keyword), latin1(inflate(rest[1:]))})
+			}
+		case "iTXt":
+			// compression flag and method, then language and translated
+			// keyword, each NUL-terminated
+			if !ok || len(rest) < 2 {
+				continue
+			}
+			compressed := rest[0] == 1
+			_, rest, _ = bytes.Cut(rest[2:], []byte{0})
+			_, text, _ := bytes.Cut(rest, []byte{0})
+			if compressed {
+				text = inflate(text)
+			}
+			if string(keyword) == "XML:com.adobe.xmp" {
+				fields = append(fields, xmlMetadata("XMP", text)...)
+			} else {
+				fields = append(fields, metadataField{string(keyword), string(text)})
+			}
+		case "eXIf":
+			fields = append(fields, tiffMetadata(chunk)...)
+		}
+	}
+	return fields
+}
+
+// EXIF and XMP chunks of a RIFF WebP file
+func webpMetadata(data []byte) []metadataField {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	var fields []metadataField
+	for pos := 12; pos+8 <= len(data); {
+		kind := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		chunk := data[pos+8 : end]
+		switch kind {
+		case "EXIF":
+			fields = append(fields, tiffMetadata(bytes.TrimPrefix(chunk, []byte("Exif\x00\x00")))...)
+		case "XMP ":
+			fields = append(fields, xmlMetadata("XMP", chunk)...)
+		}
+		pos = end + length%2
+	}
+	return fields
+}
+
+// names of the TIFF and EXIF tags that hold text people write
+var tiffTags = map[uint16]string{
+	0x010D: "DocumentName",
+	0x010E: "ImageDescription",
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0131: "Software",
+	0x013B: "Artist",
+	0x013C: "HostComputer",
+	0x8298: "Copyright",
+	0x9286: "UserComment",
+	0xA430: "CameraOwnerName",
+	0xA431: "BodySerialNumber",
+	0x9C9B: "XPTitle",
+	0x9C9C: "XPComment",
+	0x9C9D: "XPAuthor",
+	0x9C9E: "XPKeywords",
+	0x9C9F: "XPSubject",
+}
+
+// the text tags of IFD0 and the EXIF IFD of a TIFF structure, which is
+// also how EXIF is stored in JPEG, PNG and WebP
+func tiffMetadata(data []byte) []metadataField {
+	if len(data) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil
+	}
+
+	var fields []metadataField
+	visited := make(map[uint32]bool)
+	var walk func(offset uint32)
+	walk = func(offset uint32) {
+		if visited[offset] || int(offset)+2 > len(data) {
+			return
+		}
+		visited[offset] = true
+		count := int(order.Uint16(data[offset:]))
+		for i := 0; i < count; i++ {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(data) {
+				return
+			}
+			tag := order.Uint16(data[entry:])
+			kind := order.Uint16(data[entry+2:])
+			n := order.Uint32(data[entry+4:])
+
+			if tag == 0x8769 { // EXIF IFD
+				walk(order.Uint32(data[entry+8:]))
+				continue
+			}
+			name, ok := tiffTags[tag]
+			if !ok || (kind != 1 && kind != 2 && kind != 7) || n > uint32(len(data)) {
+				continue
+			}
+			value := data[entry+8 : entry+12]
+			if n > 4 {
+				start := order.Uint32(data[entry+8:])
+				if uint64(start)+uint64(n) > uint64(len(data)) {
+					continue
+				}
+				value = data[start : start+n]
+			} else {
+				value = value[:n]
+			}
+
+			switch {
+			case tag >= 0x9C9B && tag <= 0x9C9F:
+				value = []byte(utf16LE(value))
+			case tag == 0x9286 && len(value) >= 8:
+				// an 8-byte character code, then the comment
+				if bytes.HasPrefix(value, []byte("UNICODE\x00")) {
+					value = []byte(utf16LE(value[8:]))
+				} else {
+					value = value[8:]
+				}
+			}
+			fields = append(fields, metadataField{name, string(bytes.TrimRight(value, "\x00 "))})
+		}
+	}
+	walk(order.Uint32(data[4:]))
+	return fields
+}
+
+// the entries of PDF Info dictionaries, and the XMP packet when it is not
+// compressed; Info dictionaries inside compressed object streams are not
+// reached
+var pdfInfoEntry = regexp.MustCompile(`/(Title|Author|Subject|Keywords|Creator|Producer)\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+
+func pdfMetadata(data []byte) []metadataField {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil
+	}
+	var fields []metadataField
+	for _, m := range pdfInfoEntry.FindAllSubmatch(data, -1) {
+		fields = append(fields, metadataField{string(m[1]), pdfString(m[2])})
+	}
+	if start := bytes.Index(data, []byte("<x:xmpmeta")); start >= 0 {
+		if end := bytes.Index(data[start:], []byte("</x:xmpmeta>")); end >= 0 {
+			fields = append(fields, xmlMetadata("XMP", data[start:start+end+len("</x:xmpmeta>")])...)
+		}
+	}
+	return fields
+}
+
+// decodes a PDF literal "(...)" or hex "<...>" string; UTF-16 strings
+// start with a byte order mark
+func pdfString(token []byte) string {
+	var raw []byte
+	if token[0] == '<' {
+		digits := bytes.Join(bytes.Fields(token[1:len(token)-1]), nil)
+		if len(digits)%2 == 1 {
+			digits = append(digits, '0')
+		}
+		decoded, err := hex.DecodeString(string(digits))
+		if err != nil {
+			return ""
+		}
+		raw = decoded
+	} else {
+		body := token[1 : len(token)-1]
+		for i := 0; i < len(body); i++ {
+			if body[i] == '\\' && i+1 < len(body) {
+				i++
+				switch body[i] {
+				case 'n':
+					raw = append(raw, '\n')
+				case 'r':
+					raw = append(raw, '\r')
+				case 't':
+					raw = append(raw, '\t')
+				case '\r', '\n':
+					// a line continuation
+				case '0', '1', '2', '3', '4', '5', '6', '7':
+					// up to three octal digits
+					c := body[i] - '0'
+					for j := 0; j < 2 && i+1 < len(body) && body[i+1] >= '0' && body[i+1] <= '7'; j++ {
+						i++
+						c = c*8 + body[i] - '0'
+					}
+					raw = append(raw, c)
+				default:
+					raw = append(raw, body[i])
+				}
+				continue
+			}
+			raw = append(raw, body[i])
+		}
+	}
+	if bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, binary.BigEndian.Uint16(raw[i:]))
+		}
+		return string(utf16.Decode(units))
+	}
+	return latin1(raw)
+}
+
+// the property parts of an Office Open XML (docx, xlsx, pptx) or
+// OpenDocument (odt, ods, odp) zip, such as the author, title, company
+// and custom properties
+func officeMetadata(data []byte) []metadataField {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var fields []metadataField
+	for _, f := range zr.File {
+		if !officeMetadataParts[f.Name] || f.UncompressedSize64 > 1<<20 {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		part, err := io.ReadAll(io.LimitReader(rc, 1<<20))
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		fields = append(fields, xmlMetadata(strings.TrimSuffix(filepath.Base(f.Name), ".xml"), part)...)
+	}
+	return fields
+}
+
+// the text of every element and attribute of an XML metadata document,
+// named after the element, leaving out the identifiers XMP is full of
+// (document and instance IDs), which look random but are not secrets
+func xmlMetadata(prefix string, data []byte) []metadataField {
+	var fields []metadataField
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || isMetadataID(attr.Name.Local, attr.Value) {
+					continue
+				}
+				fields = append(fields, metadataField{prefix + " " + attr.Name.Local, attr.Value})
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" || len(stack) == 0 {
+				continue
+			}
+			name := stack[len(stack)-1]
+			// rdf:li items are named after the property holding them
+			for i := len(stack) - 1; i > 0 && (name == "li" || name == "Alt" || name == "Seq" || name == "Bag"); i-- {
+				name = stack[i-1]
+			}
+			if !isMetadataID(name, text) {
+				fields = append(fields, metadataField{prefix + " " + name, text})
+			}
+		}
+	}
+	return fields
+}
+
+func isMetadataID(name, value string) bool {
+	if strings.HasSuffix(name, "ID") || name == "about" || name == "documentID" {
+		return true
+	}
+	for _, prefix := range []string{"xmp.iid:", "xmp.did:", "uuid:", "adobe:docid:"} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decompresses zlib data, keeping what was read before any error, up to
+// a limit that keeps a crafted chunk from expanding without bound
+func inflate(data []byte) []byte {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	out, _ := io.ReadAll(io.LimitReader(r, 1<<20))
+	return out
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func utf16LE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(b[i:]))
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
+
+// URLs whose host only resolves inside an organization
+var metadataURL = regexp.MustCompile(`(?i)\b(?:https?|ftp|smb|file)://([^/\s:?#"'<>]+)`)
+
+// top-level domains that are not on the public internet
+var internalSuffixes = []string{".local", ".internal", ".corp", ".lan", ".intranet", ".intra", ".private", ".home.arpa", ".localdomain"}
+
+// reports internal URLs found in image and document metadata, which
+// reveal network layout when the files are published
+type metadataDetector struct {
+	s *Scanner
+}
+
+func (d metadataDetector) Name() string   { return "metadata" }
+func (d metadataDetector) Type() ScanType { return ScanTypeSecrets }
+
+func (d metadataDetector) Detect(root, filePath, content string) []Issue {
+	if !isMetadataFile(filePath) {
+		return nil
+	}
+	var issues []Issue
+	for lineNum, line := range splitLines(content) {
+		if line == documentTextMarker {
+			break
+		}
+		field, _, _ := strings.Cut(line, ": ")
+		for _, m := range metadataURL.FindAllStringSubmatchIndex(line, -1) {
+			if !isInternalHost(line[m[2]:m[3]]) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Type:        "metadata",
+				Severity:    "low",
+				File:        filePath,
+				Line:        lineNum + 1,
+				Column:      column(line, m[0]),
+				Description: fmt.Sprintf("Internal URL in file metadata (%s)", field),
+				Content:     d.s.plaintext(line[m[0]:m[1]]),
+				Rule:        "Internal URL in Metadata",
+				Timestamp:   time.Now(),
+			})
+		}
+	}
+	return issues
+}
+
+// reports whether a URL host is a private address, localhost, a name under
+// an internal suffix or a bare intranet name such as "wiki"
+func isInternalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return host != ""
+	}
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
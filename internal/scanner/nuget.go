@@ -0,0 +1,160 @@
+package scanner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reports whether a file is an MSBuild project that may hold
+// PackageReference items
+func isProjectFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csproj", ".fsproj", ".vbproj":
+		return true
+	}
+	return false
+}
+
+// parses a NuGet packages.config
+func (s *Scanner) parsePackagesConfig(content, filePath string) ([]Dependency, error) {
+	var deps []Dependency
+	var config struct {
+		Packages []struct {
+			ID      string `xml:"id,attr"`
+			Version string `xml:"version,attr"`
+		} `xml:"package"`
+	}
+
+	if err := xml.Unmarshal([]byte(content), &config); err != nil {
+		return deps, err
+	}
+
+	for _, pkg := range config.Packages {
+		if pkg.ID == "" || pkg.Version == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Name:      pkg.ID,
+			Version:   pkg.Version,
+			Ecosystem: "NuGet",
+			File:      filePath,
+		})
+	}
+
+	return deps, nil
+}
+
+// parses the PackageReference items of an SDK-style project, and the
+// PackageVersion items of central package management
+// (Directory.Packages.props); versions may be attributes or child elements
+func (s *Scanner) parseProjectFile(content, filePath string) ([]Dependency, error) {
+	var deps []Dependency
+
+	type packageItem struct {
+		Include        string `xml:"Include,attr"`
+		Update         string `xml:"Update,attr"`
+		Version        string `xml:"Version,attr"`
+		VersionElement string `xml:"Version"`
+	}
+	var project struct {
+		ItemGroups []struct {
+			References []packageItem `xml:"PackageReference"`
+			Versions   []packageItem `xml:"PackageVersion"`
+		} `xml:"ItemGroup"`
+	}
+
+	if err := xml.Unmarshal([]byte(content), &project); err != nil {
+		return deps, err
+	}
+
+	for _, group := range project.ItemGroups {
+		for _, item := range append(group.References, group.Versions...) {
+			name := item.Include
+			if name == "" {
+				name = item.Update
+			}
+			version := nugetVersion(item.Version)
+			if version == "" {
+				version = nugetVersion(item.VersionElement)
+			}
+			// versions set centrally or by MSBuild properties are unknown here
+			if name == "" || version == "" {
+				continue
+			}
+			deps = append(deps, Dependency{
+				Name:      name,
+				Version:   version,
+				Ecosystem: "NuGet",
+				File:      filePath,
+			})
+		}
+	}
+
+	return deps, nil
+}
+
+// parses a NuGet packages.lock.json; packages resolved for several target
+// frameworks are listed once per version
+func (s *Scanner) parseNuGetLock(content, filePath string) ([]Dependency, error) {
+	var deps []Dependency
+	var lock struct {
+		Dependencies map[string]map[string]struct {
+			Type     string `json:"type"`
+			Resolved string `json:"resolved"`
+		} `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return deps, err
+	}
+
+	seen := make(map[string]bool)
+	for _, packages := range lock.Dependencies {
+		for name, pkg := range packages {
+			// project references are built from source, not restored
+			if pkg.Type == "Project" || pkg.Resolved == "" {
+				continue
+			}
+			key := name + "@" + pkg.Resolved
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			deps = append(deps, Dependency{
+				Name:      name,
+				Version:   pkg.Resolved,
+				Ecosystem: "NuGet",
+				File:      filePath,
+			})
+		}
+	}
+
+	// map iteration order would otherwise reorder the findings on every run
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].Name+"@"+deps[i].Version < deps[j].Name+"@"+deps[j].Version
+	})
+	return deps, nil
+}
+
+// reduces a NuGet version or range to the version it pins: "1.2.3",
+// "[1.2.3]" and "[1.2.3, 2.0)" give 1.2.3; floating versions ("1.2.*")
+// and property references ("$(Version)") give nothing
+func nugetVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" || strings.ContainsAny(version, "*$") {
+		return ""
+	}
+	if strings.HasPrefix(version, "[") || strings.HasPrefix(version, "(") {
+		lower, _, _ := strings.Cut(version[1:], ",")
+		lower = strings.TrimSpace(strings.TrimRight(lower, "])"))
+		if lower == "" || strings.HasPrefix(version, "(") {
+			// no inclusive lower bound to check
+			return ""
+		}
+		return lower
+	}
+	return version
+}
@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// shapes of values that stand in for a secret in docs, templates and
+// sample configuration. Every pattern is anchored, so a real secret that
+// merely contains "%s" or "xxxxxx" somewhere is not taken for one.
+var placeholderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^<[^<>]+>$`),                                      // <YOUR_API_KEY>
+	regexp.MustCompile(`^\$\{[^{}]+\}$`),                                  // ${API_KEY}
+	regexp.MustCompile(`^\$\([^()]+\)$`),                                  // $(cat key.txt)
+	regexp.MustCompile(`^\$[A-Z_][A-Z0-9_]*$`),                            // $API_KEY; $Xk29dLq7 may be a real password
+	regexp.MustCompile(`^\{\{.+\}\}$`),                                    // {{ api_key }}
+	regexp.MustCompile(`^%[A-Za-z_][A-Za-z0-9_]*%$`),                      // %API_KEY%
+	regexp.MustCompile(`^\{[A-Za-z0-9_]*\}$`),                             // {api_key}, {0}
+	regexp.MustCompile(`^%[sqv]$`),                                        // %s in format strings
+	regexp.MustCompile(`(?i)^(?:[a-z0-9]+[-_.])*(?:x{6,}|\*{6,}|#{6,})$`), // sk_live_xxxxxxxx
+	regexp.MustCompile(`(?i)^(?:change|replace)[-_. ]?me$`),               // changeme
+	regexp.MustCompile(`(?i)^(?:[a-z]+[-_. ])*(?:placeholder|redacted|dummy)(?:[-_. ][a-z]+)*$`),
+	regexp.MustCompile(`(?i)^(?:your|my)[-_. ]?[-_. a-z]*(?:key|token|secret|password)(?:[-_. ]?here)?$`),
+	regexp.MustCompile(`(?i)^[-_. a-z]*(?:key|token|secret|password)[-_. ]?here$`),
+}
+
+// reports whether a matched secret is clearly a placeholder rather than a
+// real credential
+func (s *Scanner) isPlaceholder(secret string) bool {
+	cfg := &s.config.Placeholders
+	if !cfg.Enabled {
+		return false
+	}
+	if repeatsOneChar(secret) {
+		return true
+	}
+	for _, re := range placeholderPatterns {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return cfg.MatchesPattern(secret)
+}
+
+// reports an issue whose secret is a placeholder as low, or suppresses it
+// when the configured action is suppress; a heuristic can be wrong, so by
+// default the finding stays visible
+func (s *Scanner) markPlaceholder(issue *Issue) {
+	if s.config.Placeholders.Action == "suppress" {
+		issue.SuppressedBy = "placeholder"
+		return
+	}
+	issue.Severity = "low"
+	issue.Description += " (likely placeholder)"
+}
+
+// reports whether a value is one character repeated, ignoring
+// separators, e.g. 00000000 or ****-****
+func repeatsOneChar(value string) bool {
+	value = strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(value)
+	if len(value) < 4 {
+		return false
+	}
+	return strings.Count(value, value[:1]) == len(value)
+}
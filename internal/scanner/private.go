@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// where the dependencies matching a private_packages entry are looked up:
+// an internal advisory endpoint, or nowhere
+type privateRoute struct {
+	packages *config.PrivatePackages
+	source   *OSVSource // nil skips the lookup
+}
+
+// builds a route for every private_packages entry; entries naming the same
+// endpoint and token share one source
+func newPrivateRoutes(cfg config.DependencyConfig) []privateRoute {
+	sources := make(map[string]*OSVSource)
+	var routes []privateRoute
+	for i := range cfg.PrivatePackages {
+		packages := &cfg.PrivatePackages[i]
+		route := privateRoute{packages: packages}
+		if packages.Advisories != "" {
+			key := packages.Advisories + "\x00" + packages.Token
+			source, ok := sources[key]
+			if !ok {
+				source = NewOSVSource()
+				source.Endpoint = packages.Advisories
+				source.Token = packages.Token
+				if cfg.OSVWorkers > 0 {
+					source.Workers = cfg.OSVWorkers
+				}
+				sources[key] = source
+			}
+			route.source = source
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// splits dependencies into those for the public sources and those of
+// private packages, by the endpoint they are looked up in; the first
+// private_packages entry a dependency matches decides, and dependencies
+// whose entry has no endpoint are dropped
+func (s *Scanner) routeDependencies(deps []Dependency) ([]Dependency, map[*OSVSource][]Dependency) {
+	var public []Dependency
+	private := make(map[*OSVSource][]Dependency)
+	skipped := 0
+	for _, dep := range deps {
+		route, ok := s.privateRoute(dep)
+		switch {
+		case !ok:
+			public = append(public, dep)
+		case route.source == nil:
+			skipped++
+		default:
+			private[route.source] = append(private[route.source], dep)
+		}
+	}
+	if skipped > 0 {
+		s.logger.Debug("skipping vulnerability lookup of private packages", "packages", skipped)
+	}
+	return public, private
+}
+
+func (s *Scanner) privateRoute(dep Dependency) (privateRoute, bool) {
+	for _, route := range s.private {
+		if route.packages.Matches(dep.Ecosystem, dep.Name) {
+			return route, true
+		}
+	}
+	return privateRoute{}, false
+}
+
+// looks private packages up in their endpoints, in private_packages order
+// so the results do not depend on map order
+func (s *Scanner) queryPrivate(ctx context.Context, private map[*OSVSource][]Dependency) ([]Vulnerability, error) {
+	var all []Vulnerability
+	var errs []string
+	done := make(map[*OSVSource]bool)
+	for _, route := range s.private {
+		deps, ok := private[route.source]
+		if !ok || done[route.source] {
+			continue
+		}
+		done[route.source] = true
+
+		vulns, err := route.source.Query(ctx, deps)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", route.source.Endpoint, err))
+		}
+		all = append(all, vulns...)
+	}
+	if len(errs) > 0 {
+		return all, fmt.Errorf("private advisory errors: %s", strings.Join(errs, "; "))
+	}
+	return all, nil
+}
+
+// caches the lookups of each private endpoint apart from osv.dev's, whose
+// answers for the same package names would be wrong
+func (s *Scanner) cachePrivate(backend cache.Backend, ttl time.Duration) {
+	for _, route := range s.private {
+		if route.source != nil && route.source.cache == nil {
+			namespace := "advisories-" + hashSecret(route.source.Endpoint)[:12]
+			route.source.SetCache(cache.NewStore(backend, namespace), ttl)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+)
+
+// how many of the slowest files a profile keeps
+const profileTopFiles = 20
+
+// where a scan spent its time, recorded when profiling is on
+type Profile struct {
+	Rules []RuleTiming `json:"rules"` // slowest first
+	Files []FileTiming `json:"files"` // the slowest files only
+}
+
+// time one secret rule spent matching, over every file
+type RuleTiming struct {
+	Rule     string        `json:"rule"`
+	Files    int           `json:"files"`
+	Matches  int           `json:"matches"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// time the per-file detectors spent on one file
+type FileTiming struct {
+	File     string        `json:"file"`
+	Bytes    int           `json:"bytes"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// accumulates a Profile across concurrent file scans
+type profiler struct {
+	mu    sync.Mutex
+	rules map[string]*RuleTiming
+	files []FileTiming
+}
+
+func newProfiler() *profiler {
+	return &profiler{rules: make(map[string]*RuleTiming)}
+}
+
+// records the time each rule took on one file; a nil profiler records
+// nothing, so callers need not check whether profiling is on
+func (p *profiler) recordRules(timings []RuleTiming) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, t := range timings {
+		r, ok := p.rules[t.Rule]
+		if !ok {
+			r = &RuleTiming{Rule: t.Rule}
+			p.rules[t.Rule] = r
+		}
+		r.Files++
+		r.Matches += t.Matches
+		r.Duration += t.Duration
+	}
+}
+
+func (p *profiler) recordFile(file string, bytes int, elapsed time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files = append(p.files, FileTiming{File: file, Bytes: bytes, Duration: elapsed})
+}
+
+func (p *profiler) profile() *Profile {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	profile := &Profile{}
+	for _, r := range p.rules {
+		profile.Rules = append(profile.Rules, *r)
+	}
+	sort.Slice(profile.Rules, func(i, j int) bool {
+		if profile.Rules[i].Duration != profile.Rules[j].Duration {
+			return profile.Rules[i].Duration > profile.Rules[j].Duration
+		}
+		return profile.Rules[i].Rule < profile.Rules[j].Rule
+	})
+
+	profile.Files = append(profile.Files, p.files...)
+	sort.Slice(profile.Files, func(i, j int) bool {
+		return profile.Files[i].Duration > profile.Files[j].Duration
+	})
+	if len(profile.Files) > profileTopFiles {
+		profile.Files = profile.Files[:profileTopFiles]
+	}
+	return profile
+}
+
+// prints the top offenders among rules and files, with each one's share
+// of the total time
+func (p *Profile) Write(w io.Writer, top int) {
+	var total time.Duration
+	for _, r := range p.Rules {
+		total += r.Duration
+	}
+
+	fmt.Fprintf(w, "Slowest rules (of %s matching):\n", ascii.Label(w, total.Round(time.Microsecond).String()))
+	for i, r := range p.Rules {
+		if i == top {
+			break
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(r.Duration) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "  %10s %5.1f%%  %-40s %d files, %d matches\n",
+			ascii.Label(w, r.Duration.Round(time.Microsecond).String()), share, r.Rule, r.Files, r.Matches)
+	}
+
+	fmt.Fprintf(w, "Slowest files:\n")
+	for i, f := range p.Files {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(w, "  %10s  %s (%d bytes)\n", ascii.Label(w, f.Duration.Round(time.Microsecond).String()), f.File, f.Bytes)
+	}
+}
@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// one ref update of a push, as a pre-receive hook reads it from stdin
+type RefUpdate struct {
+	OldRev string
+	NewRev string
+	Ref    string
+}
+
+// reports whether a revision is git's all-zero null object id, which
+// stands for a ref that does not exist yet or is being deleted
+func isNullRev(rev string) bool {
+	return strings.Trim(rev, "0") == ""
+}
+
+// reads "<old> <new> <ref>" lines until EOF
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ref update %q: expected <old> <new> <ref>", line)
+		}
+		updates = append(updates, RefUpdate{OldRev: fields[0], NewRev: fields[1], Ref: fields[2]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ref updates: %w", err)
+	}
+	return updates, nil
+}
+
+// scans the diff of every commit a push introduces. Updated refs are
+// scanned from their old to their new revision; new refs are scanned up to
+// the commits already reachable from an existing ref, which in a
+// pre-receive hook are the ones the server accepted earlier. Deleted refs
+// bring no commits. Binaries the commits add, and with signature checks
+// enabled commits that are not signed by an allowed key, are reported too.
+// A scan cut short by ctx skips those checks and is marked Incomplete.
+func (s *Scanner) ScanPushRange(ctx context.Context, repoPath string, updates []RefUpdate, scanType ScanType) (*Results, error) {
+	results, err := s.scanUpdates(ctx, repoPath, updateRevs(updates, "--all"), scanType)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.Signatures.Enabled && ctx.Err() == nil {
+		unsigned, err := s.CheckSignatures(ctx, repoPath, updates)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		results.addIssues(unsigned...)
+	}
+
+	results.Incomplete = ctx.Err() != nil
+	s.dedupe(results, "")
+	return results, nil
+}
+
+// scans the lines a range of commits adds, as the client sees it before a
+// push: updated refs from their old to their new revision, new refs up to
+// the commits already on a remote. An old revision the repository does not
+// have, e.g. one someone else pushed since the last fetch, counts as a new
+// ref. Binaries the commits add are reported too.
+func (s *Scanner) ScanRange(ctx context.Context, repoPath string, updates []RefUpdate, scanType ScanType) (*Results, error) {
+	local := make([]RefUpdate, 0, len(updates))
+	for _, u := range updates {
+		if !isNullRev(u.OldRev) && !s.hasCommit(ctx, repoPath, u.OldRev) {
+			s.logger.Debug("old revision not in the repository, scanning up to the remotes", "rev", u.OldRev)
+			u.OldRev = ""
+		}
+		local = append(local, u)
+	}
+
+	results, err := s.scanUpdates(ctx, repoPath, updateRevs(local, "--remotes"), scanType)
+	if err != nil {
+		return nil, err
+	}
+	results.Incomplete = ctx.Err() != nil
+	s.dedupe(results, "")
+	return results, nil
+}
+
+// returns the git log arguments selecting the commits ref updates
+// introduce; commits reachable from known, e.g. --all or --remotes, bound
+// new refs. Deleted refs bring none.
+func updateRevs(updates []RefUpdate, known string) []string {
+	var revs []string
+	created := false
+	for _, u := range updates {
+		if isNullRev(u.NewRev) {
+			continue
+		}
+		revs = append(revs, u.NewRev)
+		if isNullRev(u.OldRev) {
+			created = true
+		} else {
+			revs = append(revs, "^"+u.OldRev)
+		}
+	}
+	if created {
+		revs = append(revs, "--not", known)
+	}
+	return revs
+}
+
+// scans the diffs of the commits revs select and, in full scans with
+// binaries enabled, the binaries they add
+func (s *Scanner) scanUpdates(ctx context.Context, repoPath string, revs []string, scanType ScanType) (*Results, error) {
+	if len(revs) == 0 {
+		// only deletions
+		return &Results{ScanTime: time.Now(), Duration: "0s", Issues: make([]Issue, 0)}, nil
+	}
+
+	results, err := s.scanLog(ctx, repoPath, scanType, revs)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.Binaries.Enabled && scanType == ScanTypeAll && !results.Incomplete {
+		binaries, err := s.checkAddedBinaries(ctx, repoPath, revs)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		results.addIssues(binaries...)
+	}
+	return results, nil
+}
+
+// reports whether the repository has a commit
+func (s *Scanner) hasCommit(ctx context.Context, repoPath, rev string) bool {
+	_, err := gitOutput(ctx, repoPath, "cat-file", "-e", rev+"^{commit}")
+	return err == nil
+}
+
+// parses "<old>..<new>", where an empty new side means HEAD, or a single
+// revision, which is scanned like a new ref
+func ParseRange(spec string) (RefUpdate, error) {
+	if strings.Contains(spec, "...") {
+		return RefUpdate{}, fmt.Errorf("invalid range %q: use <old>..<new>", spec)
+	}
+	oldRev, newRev, ok := strings.Cut(spec, "..")
+	if !ok {
+		oldRev, newRev = "", spec
+	} else if oldRev == "" {
+		return RefUpdate{}, fmt.Errorf("invalid range %q: missing the old revision", spec)
+	}
+	if newRev == "" {
+		newRev = "HEAD"
+	}
+	return RefUpdate{OldRev: oldRev, NewRev: newRev, Ref: spec}, nil
+}
+
+// reads the "<local ref> <local sha> <remote ref> <remote sha>" lines git
+// passes a pre-push hook on stdin, until EOF
+func ParsePrePushUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid push line %q: expected <local ref> <local sha> <remote ref> <remote sha>", line)
+		}
+		updates = append(updates, RefUpdate{OldRev: fields[3], NewRev: fields[1], Ref: fields[2]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read push lines: %w", err)
+	}
+	return updates, nil
+}
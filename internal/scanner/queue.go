@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// one pull request of a merge queue batch: the commits reachable from
+// Head but not from Base are its own
+type QueueEntry struct {
+	ID   string `json:"id"`
+	Head string `json:"head"`
+	Base string `json:"base,omitempty"`
+}
+
+// the findings in one entry's commits
+type QueueResult struct {
+	QueueEntry
+	Commits []string `json:"commits"`
+	Results *Results `json:"results"`
+}
+
+// reads a JSON list of entries, e.g. [{"id": "123", "head": "<sha>"}];
+// entries without a base get base
+func ParseQueueEntries(r io.Reader, base string) ([]QueueEntry, error) {
+	var entries []QueueEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse queue entries: %w", err)
+	}
+	for i := range entries {
+		if entries[i].Head == "" {
+			return nil, fmt.Errorf("queue entry %d has no head", i+1)
+		}
+		if entries[i].ID == "" {
+			entries[i].ID = entries[i].Head
+		}
+		if entries[i].Base == "" {
+			entries[i].Base = base
+		}
+	}
+	return entries, nil
+}
+
+// scans the commits of every entry of a merge queue batch in one pass per
+// base, so commits that several entries share, as stacked pull requests
+// do, are read and scanned once, and splits the findings by the entry
+// whose commits introduced them. A secret reused across entries counts as
+// reused, since the batch lands together.
+func (s *Scanner) ScanQueue(ctx context.Context, repoPath string, entries []QueueEntry, scanType ScanType) ([]QueueResult, error) {
+	var bases []string
+	groups := make(map[string][]int)
+	for i, entry := range entries {
+		if entry.Base == "" {
+			return nil, fmt.Errorf("queue entry %s has no base", entry.ID)
+		}
+		if _, ok := groups[entry.Base]; !ok {
+			bases = append(bases, entry.Base)
+		}
+		groups[entry.Base] = append(groups[entry.Base], i)
+	}
+
+	queued := make([]QueueResult, len(entries))
+	for _, base := range bases {
+		var updates []RefUpdate
+		for _, i := range groups[base] {
+			updates = append(updates, RefUpdate{OldRev: base, NewRev: entries[i].Head, Ref: entries[i].ID})
+		}
+		results, err := s.ScanPushRange(ctx, repoPath, updates, scanType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range groups[base] {
+			out, err := gitOutput(ctx, repoPath, "rev-list", entries[i].Head, "^"+base, "--")
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the commits of %s: %w", entries[i].ID, err)
+			}
+			commits := strings.Fields(string(out))
+			queued[i] = QueueResult{
+				QueueEntry: entries[i],
+				Commits:    commits,
+				Results:    results.forCommits(commits),
+			}
+		}
+	}
+	return queued, nil
+}
+
+// returns the part of history scan results found in the given commits
+func (r *Results) forCommits(commits []string) *Results {
+	in := make(map[string]bool, len(commits))
+	for _, commit := range commits {
+		in[commit] = true
+	}
+
+	part := &Results{
+		ScanTime:       r.ScanTime,
+		Duration:       r.Duration,
+		Issues:         make([]Issue, 0),
+		CommitsScanned: len(commits),
+		Incomplete:     r.Incomplete,
+		Deduplicated:   r.Deduplicated,
+	}
+	for _, issue := range r.Issues {
+		if in[issue.Commit] {
+			part.Issues = append(part.Issues, issue)
+		}
+	}
+	for _, issue := range r.Suppressed {
+		if in[issue.Commit] {
+			part.Suppressed = append(part.Suppressed, issue)
+		}
+	}
+
+	// a repeat of a secret first found in another part's commits is this
+	// part's finding
+	var repeats []Issue
+	for _, issue := range r.Duplicates {
+		if in[issue.Commit] {
+			repeats = append(repeats, issue)
+		}
+	}
+	if len(repeats) > 0 {
+		listed := make(map[string]bool)
+		for _, issue := range part.Issues {
+			listed[issue.ID] = true
+		}
+		var orphans []Issue
+		for _, issue := range repeats {
+			if listed[issue.DuplicateOf] {
+				part.Duplicates = append(part.Duplicates, issue)
+			} else {
+				issue.DuplicateOf = ""
+				orphans = append(orphans, issue)
+			}
+		}
+		var moved []Issue
+		orphans, moved = splitDuplicates(orphans, nil)
+		part.Issues = append(part.Issues, orphans...)
+		part.Duplicates = append(part.Duplicates, moved...)
+	}
+	part.Summary = calculateSummary(part.Issues)
+	return part
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// spaces requests evenly so that at most perSecond start each second;
+// shared by every worker of one query
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// a nil limiter, from a zero rate, never waits
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// blocks until the caller may send its next request, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(slot))
+}
+
+// waits for d to pass, returning early with ctx's error once it is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retries for throttled (429) and unavailable (5xx) responses
+const (
+	maxRetries   = 4
+	retryBackoff = time.Second
+)
+
+// posts a JSON body and returns the response body, retrying with
+// exponential backoff, or after the server's Retry-After, when throttled
+func postWithRetry(ctx context.Context, client *http.Client, url, token string, body []byte, limiter *rateLimiter) ([]byte, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if resp.StatusCode == http.StatusOK {
+			return data, nil
+		}
+		if !retryable || attempt == maxRetries {
+			return nil, fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		delay := backoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// finds secrets that appear in more than one file: each occurrence is
+// raised one severity level and lists every location of the secret, since
+// a widely copied credential is both likelier to leak and harder to rotate.
+// An occurrence marked before, as by the shard that found it, is not
+// raised again; it only gets the longer list.
+func markSecretReuse(issues []Issue) {
+	byHash := make(map[string][]int)
+	for i, issue := range issues {
+		if issue.Type == "secret" && issue.SecretHash != "" {
+			byHash[issue.SecretHash] = append(byHash[issue.SecretHash], i)
+		}
+	}
+
+	for _, indexes := range byHash {
+		files := make(map[string]bool)
+		var locations []string
+		for _, i := range indexes {
+			files[issues[i].File] = true
+			location := fmt.Sprintf("%s:%d", issues[i].File, issues[i].Line)
+			if issues[i].Commit != "" {
+				location += "@" + shortCommit(issues[i].Commit)
+			}
+			locations = append(locations, location)
+		}
+		if len(files) < 2 {
+			continue
+		}
+		sort.Strings(locations)
+
+		for _, i := range indexes {
+			if len(issues[i].Locations) == 0 {
+				issues[i].Severity = raiseSeverity(issues[i].Severity)
+			}
+			issues[i].Locations = append([]string(nil), locations...)
+		}
+	}
+}
+
+// gives every issue its ID, from its file relative to root when root is
+// set, and with deduplicate on moves repeats of a secret under the same
+// rule to Duplicates: the first occurrence by file, line and commit stays
+// in Issues, listing every location once they span files, and each repeat
+// points at it with DuplicateOf
+func (s *Scanner) dedupe(results *Results, root string) {
+	for _, issues := range [][]Issue{results.Issues, results.Suppressed} {
+		for i := range issues {
+			if issues[i].ID == "" {
+				issues[i].ID = issueID(issues[i], root)
+			}
+		}
+	}
+	if s.config.Deduplicate {
+		results.Issues, results.Duplicates = splitDuplicates(results.Issues, results.Duplicates)
+		results.Deduplicated = true
+	}
+	results.Summary = calculateSummary(results.Issues)
+}
+
+// returns the issues followed by the repeats dedupe moved out of them, for
+// outputs that point at every location of a finding, such as SARIF, GitHub
+// annotations and the findings store
+func (r *Results) Occurrences() []Issue {
+	if len(r.Duplicates) == 0 {
+		return r.Issues
+	}
+	return append(append(make([]Issue, 0, len(r.Issues)+len(r.Duplicates)), r.Issues...), r.Duplicates...)
+}
+
+// moves every occurrence of a secret but the first to duplicates, and
+// points the repeats already there at it, as when shards are merged
+func splitDuplicates(issues, duplicates []Issue) ([]Issue, []Issue) {
+	first := make(map[[2]string]int)
+	for i, issue := range issues {
+		if issue.Type != "secret" || issue.SecretHash == "" {
+			continue
+		}
+		key := [2]string{issue.Rule, issue.SecretHash}
+		if j, ok := first[key]; !ok || issueBefore(issue, issues[j]) {
+			first[key] = i
+		}
+	}
+
+	kept := make([]Issue, 0, len(issues))
+	for i, issue := range issues {
+		if j, ok := first[[2]string{issue.Rule, issue.SecretHash}]; ok && j != i && issue.Type == "secret" {
+			issue.DuplicateOf = issues[j].ID
+			duplicates = append(duplicates, issue)
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	for i, issue := range duplicates {
+		if j, ok := first[[2]string{issue.Rule, issue.SecretHash}]; ok {
+			duplicates[i].DuplicateOf = issues[j].ID
+		}
+	}
+	return kept, duplicates
+}
+
+// identifies an issue by its rule, file and what it reports; see
+// Issue.Fingerprint
+func issueID(issue Issue, root string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, issue.File); err == nil {
+			issue.File = rel
+		}
+	}
+	return issue.Fingerprint()
+}
+
+// orders occurrences of a secret by file, line, column and commit
+func issueBefore(a, b Issue) bool {
+	switch {
+	case a.File != b.File:
+		return a.File < b.File
+	case a.Line != b.Line:
+		return a.Line < b.Line
+	case a.Column != b.Column:
+		return a.Column < b.Column
+	default:
+		return a.Commit < b.Commit
+	}
+}
+
+func raiseSeverity(severity string) string {
+	switch severity {
+	case "low":
+		return "medium"
+	case "medium":
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
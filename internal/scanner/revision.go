@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// scans the tree of a commit, tag or branch straight from the object
+// database, without checking it out
+func (s *Scanner) ScanRevision(ctx context.Context, repoPath, rev string, scanType ScanType) (*Results, error) {
+	startTime := time.Now()
+
+	out, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s: %w", rev, err)
+	}
+	commit := strings.TrimSpace(string(out))
+
+	out, err = gitOutput(ctx, repoPath, "ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %w", rev, err)
+	}
+
+	var paths, specs []string
+	for _, record := range strings.Split(string(out), "\x00") {
+		// "<mode> <type> <object>\t<path>"
+		meta, path, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		if inSkippedDir(path) || !s.paths.allowFile(path) {
+			continue
+		}
+		if shouldScanFile(path) || isDependencyFile(path) {
+			paths = append(paths, path)
+			specs = append(specs, fields[2])
+		}
+	}
+
+	if s.shard.total > 1 {
+		keep := make(map[string]bool)
+		for _, path := range s.shard.filter(".", paths) {
+			keep[path] = true
+		}
+		var shardPaths, shardSpecs []string
+		for i, path := range paths {
+			if keep[path] {
+				shardPaths = append(shardPaths, path)
+				shardSpecs = append(shardSpecs, specs[i])
+			}
+		}
+		paths, specs = shardPaths, shardSpecs
+	}
+
+	blobs, err := readBlobs(ctx, repoPath, paths, specs, s.config.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	blobs = s.resolveLFSBlobs(ctx, repoPath, blobs)
+
+	results := s.scanFiles(ctx, "", blobPaths(blobs), s.blobReader(blobs), scanType, startTime)
+	results.Revision = commit
+	s.dedupe(results, "")
+	if s.shard.total > 0 {
+		results.Shard = s.shard.String()
+	}
+	return results, nil
+}
+
+// reports whether any directory of a slash-separated path is one a
+// directory walk would skip
+func inSkippedDir(path string) bool {
+	dirs := strings.Split(path, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if shouldSkipDir(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"math"
+	"time"
+)
+
+// the risk the findings of a scan pose, so a repository's posture can be
+// tracked over time
+type RiskScore struct {
+	Score  int     `json:"score"`  // 0, nothing found, to 100
+	Grade  string  `json:"grade"`  // A, the lowest risk, to F
+	Points float64 `json:"points"` // the weighted sum Score is scaled from
+}
+
+// what one finding adds to the risk, by severity
+var riskWeights = map[string]float64{
+	"critical": 10,
+	"high":     5,
+	"medium":   2,
+	"low":      1,
+}
+
+// the points at which the score reaches 63, about four critical findings
+const riskScale = 40
+
+// grades by the lowest score that earns them
+var riskGrades = []struct {
+	min   int
+	grade string
+}{
+	{75, "F"},
+	{50, "D"},
+	{25, "C"},
+	{10, "B"},
+	{0, "A"},
+}
+
+// weighs every issue by its severity; a secret verified live counts
+// double and one verified invalid a quarter, and a finding the findings
+// store first saw a while ago counts more the longer it stays, up to
+// three times at 90 days. The sum is scaled to 0-100, so the score rises
+// quickly with the first serious findings and approaches 100 slowly.
+func ScoreRisk(issues []Issue, now time.Time) RiskScore {
+	points := 0.0
+	for _, issue := range issues {
+		weight := riskWeights[issue.Severity]
+		switch issue.Verified {
+		case VerifiedLive:
+			weight *= 2
+		case VerifiedInvalid:
+			weight /= 4
+		}
+		if issue.FirstSeen != nil {
+			days := min(now.Sub(*issue.FirstSeen).Hours()/24, 90)
+			weight *= 1 + max(days, 0)/45
+		}
+		points += weight
+	}
+
+	risk := RiskScore{
+		Score:  int(math.Round(100 * (1 - math.Exp(-points/riskScale)))),
+		Points: math.Round(points*100) / 100,
+	}
+	for _, g := range riskGrades {
+		if risk.Score >= g.min {
+			risk.Grade = g.grade
+			break
+		}
+	}
+	return risk
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// a sample file for "gitguardian rules test": Expect is "positive" when
+// a rule under test must report something in it, "negative" when none may,
+// and "" when its matches are only listed
+type RuleSample struct {
+	Path    string
+	Content string
+	Expect  string
+}
+
+// what running rules over samples found
+type RuleTestReport struct {
+	Rules    []RuleTestRule   `json:"rules"`
+	Samples  []RuleTestSample `json:"samples"`
+	Overlaps []RuleOverlap    `json:"overlaps,omitempty"`
+	Examples []ExampleFailure `json:"example_failures,omitempty"`
+
+	// rules that do not compile or are unsafe, samples that fail their
+	// expectation and examples the rules get wrong
+	Failures int `json:"failures"`
+}
+
+// a rule under test and the samples it matches
+type RuleTestRule struct {
+	Name     string   `json:"name"`
+	Error    string   `json:"error,omitempty"`  // the pattern or its allowlist does not compile
+	Unsafe   []string `json:"unsafe,omitempty"` // the problems CheckPattern finds
+	Positive int      `json:"positive"`         // positive samples it reports something in
+	Negative int      `json:"negative"`         // negative samples it reports something in
+	Matches  int      `json:"matches"`
+}
+
+// a sample and every match in it
+type RuleTestSample struct {
+	Path    string          `json:"path"`
+	Expect  string          `json:"expect,omitempty"`
+	Matches []RuleTestMatch `json:"matches"`
+	Passed  bool            `json:"passed"`
+}
+
+type RuleTestMatch struct {
+	Rule         string `json:"rule"`
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+	Secret       string `json:"secret"` // masked
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+
+	// the rule is one of the configuration's, run for overlaps only
+	Configured bool `json:"configured,omitempty"`
+}
+
+// two rules reporting the same secret, or a secret at the same place: a
+// scan reports it twice, and one of the rules may be redundant
+type RuleOverlap struct {
+	Rules   [2]string `json:"rules"`
+	Count   int       `json:"count"`
+	Example string    `json:"example"` // path:line of the first
+}
+
+// compiles rules and runs them over samples the way a scan would
+// (keywords, secret group, entropy, allowlists, placeholders and the
+// whitelist all apply), along with the rules of cfg, which only count
+// toward overlaps. A match suppressed as a placeholder does not count, as
+// a scan would not report it. The rules' own examples are checked too.
+func RunRuleTests(cfg *config.Config, rules []config.SecretPattern, samples []RuleSample) *RuleTestReport {
+	report := &RuleTestReport{}
+
+	tested := make(map[string]bool)
+	var runnable []config.SecretPattern
+	index := make(map[string]int)
+	for _, rule := range rules {
+		result := RuleTestRule{Name: rule.Name}
+		if err := rule.Compile(); err != nil {
+			result.Error = err.Error()
+			report.Failures++
+		} else {
+			result.Unsafe = config.CheckPattern(rule.Pattern)
+			if len(result.Unsafe) > 0 && !cfg.AllowUnsafePatterns {
+				report.Failures++
+			}
+			runnable = append(runnable, rule)
+		}
+		tested[rule.Name] = true
+		index[rule.Name] = len(report.Rules)
+		report.Rules = append(report.Rules, result)
+	}
+
+	// configured rules of the same name are the rules under test, loaded
+	// through rule_files
+	patterns := append([]config.SecretPattern{}, runnable...)
+	for _, pattern := range cfg.SecretPatterns {
+		if !tested[pattern.Name] {
+			patterns = append(patterns, pattern)
+		}
+	}
+	single := *cfg
+	single.SecretPatterns = patterns
+	single.Verify.Enabled = false
+	single.MaxFindingsPerFile = 0 // every match counts
+	s := New(&single)
+
+	overlaps := make(map[[2]string]*RuleOverlap)
+	for _, sample := range samples {
+		issues := s.scanSecrets("", sample.Path, sample.Content)
+		result := RuleTestSample{Path: sample.Path, Expect: sample.Expect, Matches: []RuleTestMatch{}}
+		counted := make(map[string]bool)
+		for _, issue := range issues {
+			result.Matches = append(result.Matches, RuleTestMatch{
+				Rule:         issue.Rule,
+				Line:         issue.Line,
+				Column:       issue.Column,
+				Secret:       issue.Content,
+				SuppressedBy: issue.SuppressedBy,
+				Configured:   !tested[issue.Rule],
+			})
+			if !tested[issue.Rule] || issue.SuppressedBy != "" {
+				continue
+			}
+			rule := &report.Rules[index[issue.Rule]]
+			rule.Matches++
+			if !counted[issue.Rule] {
+				counted[issue.Rule] = true
+				switch sample.Expect {
+				case "positive":
+					rule.Positive++
+				case "negative":
+					rule.Negative++
+				}
+			}
+		}
+		switch sample.Expect {
+		case "positive":
+			result.Passed = len(counted) > 0
+		case "negative":
+			result.Passed = len(counted) == 0
+		default:
+			result.Passed = true
+		}
+		if !result.Passed {
+			report.Failures++
+		}
+		report.Samples = append(report.Samples, result)
+
+		for i := range issues {
+			for j := i + 1; j < len(issues); j++ {
+				a, b := issues[i], issues[j]
+				if a.Rule == b.Rule || a.Line != b.Line || (a.SecretHash != b.SecretHash && a.Column != b.Column) {
+					continue
+				}
+				if !tested[a.Rule] && !tested[b.Rule] {
+					continue
+				}
+				pair := [2]string{a.Rule, b.Rule}
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				overlap, ok := overlaps[pair]
+				if !ok {
+					overlap = &RuleOverlap{Rules: pair, Example: fmt.Sprintf("%s:%d", sample.Path, a.Line)}
+					overlaps[pair] = overlap
+				}
+				overlap.Count++
+			}
+		}
+	}
+	for _, overlap := range overlaps {
+		report.Overlaps = append(report.Overlaps, *overlap)
+	}
+	sort.Slice(report.Overlaps, func(i, j int) bool {
+		if report.Overlaps[i].Count != report.Overlaps[j].Count {
+			return report.Overlaps[i].Count > report.Overlaps[j].Count
+		}
+		return report.Overlaps[i].Rules[0]+report.Overlaps[i].Rules[1] < report.Overlaps[j].Rules[0]+report.Overlaps[j].Rules[1]
+	})
+
+	examples := *cfg
+	examples.SecretPatterns = runnable
+	report.Examples, _ = CheckExamples(&examples)
+	report.Failures += len(report.Examples)
+	return report
+}
@@ -0,0 +1,236 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SARIF 2.1.0 document, limited to the parts code scanning services read
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool       `json:"tool"`
+	Results    []sarifResult   `json:"results"`
+	Properties sarifRunSummary `json:"properties"`
+}
+
+// severity counts carried in the run property bag
+type sarifRunSummary struct {
+	Summary Summary `json:"summary"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name"`
+	ShortDescription     sarifMessage    `json:"shortDescription"`
+	Help                 *sarifMessage   `json:"help,omitempty"`
+	HelpURI              string          `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifRuleConfig `json:"defaultConfiguration"`
+	Properties           sarifProperties `json:"properties"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// outputs results in SARIF 2.1.0 format for code scanning upload
+func (r *Results) OutputSARIF(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "GitGuardian",
+			InformationURI: "https://github.com/JohnnyCannelloni/gitguardian",
+			Rules:          make([]sarifRule, 0),
+		}},
+		Results:    make([]sarifResult, 0, len(r.Issues)+len(r.Duplicates)),
+		Properties: sarifRunSummary{Summary: r.Summary},
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, issue := range r.Occurrences() {
+		id := sarifRuleID(issue)
+		if _, ok := ruleIndex[id]; !ok {
+			ruleIndex[id] = len(run.Tool.Driver.Rules)
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(id, issue))
+		}
+	}
+
+	for _, issue := range r.Occurrences() {
+		id := sarifRuleID(issue)
+		line := issue.Line
+		if line < 1 {
+			line = 1
+		}
+
+		result := sarifResult{
+			RuleID:    id,
+			RuleIndex: ruleIndex[id],
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: issue.Description},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(issue.File)},
+					Region:           sarifRegion{StartLine: line, StartColumn: issue.Column},
+				},
+			}},
+		}
+		if issue.SecretHash != "" {
+			result.PartialFingerprints = map[string]string{"secretHash/v1": issue.SecretHash}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// secret and social rules map from the rule name, vulnerabilities from the
+// advisory ID so each advisory becomes its own rule
+func sarifRuleID(issue Issue) string {
+	if issue.AdvisoryID != "" {
+		return issue.AdvisoryID
+	}
+	return issue.Rule
+}
+
+func sarifRuleFor(id string, issue Issue) sarifRule {
+	description := issue.Description
+	if issue.AdvisoryID == "" && issue.Type == "social" {
+		description = "Suspicious keyword detected"
+	}
+
+	tags := []string{"security", issue.Type}
+	if issue.Category != "" {
+		tags = append(tags, issue.Category)
+	}
+	tags = uniqueStrings(append(tags, issue.Tags...), "")
+
+	rule := sarifRule{
+		ID:                   id,
+		Name:                 sarifRuleName(id),
+		ShortDescription:     sarifMessage{Text: description},
+		DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(issue.Severity)},
+		Properties: sarifProperties{
+			Tags:             tags,
+			SecuritySeverity: sarifSecuritySeverity(issue.Severity),
+		},
+	}
+	if issue.Remediation != "" {
+		rule.Help = &sarifMessage{Text: issue.Remediation}
+	}
+	if len(issue.References) > 0 {
+		rule.HelpURI = issue.References[0]
+	}
+	return rule
+}
+
+// turns a rule ID such as "AWS Access Key" into "AwsAccessKey"
+func sarifRuleName(id string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(id, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// numeric severity GitHub code scanning uses to rank security alerts
+func sarifSecuritySeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "9.5"
+	case "high":
+		return "8.0"
+	case "medium":
+		return "5.5"
+	case "low":
+		return "2.0"
+	default:
+		return ""
+	}
+}
+
+// SARIF artifact URIs use forward slashes and are relative where possible
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := filepath.Abs("."); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
@@ -1,16 +1,26 @@
 package scanner
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
 	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
 )
 
 // defines what to scan for
@@ -25,7 +35,29 @@ const (
 
 // main security scanner
 type Scanner struct {
-	config *config.Config
+	config         *config.Config
+	paths          pathFilter
+	shard          shard
+	vulnSources    []VulnSource
+	private        []privateRoute // private_packages, by where they are looked up
+	feeds          []VulnSource   // advisory_feeds, checked for every dependency
+	detectors      []Detector
+	batchDetectors []BatchDetector
+	slots          chan struct{} // max_concurrency, shared by file scans and dependency parsing
+	reportSkipped  bool
+	verifier       *secretVerifier
+	whitelist      *whitelistCounter // counts for the scan in progress
+	profiling      bool
+	profile        *profiler // timings for the scan in progress, when profiling
+	progress       func(Progress)
+	results        *cache.Store // per-file findings of earlier scans
+	digest         string       // of the rules, keying the results cache
+	cacheHits      atomic.Int64
+	hashKey        []byte // of HashSecret, loaded on first use
+	hashKeyOnce    sync.Once
+	exploits       *exploitData // kev_file and epss_file, read on first use
+	exploitsOnce   sync.Once
+	logger         *slog.Logger
 }
 
 type Issue struct {
@@ -38,6 +70,65 @@ type Issue struct {
 	Content     string    `json:"content"`
 	Rule        string    `json:"rule"`
 	Timestamp   time.Time `json:"timestamp"`
+
+	// class of secret, from the rule's first tag: cloud, vcs, database...
+	Category string `json:"category,omitempty"`
+
+	// from the rule: its tags, how to rotate or revoke the credential,
+	// documentation on it, and who maintains the rule
+	Tags        []string `json:"tags,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+
+	// HMAC-SHA-256 of the matched secret with the secret hash key, so
+	// findings can be correlated without the plaintext ever leaving the
+	// process
+	SecretHash string `json:"secret_hash,omitempty"`
+
+	// the advisory behind a vulnerability issue, e.g. GHSA-xxxx-xxxx-xxxx
+	AdvisoryID string `json:"advisory_id,omitempty"`
+
+	// of a vulnerability issue: listed in the KEV catalog of kev_file, and
+	// its EPSS score from epss_file
+	KnownExploited bool    `json:"known_exploited,omitempty"`
+	EPSS           float64 `json:"epss,omitempty"`
+
+	// why the issue was suppressed, e.g. "inline" for a gitguardian:ignore
+	// comment; suppressed issues are listed in Results.Suppressed
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+
+	// result of checking the secret against its provider: live or invalid
+	Verified string `json:"verified,omitempty"`
+
+	// set when the findings store has never seen the issue before
+	New bool `json:"new,omitempty"`
+
+	// when the findings store first saw the issue; set when it is enabled
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+
+	// every place a reused secret was found, set when it is in several files
+	Locations []string `json:"locations,omitempty"`
+
+	// identifies the finding across scans by its rule, its file relative
+	// to the scanned directory and, for secrets, the secret hash; the one
+	// fingerprint the findings store, "gitguardian feedback" and the Go
+	// package use
+	ID string `json:"id,omitempty"`
+
+	// set on a repeat of a secret listed in Results.Duplicates: the ID of
+	// the occurrence reported in Results.Issues
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	// set when the issue was found in git history
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+
+	// the plaintext secret, held between detection and verification
+	secret string
+
+	// the matches a Finding Limit issue stands for
+	dropped int
 }
 
 type Results struct {
@@ -46,9 +137,67 @@ type Results struct {
 	FilesScanned int       `json:"files_scanned"`
 	Issues       []Issue   `json:"issues"`
 	Summary      Summary   `json:"summary"`
+
+	// set by history scans
+	CommitsScanned int `json:"commits_scanned,omitempty"`
+
+	// the commit whose tree was scanned, set by revision scans
+	Revision string `json:"revision,omitempty"`
+
+	// findings silenced by ignore comments, kept for auditing
+	Suppressed []Issue `json:"suppressed,omitempty"`
+
+	// further occurrences of secrets reported once in Issues
+	Duplicates []Issue `json:"duplicates,omitempty"`
+
+	// set when repeats were moved to Duplicates, so merged shards are
+	// deduplicated across each other too
+	Deduplicated bool `json:"deduplicated,omitempty"`
+
+	// findings on lines a diff-only scan saw unchanged, which it does not
+	// report
+	Unchanged int `json:"unchanged,omitempty"`
+
+	// the shard this scan covered, e.g. "3/8"
+	Shard string `json:"shard,omitempty"`
+
+	// per-detector timing metrics
+	Detectors []DetectorStats `json:"detectors,omitempty"`
+
+	// every dependency found in the scanned manifests
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+
+	// files left out of the scan and why, when requested
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+
+	// how many matches each whitelist entry suppressed
+	Whitelist []WhitelistStats `json:"whitelist,omitempty"`
+
+	// per-rule and per-file timings, when profiling
+	Profile *Profile `json:"profile,omitempty"`
+
+	// files whose findings came from the results cache
+	CachedFiles int `json:"cached_files,omitempty"`
+
+	// set when the scan was cancelled or timed out before it finished; the
+	// findings cover only what was scanned by then
+	Incomplete bool `json:"incomplete,omitempty"`
 }
 
 type Summary struct {
+	SeverityCounts
+
+	// the same counts split by issue type (secret, vulnerability, social...)
+	ByType map[string]SeverityCounts `json:"by_type,omitempty"`
+
+	// secret counts split by rule category (cloud, vcs, database...)
+	ByCategory map[string]SeverityCounts `json:"by_category,omitempty"`
+
+	// the findings weighed into one score
+	Risk RiskScore `json:"risk"`
+}
+
+type SeverityCounts struct {
 	Critical int `json:"critical"`
 	High     int `json:"high"`
 	Medium   int `json:"medium"`
@@ -56,47 +205,299 @@ type Summary struct {
 	Total    int `json:"total"`
 }
 
+func (c *SeverityCounts) add(severity string) {
+	switch severity {
+	case "critical":
+		c.Critical++
+	case "high":
+		c.High++
+	case "medium":
+		c.Medium++
+	case "low":
+		c.Low++
+	}
+	c.Total++
+}
+
 // creates a new scanner instance
 func New(cfg *config.Config) *Scanner {
-	return &Scanner{
-		config: cfg,
+	s := &Scanner{
+		config:      cfg,
+		vulnSources: NewVulnSources(cfg.DependencyAPIs),
+		private:     newPrivateRoutes(cfg.DependencyAPIs),
+		feeds:       newFeedSources(cfg.DependencyAPIs),
+		logger:      cfg.Log(),
+		paths:       pathFilter{include: cfg.IncludePaths, exclude: cfg.ExcludePaths},
+		slots:       make(chan struct{}, cfg.MaxConcurrency),
 	}
+
+	if cfg.Verify.Enabled {
+		s.verifier = newSecretVerifier(cfg.Verify, s.logger)
+	}
+
+	s.RegisterDetector(secretDetector{s})
+	s.RegisterDetector(entropyDetector{s})
+	s.RegisterDetector(socialDetector{s})
+	s.RegisterDetector(metadataDetector{s})
+	s.RegisterBatchDetector(dependencyDetector{s})
+	s.RegisterBatchDetector(imageDetector{s})
+
+	return s
+}
+
+// how far a scan has got, as reported to the function set by SetProgress
+type Progress struct {
+	Files  int // files scanned so far
+	Total  int // files to scan
+	Issues int // findings so far, before ignores and batch detectors
+}
+
+// calls fn as each file finishes scanning, from the scanning goroutines;
+// fn must be quick and safe for concurrent use
+func (s *Scanner) SetProgress(fn func(Progress)) {
+	s.progress = fn
 }
 
-// scans a directory
-func (s *Scanner) ScanPath(path string, scanType ScanType) (*Results, error) {
+// records how long each secret rule and each file takes, reported in
+// Results.Profile
+func (s *Scanner) SetProfiling(enabled bool) {
+	s.profiling = enabled
+}
+
+// replaces the vulnerability sources used for dependency checks
+func (s *Scanner) SetVulnSources(sources ...VulnSource) {
+	s.vulnSources = sources
+}
+
+// caches vulnerability lookups in the backend, for the sources that
+// support it, when the dependency cache is enabled in the configuration
+func (s *Scanner) SetCache(backend cache.Backend) {
+	deps := s.config.DependencyAPIs
+	if !deps.CacheEnabled {
+		return
+	}
+
+	ttl := time.Duration(deps.CacheDuration) * time.Hour
+	for _, source := range s.vulnSources {
+		if c, ok := source.(interface {
+			SetCache(*cache.Store, time.Duration)
+		}); ok {
+			c.SetCache(cache.NewStore(backend, source.Name()), ttl)
+		}
+	}
+	s.cachePrivate(backend, ttl)
+}
+
+// restricts scans to files matching the include globs and not matching
+// the exclude globs, relative to the scanned path; they are added to the
+// configuration's include_paths and exclude_paths
+func (s *Scanner) SetPathFilters(include, exclude []string) {
+	s.paths = pathFilter{
+		include: append(append([]string{}, s.config.IncludePaths...), include...),
+		exclude: append(append([]string{}, s.config.ExcludePaths...), exclude...),
+	}
+}
+
+// scans a directory; once ctx is done no more files are started and the
+// findings so far are returned, marked Incomplete
+func (s *Scanner) ScanPath(ctx context.Context, path string, scanType ScanType) (*Results, error) {
 	startTime := time.Now()
 
-	results := &Results{
-		ScanTime: startTime,
-		Issues:   make([]Issue, 0),
+	var skips *skipLog
+	read := s.fileReader(ctx)
+	if s.reportSkipped {
+		skips = newSkipLog(path)
+		read = func(filePath string) (string, bool) {
+			content, reason := s.loadFile(ctx, filePath)
+			if reason != "" {
+				skips.add(filePath, reason)
+			}
+			return content, reason == ""
+		}
 	}
 
 	// collect files to scan
-	files, err := s.collectFiles(path)
+	files, err := s.collectFiles(path, skips)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect files: %w", err)
 	}
 
-	results.FilesScanned = len(files)
+	files = s.shard.filter(path, files)
 
-	// scan files concurrently
-	issues := make(chan Issue, 100)
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, s.config.MaxConcurrency)
+	results := s.scanFiles(ctx, path, files, read, scanType, startTime)
+	if s.shard.total > 0 {
+		results.Shard = s.shard.String()
+	}
+	results.Skipped = skips.list()
+	s.dedupe(results, path)
+
+	if advice := s.gitignoreAdvice(path, results.Issues); len(advice) > 0 {
+		results.addIssues(advice...)
+		results.Summary = calculateSummary(results.Issues)
+	}
+
+	return results, nil
+}
+
+// returns the files ScanPath would scan, relative to path, after the path
+// filters and shard selection
+func (s *Scanner) ListFiles(path string) ([]string, error) {
+	files, err := s.collectFiles(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	files = s.shard.filter(path, files)
+	for i, file := range files {
+		if rel, err := filepath.Rel(path, file); err == nil {
+			files[i] = filepath.ToSlash(rel)
+		}
+	}
+	return files, nil
+}
 
+// scans the given files under root from disk, for callers that track
+// which files changed, such as watch mode
+func (s *Scanner) ScanFiles(ctx context.Context, root string, files []string, scanType ScanType) *Results {
+	results := s.scanFiles(ctx, root, files, s.fileReader(ctx), scanType, time.Now())
+	s.dedupe(results, root)
+	return results
+}
+
+// scans the files under root that the path filters allow, such as those
+// a working copy changed
+func (s *Scanner) ScanChangedFiles(ctx context.Context, root string, files []string, scanType ScanType) *Results {
+	var selected []string
 	for _, file := range files {
-		wg.Add(1)
-		go func(f string) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			fileIssues := s.scanFile(f, scanType)
-			for _, issue := range fileIssues {
-				issues <- issue
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+		if s.paths.allowFile(filepath.ToSlash(rel)) && (shouldScanFile(file) || isDependencyFile(file)) {
+			selected = append(selected, file)
+		}
+	}
+
+	results := s.scanFiles(ctx, root, selected, s.fileReader(ctx), scanType, time.Now())
+	s.dedupe(results, root)
+	return results
+}
+
+// an in-memory file, such as a staged blob
+type Blob struct {
+	Path    string
+	Content string
+}
+
+// scans in-memory files without touching the filesystem
+func (s *Scanner) ScanBlobs(ctx context.Context, blobs []Blob, scanType ScanType) *Results {
+	startTime := time.Now()
+
+	results := s.scanFiles(ctx, "", blobPaths(blobs), s.blobReader(blobs), scanType, startTime)
+	s.dedupe(results, "")
+	return results
+}
+
+// runs the enabled detectors over files whose content comes from read,
+// until ctx is done; root is that of a path scan, or ""
+func (s *Scanner) scanFiles(ctx context.Context, root string, files []string, read ReadFunc, scanType ScanType, startTime time.Time) *Results {
+	results := &Results{
+		ScanTime:     startTime,
+		Issues:       make([]Issue, 0),
+		FilesScanned: len(files),
+	}
+
+	metrics := newDetectorMetrics()
+	s.whitelist = newWhitelistCounter()
+	s.profile = nil
+	if s.profiling {
+		s.profile = newProfiler()
+	}
+
+	var detectors []Detector
+	for _, d := range s.detectors {
+		if s.detectorEnabled(d.Name(), d.Type(), scanType) {
+			detectors = append(detectors, d)
+		}
+	}
+	s.cacheHits.Store(0)
+	if s.results != nil {
+		s.digest = s.resultsDigest(detectors)
+	}
+
+	// whole-scan detectors run alongside the per-file scan
+	batchDone := make(chan BatchResult, len(s.batchDetectors))
+	batchCount := 0
+	var batchCut atomic.Bool
+	for _, d := range s.batchDetectors {
+		if !s.detectorEnabled(d.Name(), d.Type(), scanType) {
+			continue
+		}
+
+		var wanted []string
+		for _, file := range files {
+			if d.Wants(file) {
+				wanted = append(wanted, file)
+			}
+		}
+
+		batchCount++
+		go func(d BatchDetector, wanted []string) {
+			start := time.Now()
+			result := d.DetectFiles(ctx, wanted, read)
+			if ctx.Err() != nil {
+				batchCut.Store(true)
 			}
-		}(file)
+			metrics.record(d.Name(), len(wanted), len(result.Issues), time.Since(start))
+			batchDone <- result
+		}(d, wanted)
+	}
+
+	// scan files concurrently
+	issues := make(chan Issue, 100)
+	var wg sync.WaitGroup
+	var filesDone, issuesFound atomic.Int64
+
+	if len(detectors) > 0 {
+		for _, file := range files {
+			wg.Add(1)
+			go func(f string) {
+				defer wg.Done()
+
+				fileIssues, ok := func() ([]Issue, bool) {
+					s.slots <- struct{}{}
+					defer func() { <-s.slots }()
+
+					// a panicking detector must not crash the scan, and its
+					// panic value may hold file content, so it is never printed
+					defer func() {
+						if r := recover(); r != nil {
+							s.logger.Warn("detector failed", "file", f)
+							filesDone.Add(1)
+						}
+					}()
+
+					return s.scanFile(ctx, root, f, read, detectors, metrics)
+				}()
+				if !ok {
+					return
+				}
+				// with the slot given back, so files are matched while
+				// providers answer
+				s.verifySecrets(fileIssues)
+				for _, issue := range fileIssues {
+					issues <- issue
+				}
+				done := filesDone.Add(1)
+				if s.progress != nil {
+					s.progress(Progress{
+						Files:  int(done),
+						Total:  len(files),
+						Issues: int(issuesFound.Add(int64(len(fileIssues)))),
+					})
+				}
+			}(file)
+		}
 	}
 
 	// close issues channel when all scans complete
@@ -106,112 +507,265 @@ func (s *Scanner) ScanPath(path string, scanType ScanType) (*Results, error) {
 	}()
 
 	for issue := range issues {
-		results.Issues = append(results.Issues, issue)
+		results.addIssues(issue)
+	}
+
+	for i := 0; i < batchCount; i++ {
+		batch := <-batchDone
+		results.Dependencies = append(results.Dependencies, batch.Dependencies...)
+		s.maskContents(batch.Issues)
+		results.addIssues(batch.Issues...)
+	}
+
+	if len(detectors) > 0 {
+		results.FilesScanned = int(filesDone.Load())
+	}
+	if results.FilesScanned < len(files) || batchCut.Load() {
+		results.Incomplete = true
+		s.logger.Warn("scan cut short", "reason", ctx.Err(), "files", results.FilesScanned, "of", len(files))
 	}
 
-	results.Summary = s.calculateSummary(results.Issues)
+	markSecretReuse(results.Issues)
+	results.Detectors = metrics.list()
+	results.Whitelist = s.whitelist.list()
+	results.Profile = s.profile.profile()
+	results.CachedFiles = int(s.cacheHits.Load())
+	results.Summary = calculateSummary(results.Issues)
 	results.Duration = time.Since(startTime).String()
 
-	if s.config.Verbose {
-		fmt.Printf("Scanned %d files in %s\n", results.FilesScanned, results.Duration)
+	s.logger.Debug("scan finished", "files", results.FilesScanned, "cached", results.CachedFiles, "issues", len(results.Issues), "duration", results.Duration)
+	for _, d := range results.Detectors {
+		s.logger.Debug("detector finished", "detector", d.Name, "files", d.Files, "issues", d.Issues, "duration", d.Duration)
 	}
+	s.logWhitelistStats(results.Whitelist)
 
-	return results, nil
+	return results
 }
 
-// scans a single file
-func (s *Scanner) scanFile(filePath string, scanType ScanType) []Issue {
-	var issues []Issue
+// reads files for scanning, skipping large and binary files
+func (s *Scanner) fileReader(ctx context.Context) ReadFunc {
+	return func(filePath string) (string, bool) {
+		content, reason := s.loadFile(ctx, filePath)
+		return content, reason == ""
+	}
+}
 
-	// check file size
+// reads a file for scanning, or returns why it was skipped; Git LFS
+// pointers are replaced by the object they point to
+func (s *Scanner) loadFile(ctx context.Context, filePath string) (string, string) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		return issues
+		return "", SkipUnreadable
 	}
 
 	if fileInfo.Size() > s.config.MaxFileSize {
-		if s.config.Verbose {
-			fmt.Printf("Skipping large file: %s (%d bytes)\n", filePath, fileInfo.Size())
-		}
-		return issues
+		s.logger.Debug("skipping large file", "file", filePath, "bytes", fileInfo.Size())
+		return "", SkipTooLarge
 	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return issues
+		return "", SkipUnreadable
+	}
+
+	// the raw buffer is cleared once copied so file contents, and any
+	// secrets in them, are held in as few places as possible
+	defer clear(content)
+
+	if pointer, ok := parseLFSPointer(content); ok {
+		return s.readLFSObject(ctx, filepath.Dir(filePath), filePath, pointer)
+	}
+	if isMetadataFile(filePath) {
+		return s.readMetadata(filePath, content)
 	}
 
 	if isBinary(content) {
-		return issues
+		return "", SkipBinary
 	}
 
-	contentStr := string(content)
+	return string(content), ""
+}
 
-	// scan for secrets
-	if scanType == ScanTypeAll || scanType == ScanTypeSecrets {
-		issues = append(issues, s.scanSecrets(filePath, contentStr)...)
+// runs the per-file detectors over a single file; false means ctx was
+// done before they all ran, and the file's findings are left out
+func (s *Scanner) scanFile(ctx context.Context, root, filePath string, read ReadFunc, detectors []Detector, metrics *detectorMetrics) ([]Issue, bool) {
+	var issues []Issue
+
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	contentStr, ok := read(filePath)
+	if !ok {
+		return issues, true
 	}
 
-	// scan dependencies
-	if scanType == ScanTypeAll || scanType == ScanTypeDependencies {
-		if isDependencyFile(filePath) {
-			depIssues, err := s.scanDependencies(filePath, contentStr)
-			if err != nil && s.config.Verbose {
-				fmt.Printf("Error scanning dependencies in %s: %v\n", filePath, err)
+	// verification results change over time, so they are never reused
+	var key string
+	if s.results != nil && s.verifier == nil {
+		key = resultsKey(s.digest, filePath, contentStr)
+		if cached, ok := s.cachedIssues(key); ok {
+			// as found by this scan
+			for i := range cached {
+				cached[i].Timestamp = time.Now()
 			}
-			issues = append(issues, depIssues...)
+			return cached, true
 		}
 	}
 
-	if scanType == ScanTypeAll || scanType == ScanTypeSocial {
-		if s.config.SocialEngineering.Enabled {
-			issues = append(issues, s.scanSocialEngineering(filePath, contentStr)...)
+	fileStart := time.Now()
+	for _, d := range detectors {
+		if ctx.Err() != nil {
+			return nil, false
 		}
+		start := time.Now()
+		found := d.Detect(root, filePath, contentStr)
+		metrics.record(d.Name(), 1, len(found), time.Since(start))
+		issues = append(issues, found...)
 	}
-
-	return issues
+	s.profile.recordFile(filePath, len(contentStr), time.Since(fileStart))
+
+	issues = s.dropLockfileHashes(filePath, contentStr, issues)
+	markInlineIgnores(contentStr, issues)
+	issues = s.limitFileIssues(filePath, issues)
+	// masked here rather than once the scan ends, so findings from the
+	// results cache, which only ever holds them masked, come out the same
+	s.maskContents(issues)
+	if key != "" {
+		s.cacheIssues(key, issues)
+	}
+	return issues, true
 }
 
 // scans content for secret patterns
-func (s *Scanner) scanSecrets(filePath, content string) []Issue {
+func (s *Scanner) scanSecrets(root, filePath, content string) []Issue {
 	var issues []Issue
-	lines := strings.Split(content, "\n")
+	lines := splitLines(content)
+
+	// per-rule timings for this file, when profiling
+	var timings []RuleTiming
+	if s.profile != nil {
+		timings = make([]RuleTiming, len(s.config.SecretPatterns))
+		for i, pattern := range s.config.SecretPatterns {
+			timings[i].Rule = pattern.Name
+		}
+		defer s.profile.recordRules(timings)
+	}
+
+	// checksums are dropped before they count toward the limit
+	checksums := s.lockfileIgnoreFor(filePath)
+
+	// past max_findings_per_file, matches are only counted
+	limit := s.config.MaxFindingsPerFile
+	var ignored map[int]bool
+	if limit > 0 {
+		ignored = ignoredLines(content)
+	}
+	reported := 0
+	var overflow *Issue
 
 	for lineNum, line := range lines {
-		for _, pattern := range s.config.SecretPatterns {
-			matches := pattern.GetCompiledPattern().FindAllStringSubmatch(line, -1)
-			for _, match := range matches {
-				if s.isWhitelisted(match[0]) {
+		lowerLine := strings.ToLower(line)
+		for i, pattern := range s.config.SecretPatterns {
+			if pattern.Allowlist.AllowsPath(filePath) || !hasKeyword(lowerLine, pattern.Keywords) {
+				continue
+			}
+
+			var start time.Time
+			if timings != nil {
+				start = time.Now()
+			}
+			matches := pattern.GetCompiledPattern().FindAllStringSubmatchIndex(line, -1)
+			if timings != nil {
+				timings[i].Duration += time.Since(start)
+				timings[i].Matches += len(matches)
+			}
+			for _, loc := range matches {
+				matched := line[loc[0]:loc[1]]
+				if s.isWhitelisted(root, filePath, matched) {
 					continue
 				}
 
-				secret := match[0]
-				if len(match) > 1 {
-					secret = match[1]
+				secret := matched
+				if g := pattern.SecretGroup; g > 0 && len(loc) > 2*g+1 && loc[2*g] >= 0 {
+					secret = line[loc[2*g]:loc[2*g+1]]
+				} else if g == 0 && len(loc) > 3 && loc[2] >= 0 {
+					secret = line[loc[2]:loc[3]]
+				}
+				if pattern.Entropy > 0 && shannonEntropy(secret) < pattern.Entropy {
+					continue
+				}
+				if pattern.Allowlist.AllowsSecret(secret, matched, line) || checksums.drops(pattern.Name, line) {
+					continue
 				}
 
-				issues = append(issues, Issue{
+				issue := Issue{
 					Type:        "secret",
 					Severity:    pattern.Severity,
 					File:        filePath,
 					Line:        lineNum + 1,
-					Column:      strings.Index(line, match[0]) + 1,
-					Description: pattern.Description,
-					Content:     s.maskSecret(secret),
+					Column:      column(line, loc[0]),
+					Description: firstNonEmpty(pattern.Description, pattern.Name),
 					Rule:        pattern.Name,
 					Timestamp:   time.Now(),
-				})
+					Category:    pattern.Category(),
+					Tags:        pattern.Tags,
+					Remediation: pattern.Remediation,
+					References:  pattern.References,
+					Owner:       pattern.Owner,
+				}
+				if s.isPlaceholder(secret) {
+					s.markPlaceholder(&issue)
+				}
+				if limit > 0 && issue.SuppressedBy == "" && !ignored[issue.Line] {
+					if reported == limit {
+						overflow = tallyOverflow(overflow, issue, 1)
+						continue
+					}
+					reported++
+				}
+				issue.Content = s.maskSecret(secret)
+				issue.SecretHash = s.HashSecret(secret)
+				if s.verifier != nil {
+					issue.secret = secret
+				}
+				issues = append(issues, issue)
 			}
 		}
 	}
 
+	if overflow != nil {
+		issues = append(issues, *overflow)
+	}
 	return issues
 }
 
+// checks the secrets of issues against their providers. Callers run it
+// outside the max_concurrency slots: verification waits on the network,
+// and holding a slot through it would leave file scanning idle.
+func (s *Scanner) verifySecrets(issues []Issue) {
+	if s.verifier != nil {
+		s.verifier.verify(issues)
+	}
+}
+
+// reports whether a lowercased line contains one of a pattern's keywords;
+// patterns without keywords apply to every line
+func hasKeyword(lowerLine string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	for _, keyword := range keywords {
+		if strings.Contains(lowerLine, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
 // scans for suspicious commit messages
 func (s *Scanner) scanSocialEngineering(filePath, content string) []Issue {
 	var issues []Issue
-	lines := strings.Split(content, "\n")
+	lines := splitLines(content)
 
 	for lineNum, line := range lines {
 		lowerLine := strings.ToLower(line)
@@ -223,9 +777,9 @@ func (s *Scanner) scanSocialEngineering(filePath, content string) []Issue {
 					Severity:    "medium",
 					File:        filePath,
 					Line:        lineNum + 1,
-					Column:      strings.Index(lowerLine, strings.ToLower(keyword)) + 1,
+					Column:      column(lowerLine, strings.Index(lowerLine, strings.ToLower(keyword))),
 					Description: fmt.Sprintf("Suspicious keyword detected: %s", keyword),
-					Content:     line,
+					Content:     s.plaintext(line),
 					Rule:        "Social Engineering Detection",
 					Timestamp:   time.Now(),
 				})
@@ -236,26 +790,76 @@ func (s *Scanner) scanSocialEngineering(filePath, content string) []Issue {
 	return issues
 }
 
+// splits content into lines, accepting LF and CRLF endings so that no
+// line carries a trailing carriage return
+func splitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// converts a byte offset within a line to a 1-based character column
+func column(line string, offset int) int {
+	if offset < 0 {
+		return 1
+	}
+	return utf8.RuneCountInString(line[:offset]) + 1
+}
+
 // collects all files to scan
-func (s *Scanner) collectFiles(path string) ([]string, error) {
+func (s *Scanner) collectFiles(path string, skips *skipLog) ([]string, error) {
 	var files []string
 
+	var ignores *gitignore
+	if s.config.RespectGitignore {
+		ignores = loadGitignore(path)
+	}
+
 	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		rel, relErr := filepath.Rel(path, filePath)
+		if relErr != nil {
+			rel = filePath
+		}
+		rel = filepath.ToSlash(rel)
+
 		if info.IsDir() {
 			dirname := filepath.Base(filePath)
 			if shouldSkipDir(dirname) {
+				skips.add(filePath, SkipDirectory)
+				return filepath.SkipDir
+			}
+			if rel != "." && ignores.ignored(rel, true) {
+				skips.add(filePath, SkipGitignored)
+				return filepath.SkipDir
+			}
+			if rel != "." && s.paths.excluded(rel) {
+				skips.add(filePath, SkipExcluded)
 				return filepath.SkipDir
 			}
+			ignores.enter(filePath, rel)
+			return nil
+		}
+
+		if ignores.ignored(rel, false) {
+			skips.add(filePath, SkipGitignored)
+			return nil
+		}
+		if !s.paths.allowFile(rel) {
+			skips.add(filePath, SkipExcluded)
 			return nil
 		}
 
 		// only scan text files
-		if shouldScanFile(filePath) {
+		if shouldScanFile(filePath) || isDependencyFile(filePath) {
 			files = append(files, filePath)
+		} else {
+			skips.add(filePath, SkipExtension)
 		}
 
 		return nil
@@ -266,8 +870,9 @@ func (s *Scanner) collectFiles(path string) ([]string, error) {
 
 // masks a secret for safe display
 func (s *Scanner) maskSecret(secret string) string {
-	// mask *every* character for secrets up to length 9
-	if len(secret) <= 9 {
+	// mask *every* character for secrets up to length 9, or for any
+	// secret when no plaintext may leave the process
+	if len(secret) <= 9 || s.config.NoPlaintext {
 		return strings.Repeat("*", len(secret))
 	}
 	// for longer secrets, show 4 chars at each end
@@ -277,32 +882,98 @@ func (s *Scanner) maskSecret(secret string) string {
 		secret[len(secret)-4:]
 }
 
-func (s *Scanner) isWhitelisted(value string) bool {
-	for _, whitelisted := range s.config.Whitelist {
-		if strings.Contains(strings.ToLower(value), strings.ToLower(whitelisted)) {
-			return true
+// masks every secret pattern match in the content of issues, whatever
+// their type: social findings quote whole lines, which may hold a token
+func (s *Scanner) maskContents(issues []Issue) {
+	for i := range issues {
+		if issues[i].Content != "" {
+			issues[i].Content = s.maskMatches(issues[i].Content)
 		}
 	}
-	return false
 }
 
-func (s *Scanner) calculateSummary(issues []Issue) Summary {
+// replaces the secret of each pattern match in text with its mask
+func (s *Scanner) maskMatches(text string) string {
+	for _, pattern := range s.config.SecretPatterns {
+		matches := pattern.GetCompiledPattern().FindAllStringSubmatchIndex(text, -1)
+		// replaced back to front so earlier offsets stay valid
+		for m := len(matches) - 1; m >= 0; m-- {
+			start, end := matches[m][0], matches[m][1]
+			if g := pattern.SecretGroup; g > 0 && len(matches[m]) > 2*g+1 && matches[m][2*g] >= 0 {
+				start, end = matches[m][2*g], matches[m][2*g+1]
+			} else if g == 0 && len(matches[m]) > 3 && matches[m][2] >= 0 {
+				start, end = matches[m][2], matches[m][3]
+			}
+			text = text[:start] + s.maskSecret(text[start:end]) + text[end:]
+		}
+	}
+	return text
+}
+
+// returns scanned content for inclusion in an Issue, or nothing when the
+// configuration forbids plaintext output
+func (s *Scanner) plaintext(content string) string {
+	if s.config.NoPlaintext {
+		return ""
+	}
+	return content
+}
+
+// hashes a value such as a fingerprint's parts; secrets are hashed
+// with HashSecret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// returns the SecretHash a finding of secret gets, for matching findings
+// against known values: an HMAC with the secret hash key, so that a hash
+// in a report cannot be matched against guesses without it
+func (s *Scanner) HashSecret(secret string) string {
+	s.hashKeyOnce.Do(func() { s.hashKey = s.config.HashKey() })
+	return hex.EncodeToString(hmacSHA256(s.hashKey, secret))
+}
+
+// identifies a finding across scans: secrets by rule, file and secret
+// hash so edits that only move them keep their identity, other issues by
+// rule, file and what they report
+func (i Issue) Fingerprint() string {
+	parts := []string{i.Type, i.Rule, filepath.ToSlash(i.File)}
+	switch {
+	case i.SecretHash != "":
+		parts = append(parts, i.SecretHash)
+	case i.AdvisoryID != "":
+		parts = append(parts, i.AdvisoryID, i.Description)
+	default:
+		parts = append(parts, fmt.Sprint(i.Line), i.Description)
+	}
+	return hashSecret(strings.Join(parts, "\x00"))
+}
+
+func calculateSummary(issues []Issue) Summary {
 	summary := Summary{}
 
 	for _, issue := range issues {
-		switch issue.Severity {
-		case "critical":
-			summary.Critical++
-		case "high":
-			summary.High++
-		case "medium":
-			summary.Medium++
-		case "low":
-			summary.Low++
+		summary.add(issue.Severity)
+
+		if summary.ByType == nil {
+			summary.ByType = make(map[string]SeverityCounts)
+		}
+		counts := summary.ByType[issue.Type]
+		counts.add(issue.Severity)
+		summary.ByType[issue.Type] = counts
+
+		if issue.Category != "" {
+			if summary.ByCategory == nil {
+				summary.ByCategory = make(map[string]SeverityCounts)
+			}
+			counts := summary.ByCategory[issue.Category]
+			counts.add(issue.Severity)
+			summary.ByCategory[issue.Category] = counts
 		}
-		summary.Total++
 	}
 
+	summary.Risk = ScoreRisk(issues, time.Now())
 	return summary
 }
 
@@ -320,6 +991,11 @@ func isBinary(data []byte) bool {
 	return false
 }
 
+// reports whether a path scan would read this file, judging by its name
+func ShouldScan(filePath string) bool {
+	return shouldScanFile(filePath) || isDependencyFile(filePath)
+}
+
 func shouldSkipDir(dirname string) bool {
 	skipDirs := []string{
 		".git", ".svn", ".hg",
@@ -345,7 +1021,8 @@ func shouldScanFile(filePath string) bool {
 		".yaml", ".yml", ".json", ".xml", ".toml", ".ini", ".cfg", ".conf",
 		".txt", ".md", ".rst", ".html", ".css", ".scss", ".sass",
 		".sql", ".env", ".envrc", ".dockerignore", ".gitignore",
-		".Dockerfile", "",
+		".pem", ".key", ".npmrc", ".pypirc", ".netrc",
+		".dockerfile", "",
 	}
 
 	for _, textExt := range textExts {
@@ -367,19 +1044,23 @@ func shouldScanFile(filePath string) bool {
 		}
 	}
 
-	return false
+	return isImageFile(filePath) || isMetadataFile(filePath)
 }
 
 func isDependencyFile(filePath string) bool {
 	basename := strings.ToLower(filepath.Base(filePath))
 	depFiles := []string{
-		"package.json", "package-lock.json", "yarn.lock",
+		"package.json", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
 		"go.mod", "go.sum",
 		"requirements.txt", "pipfile", "pipfile.lock", "poetry.lock",
 		"gemfile", "gemfile.lock",
 		"composer.json", "composer.lock",
 		"pom.xml", "build.gradle", "gradle.lockfile",
 		"cargo.toml", "cargo.lock",
+		"packages.config", "packages.lock.json", "directory.packages.props",
+	}
+	if isProjectFile(basename) {
+		return true
 	}
 
 	for _, depFile := range depFiles {
@@ -394,6 +1075,25 @@ func (r *Results) HasIssues() bool {
 	return len(r.Issues) > 0
 }
 
+// reports whether any issue is at least as severe as the given severity;
+// an empty severity counts every issue
+func (r *Results) HasIssuesAtOrAbove(severity string) bool {
+	if severity == "" {
+		return r.HasIssues()
+	}
+	for _, issue := range r.Issues {
+		if SeverityRank(issue.Severity) >= SeverityRank(severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether s is one of low, medium, high or critical
+func ValidSeverity(s string) bool {
+	return SeverityRank(s) > 0
+}
+
 // outputs results in JSON format
 func (r *Results) OutputJSON(w io.Writer) error {
 	encoder := json.NewEncoder(w)
@@ -401,36 +1101,111 @@ func (r *Results) OutputJSON(w io.Writer) error {
 	return encoder.Encode(r)
 }
 
-// outputs results in text format
+// outputs results in text format, in the language i18n selects
 func (r *Results) OutputText(w io.Writer) error {
-	fmt.Fprintf(w, "GitGuardian Security Scan Results\n")
-	fmt.Fprintf(w, "=================================\n\n")
-	fmt.Fprintf(w, "Scan completed at: %s\n", r.ScanTime.Format("2006-01-02 15:04:05"))
-	fmt.Fprintf(w, "Duration: %s\n", r.Duration)
-	fmt.Fprintf(w, "Files scanned: %d\n\n", r.FilesScanned)
+	// the tool's own words and icons, which plain ASCII output converts;
+	// what the issues hold is written as it is
+	label := func(msg string) string { return ascii.Label(w, i18n.T(msg)) }
+	labelf := func(format string, args ...interface{}) string { return ascii.Label(w, i18n.Sprintf(format, args...)) }
+
+	writeHeading(w, i18n.T("GitGuardian Security Scan Results"))
+	i18n.Fprintf(w, "Scan completed at: %s\n", r.ScanTime.Format("2006-01-02 15:04:05"))
+	i18n.Fprintf(w, "Duration: %s\n", ascii.Label(w, r.Duration))
+	if r.CommitsScanned > 0 {
+		i18n.Fprintf(w, "Commits scanned: %d\n", r.CommitsScanned)
+	}
+	if r.Revision != "" {
+		i18n.Fprintf(w, "Revision: %s\n", r.Revision)
+	}
+	i18n.Fprintf(w, "Files scanned: %d\n\n", r.FilesScanned)
+
+	if r.Incomplete {
+		i18n.Fprint(w, "⚠️  Scan incomplete: stopped before everything was scanned\n\n")
+	}
+
+	if len(r.Suppressed) > 0 {
+		i18n.Fprintf(w, "Suppressed by ignore comments or as placeholders: %d\n\n", len(r.Suppressed))
+	}
+	if len(r.Duplicates) > 0 {
+		i18n.Fprintf(w, "Repeats of secrets listed once: %d\n\n", len(r.Duplicates))
+	}
+	if r.Unchanged > 0 {
+		i18n.Fprintf(w, "Findings on unchanged lines not reported: %d\n\n", r.Unchanged)
+	}
 
 	if len(r.Issues) == 0 {
-		fmt.Fprintf(w, "✅ No security issues found!\n")
+		i18n.Fprint(w, "✅ No security issues found!\n")
 		return nil
 	}
 
-	fmt.Fprintf(w, "Summary:\n")
-	fmt.Fprintf(w, "  Critical: %d\n", r.Summary.Critical)
-	fmt.Fprintf(w, "  High:     %d\n", r.Summary.High)
-	fmt.Fprintf(w, "  Medium:   %d\n", r.Summary.Medium)
-	fmt.Fprintf(w, "  Low:      %d\n", r.Summary.Low)
-	fmt.Fprintf(w, "  Total: %d\n", r.Summary.Total)
+	i18n.Fprint(w, "Summary:\n")
+	fmt.Fprintf(w, "  %-9s %d\n", label("Critical")+":", r.Summary.Critical)
+	fmt.Fprintf(w, "  %-9s %d\n", label("High")+":", r.Summary.High)
+	fmt.Fprintf(w, "  %-9s %d\n", label("Medium")+":", r.Summary.Medium)
+	fmt.Fprintf(w, "  %-9s %d\n", label("Low")+":", r.Summary.Low)
+	fmt.Fprintf(w, "  %s: %d\n", label("Total"), r.Summary.Total)
+	fmt.Fprintf(w, "  %s\n", labelf("Risk score: %d/100 (%s)", r.Summary.Risk.Score, r.Summary.Risk.Grade))
+
+	if len(r.Summary.ByType) > 0 {
+		i18n.Fprint(w, "\nBy type:\n")
+		for _, issueType := range summaryTypes(r.Summary.ByType) {
+			c := r.Summary.ByType[issueType]
+			fmt.Fprintf(w, "  %-14s %s\n", issueType+":",
+				labelf("%d (critical %d, high %d, medium %d, low %d)", c.Total, c.Critical, c.High, c.Medium, c.Low))
+		}
+	}
 
-	fmt.Fprintf(w, "Issues Found:\n")
-	fmt.Fprintf(w, "=============\n\n")
+	if len(r.Summary.ByCategory) > 0 {
+		i18n.Fprint(w, "\nBy category:\n")
+		for _, category := range summaryTypes(r.Summary.ByCategory) {
+			c := r.Summary.ByCategory[category]
+			fmt.Fprintf(w, "  %-14s %s\n", category+":",
+				labelf("%d (critical %d, high %d, medium %d, low %d)", c.Total, c.Critical, c.High, c.Medium, c.Low))
+		}
+	}
+	fmt.Fprintf(w, "\n")
+
+	writeHeading(w, i18n.T("Issues Found:"))
 
 	for i, issue := range r.Issues {
-		severityIcon := getSeverityIcon(issue.Severity)
-		fmt.Fprintf(w, "%d. %s [%s] %s\n", i+1, severityIcon, strings.ToUpper(issue.Severity), issue.Description)
-		fmt.Fprintf(w, "   File: %s:%d:%d\n", issue.File, issue.Line, issue.Column)
-		fmt.Fprintf(w, "   Rule: %s\n", issue.Rule)
+		severityIcon := ascii.Label(w, getSeverityIcon(issue.Severity))
+		newTag := ""
+		if issue.New {
+			newTag = label("[NEW]") + " "
+		}
+		fmt.Fprintf(w, "%d. %s %s[%s] %s\n", i+1, severityIcon, newTag, strings.ToUpper(issue.Severity), issue.Description)
+		if issue.Line > 0 {
+			fmt.Fprintf(w, "   %s: %s:%d:%d\n", label("File"), issue.File, issue.Line, issue.Column)
+		} else if issue.File != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("File"), issue.File)
+		}
+		fmt.Fprintf(w, "   %s: %s\n", label("Rule"), issue.Rule)
+		if issue.Owner != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("Owner"), issue.Owner)
+		}
+		if issue.Verified != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("Verified"), issue.Verified)
+		}
+		if exploitation := issue.exploitation(); exploitation != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("Exploitation"), exploitation)
+		}
+		if len(issue.Locations) > 0 {
+			i18n.Fprintf(w, "   Reused in %d places: %s\n", len(issue.Locations), strings.Join(issue.Locations, ", "))
+		}
+		if issue.Commit != "" {
+			fmt.Fprintf(w, "   %s: %s (%s)\n", label("Commit"), issue.Commit, issue.Author)
+		}
 		if issue.Content != "" {
-			fmt.Fprintf(w, "   Content: %s\n", issue.Content)
+			fmt.Fprintf(w, "   %s: %s\n", label("Content"), issue.Content)
+		}
+		if issue.Remediation != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("Remediation"), issue.Remediation)
+		}
+		for _, ref := range issue.References {
+			fmt.Fprintf(w, "   %s: %s\n", label("Reference"), ref)
+		}
+		if issue.FirstSeen != nil && issue.ID != "" {
+			fmt.Fprintf(w, "   %s: %s\n", label("Fingerprint"), shortFingerprint(issue.ID))
 		}
 		fmt.Fprintf(w, "\n")
 	}
@@ -438,6 +1213,44 @@ func (r *Results) OutputText(w io.Writer) error {
 	return nil
 }
 
+// shortens a store fingerprint for display; feedback accepts any
+// unambiguous prefix
+func shortFingerprint(fingerprint string) string {
+	if len(fingerprint) > 16 {
+		return fingerprint[:16]
+	}
+	return fingerprint
+}
+
+// writes a title underlined to its width
+func writeHeading(w io.Writer, title string) {
+	title = ascii.Label(w, title)
+	fmt.Fprintf(w, "%s\n%s\n\n", title, strings.Repeat("=", utf8.RuneCountInString(title)))
+}
+
+// orders summary keys with the built-in issue types first, then by name
+func summaryTypes(byType map[string]SeverityCounts) []string {
+	order := map[string]int{"secret": 0, "vulnerability": 1, "social": 2, "ci-config": 3, "signature": 4, "advisory": 5}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		oi, iKnown := order[types[i]]
+		oj, jKnown := order[types[j]]
+		switch {
+		case iKnown && jKnown:
+			return oi < oj
+		case iKnown != jKnown:
+			return iKnown
+		default:
+			return types[i] < types[j]
+		}
+	})
+	return types
+}
+
 func getSeverityIcon(severity string) string {
 	switch severity {
 	case "critical":
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// one of total deterministic partitions of the file list; the zero value
+// covers every file
+type shard struct {
+	index int // 1-based
+	total int
+}
+
+func (sh shard) String() string {
+	return fmt.Sprintf("%d/%d", sh.index, sh.total)
+}
+
+// parses a shard specification such as "3/8"
+func ParseShard(spec string) (index, total int, err error) {
+	i, t, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected N/M", spec)
+	}
+	if index, err = strconv.Atoi(strings.TrimSpace(i)); err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %w", spec, err)
+	}
+	if total, err = strconv.Atoi(strings.TrimSpace(t)); err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %w", spec, err)
+	}
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, fmt.Errorf("invalid shard %q: need 1 <= N <= M", spec)
+	}
+	return index, total, nil
+}
+
+// limits scans to one shard of the file list; files are assigned by a
+// hash of their path relative to the scan root, so every job computes
+// the same partition
+func (s *Scanner) SetShard(index, total int) {
+	s.shard = shard{index: index, total: total}
+}
+
+func (sh shard) filter(root string, files []string) []string {
+	if sh.total <= 1 {
+		return files
+	}
+
+	var kept []string
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(filepath.ToSlash(rel)))
+		if int(h.Sum32()%uint32(sh.total)) == sh.index-1 {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// combines the results of sharded scans into one report; missing or
+// duplicated shards are reported as an error alongside the merged result
+func MergeResults(parts []*Results) (*Results, error) {
+	merged := &Results{Issues: make([]Issue, 0)}
+
+	var longest time.Duration
+	seen := make(map[string]bool)
+	skippedSeen := make(map[string]bool)
+	total := 0
+	var problems []string
+
+	for _, part := range parts {
+		if merged.ScanTime.IsZero() || (!part.ScanTime.IsZero() && part.ScanTime.Before(merged.ScanTime)) {
+			merged.ScanTime = part.ScanTime
+		}
+		if d, err := time.ParseDuration(part.Duration); err == nil && d > longest {
+			longest = d
+		}
+
+		merged.FilesScanned += part.FilesScanned
+		merged.Issues = append(merged.Issues, part.Issues...)
+		merged.Suppressed = append(merged.Suppressed, part.Suppressed...)
+		merged.Duplicates = append(merged.Duplicates, part.Duplicates...)
+		merged.Unchanged += part.Unchanged
+		merged.Dependencies = append(merged.Dependencies, part.Dependencies...)
+		merged.Detectors = append(merged.Detectors, part.Detectors...)
+		merged.Incomplete = merged.Incomplete || part.Incomplete
+		merged.Deduplicated = merged.Deduplicated || part.Deduplicated
+
+		// every shard walks the whole tree, so skips repeat across parts
+		for _, skipped := range part.Skipped {
+			if !skippedSeen[skipped.Path] {
+				skippedSeen[skipped.Path] = true
+				merged.Skipped = append(merged.Skipped, skipped)
+			}
+		}
+
+		if part.Shard == "" {
+			continue
+		}
+		if seen[part.Shard] {
+			problems = append(problems, fmt.Sprintf("shard %s appears more than once", part.Shard))
+		}
+		seen[part.Shard] = true
+		if _, t, err := ParseShard(part.Shard); err == nil {
+			if total != 0 && t != total {
+				problems = append(problems, fmt.Sprintf("shard %s does not match total %d", part.Shard, total))
+			}
+			total = t
+		}
+	}
+
+	for i := 1; i <= total; i++ {
+		if !seen[fmt.Sprintf("%d/%d", i, total)] {
+			problems = append(problems, fmt.Sprintf("shard %d/%d is missing", i, total))
+		}
+	}
+
+	merged.Duration = longest.String()
+
+	// each shard only saw the reuse within its own files; the repeats in
+	// Duplicates are locations of the secret too
+	occurrences := merged.Occurrences()
+	markSecretReuse(occurrences)
+	if len(merged.Duplicates) > 0 {
+		n := len(merged.Issues)
+		merged.Issues, merged.Duplicates = occurrences[:n:n], occurrences[n:]
+	}
+	if merged.Deduplicated {
+		// each shard only saw the repeats within its own files
+		merged.Issues, merged.Duplicates = splitDuplicates(merged.Issues, merged.Duplicates)
+	}
+	merged.Summary = calculateSummary(merged.Issues)
+
+	if len(problems) > 0 {
+		return merged, fmt.Errorf("incomplete shard set: %s", strings.Join(problems, "; "))
+	}
+	return merged, nil
+}
@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// what git log's %G? placeholder reports, for signatures that fail the check
+var signatureProblems = map[string]string{
+	"N": "is not signed",
+	"B": "has a bad signature",
+	"E": "has a signature that cannot be checked (unknown key)",
+	"X": "has an expired signature",
+	"Y": "is signed by an expired key",
+	"R": "is signed by a revoked key",
+}
+
+// checks that every commit a push introduces is signed by an allowed key,
+// returning one "signature" issue per commit that is not
+func (s *Scanner) CheckSignatures(ctx context.Context, repoPath string, updates []RefUpdate) ([]Issue, error) {
+	cfg := s.config.Signatures
+	severity := cfg.Severity
+	if severity == "" {
+		severity = "high"
+	}
+
+	var issues []Issue
+	checked := make(map[string]bool)
+	for _, u := range updates {
+		if isNullRev(u.NewRev) {
+			continue
+		}
+		revs := []string{u.NewRev, "^" + u.OldRev}
+		if isNullRev(u.OldRev) {
+			revs = []string{u.NewRev, "--not", "--all"}
+		}
+
+		args := []string{"log", "--format=%H%x00%G?%x00%GF%x00%GP%x00%GK%x00%an <%ae>"}
+		if cfg.AllowedSignersFile != "" {
+			args = append([]string{"-c", "gpg.ssh.allowedSignersFile=" + cfg.AllowedSignersFile}, args...)
+		}
+		args = append(args, revs...)
+		args = append(args, "--")
+
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.Output()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to read commit signatures: %w", err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			fields := strings.Split(line, "\x00")
+			if len(fields) != 6 || checked[fields[0]] {
+				continue
+			}
+			commit, status, author := fields[0], fields[1], fields[5]
+			checked[commit] = true
+
+			problem, bad := signatureProblems[status]
+			switch {
+			case bad:
+			// a good signature by a key of unknown trust ("U") passes
+			// unless allowed_keys lists the keys it must be made with
+			case !allowedSigningKey(cfg.AllowedKeys, fields[2], fields[3], fields[4]):
+				problem, bad = fmt.Sprintf("is signed by a key that is not allowed (%s)", firstNonEmpty(fields[2], fields[4])), true
+			}
+			if !bad {
+				continue
+			}
+
+			issues = append(issues, Issue{
+				Type:        "signature",
+				Severity:    severity,
+				File:        u.Ref,
+				Description: fmt.Sprintf("Commit %s %s", shortCommit(commit), problem),
+				Rule:        "Commit Signature",
+				Timestamp:   time.Now(),
+				Commit:      commit,
+				Author:      author,
+			})
+		}
+	}
+	return issues, nil
+}
+
+// reports whether a good signature's key is on the allow list; fingerprints
+// match in full, GPG key ids as a suffix of either fingerprint
+func allowedSigningKey(allowed []string, fingerprint, primary, keyID string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, key := range allowed {
+		key = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), " ", ""))
+		for _, candidate := range []string{fingerprint, primary, keyID} {
+			candidate = strings.ToUpper(candidate)
+			if candidate == "" {
+				continue
+			}
+			if candidate == key || (len(key) >= 8 && strings.HasSuffix(candidate, key)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// reasons a file is left out of a scan
+const (
+	SkipBinary     = "binary"
+	SkipTooLarge   = "too_large"
+	SkipExtension  = "extension"
+	SkipExcluded   = "excluded"
+	SkipDirectory  = "skipped_directory"
+	SkipGitignored = "gitignored"
+	SkipUnreadable = "unreadable"
+	SkipLFSPointer = "lfs_pointer"
+)
+
+// a file, or a whole directory, that was not scanned
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// lists skipped files in Results.Skipped for path scans
+func (s *Scanner) SetReportSkipped(enabled bool) {
+	s.reportSkipped = enabled
+}
+
+// collects skipped files during a scan; a nil log records nothing
+type skipLog struct {
+	mu      sync.Mutex
+	root    string
+	entries map[string]string
+}
+
+func newSkipLog(root string) *skipLog {
+	return &skipLog{root: root, entries: make(map[string]string)}
+}
+
+func (l *skipLog) add(filePath, reason string) {
+	if l == nil {
+		return
+	}
+
+	rel, err := filepath.Rel(l.root, filePath)
+	if err != nil {
+		rel = filePath
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[filepath.ToSlash(rel)] = reason
+}
+
+func (l *skipLog) list() []SkippedFile {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]SkippedFile, 0, len(l.entries))
+	for path, reason := range l.entries {
+		list = append(list, SkippedFile{Path: path, Reason: reason})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
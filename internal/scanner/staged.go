@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scans the staged (index) version of every added, copied, modified or
+// renamed file, reading blobs straight from git so partially staged files
+// are scanned as they will be committed and nothing is written to disk
+func (s *Scanner) ScanStaged(ctx context.Context, repoPath string, scanType ScanType) (*Results, error) {
+	startTime := time.Now()
+
+	out, err := gitOutput(ctx, repoPath, "diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" || !s.paths.allowFile(path) {
+			continue
+		}
+		if shouldScanFile(path) || isDependencyFile(path) {
+			paths = append(paths, path)
+		}
+	}
+
+	specs := make([]string, len(paths))
+	for i, path := range paths {
+		specs[i] = ":" + path
+	}
+
+	blobs, err := readBlobs(ctx, repoPath, paths, specs, s.config.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	blobs = s.resolveLFSBlobs(ctx, repoPath, blobs)
+
+	results := s.scanFiles(ctx, "", blobPaths(blobs), s.blobReader(blobs), scanType, startTime)
+	if s.config.DiffOnly {
+		diff, err := gitOutput(ctx, repoPath, "diff", "--cached", "-U0", "-M", "--no-color", "--no-ext-diff", "--diff-filter=ACMR")
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff staged files: %w", err)
+		}
+		changed, err := readChangedLines(bytes.NewReader(diff), int(s.config.MaxFileSize))
+		if err != nil {
+			return nil, err
+		}
+		changed.filter(results)
+	}
+	s.dedupe(results, "")
+	return results, nil
+}
+
+func blobPaths(blobs []Blob) []string {
+	paths := make([]string, len(blobs))
+	for i, blob := range blobs {
+		paths[i] = blob.Path
+	}
+	return paths
+}
+
+// serves blob content to detectors, skipping oversized and binary blobs;
+// images and documents are served as their metadata
+func (s *Scanner) blobReader(blobs []Blob) ReadFunc {
+	contents := make(map[string]string, len(blobs))
+	for _, blob := range blobs {
+		contents[blob.Path] = blob.Content
+	}
+	return func(path string) (string, bool) {
+		content, ok := contents[path]
+		if !ok || int64(len(content)) > s.config.MaxFileSize {
+			return "", false
+		}
+		if isMetadataFile(path) {
+			metadata, reason := s.readMetadata(path, []byte(content))
+			return metadata, reason == ""
+		}
+		if isBinary([]byte(content)) {
+			return "", false
+		}
+		return content, true
+	}
+}
+
+// reads objects named by git revision specs (such as ":path" for the index
+// or "sha:path") through a single `git cat-file --batch` process
+func readBlobs(ctx context.Context, repoPath string, paths, specs []string, maxSize int64) ([]Blob, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	var input bytes.Buffer
+	var requested []string
+	for i, spec := range specs {
+		// cat-file reads one spec per line
+		if strings.ContainsAny(spec, "\n") {
+			continue
+		}
+		input.WriteString(spec + "\n")
+		requested = append(requested, paths[i])
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = repoPath
+	cmd.Stdin = &input
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run git cat-file: %w", err)
+	}
+
+	// git could still be writing to a pipe no one reads, so on a failed
+	// read it is stopped rather than waited on
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	rd := bufio.NewReader(stdout)
+	var blobs []Blob
+	for _, path := range requested {
+		header, err := rd.ReadString('\n')
+		if err != nil {
+			stop()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to read blob %s: %w", path, err)
+		}
+
+		// "<sha> <type> <size>", or "<spec> missing" where the spec may
+		// hold spaces
+		header = strings.TrimSuffix(header, "\n")
+		if strings.HasSuffix(header, " missing") || strings.HasSuffix(header, " ambiguous") {
+			continue
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			stop()
+			return nil, fmt.Errorf("unexpected cat-file header %q", header)
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("unexpected cat-file header %q", header)
+		}
+
+		if fields[1] != "blob" || size > maxSize {
+			if _, err := io.CopyN(io.Discard, rd, size+1); err != nil {
+				stop()
+				return nil, fmt.Errorf("failed to read blob %s: %w", path, err)
+			}
+			continue
+		}
+
+		data := make([]byte, size+1) // content plus trailing newline
+		if _, err := io.ReadFull(rd, data); err != nil {
+			stop()
+			return nil, fmt.Errorf("failed to read blob %s: %w", path, err)
+		}
+		blobs = append(blobs, Blob{Path: path, Content: string(data[:size])})
+		clear(data)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+	return blobs, nil
+}
+
+// runs a git command in a repository and returns its standard output;
+// the command is killed once ctx is done
+func gitOutput(ctx context.Context, repoPath string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,266 @@
+package scanner
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// outcomes of checking a secret against its provider
+const (
+	VerifiedLive    = "live"
+	VerifiedInvalid = "invalid"
+)
+
+// checks detected credentials against provider APIs so live ones can be
+// told apart from revoked or fake ones
+type secretVerifier struct {
+	cfg    config.VerifyConfig
+	client *http.Client
+
+	stsURL    string
+	githubURL string
+	slackURL  string
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	results map[string]string // by secret hash, so each secret is checked once
+
+	// bounds the requests in flight, apart from the slots of file scanning
+	slots chan struct{}
+}
+
+func newSecretVerifier(cfg config.VerifyConfig, logger *slog.Logger) *secretVerifier {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &secretVerifier{
+		cfg:       cfg,
+		client:    &http.Client{Timeout: timeout},
+		logger:    logger,
+		stsURL:    "https://sts.amazonaws.com/",
+		githubURL: "https://api.github.com/user",
+		slackURL:  "https://slack.com/api/auth.test",
+		results:   make(map[string]string),
+		slots:     make(chan struct{}, max(cfg.Workers, 1)),
+	}
+}
+
+// verifies the secret issues of one file, dropping the plaintext they
+// hold once checked. Live secrets become critical; invalid ones keep their
+// severity and are only marked, as a provider refusing a key does not
+// prove it was never valid elsewhere.
+func (v *secretVerifier) verify(issues []Issue) {
+	secrets := make([]string, len(issues))
+	for i := range issues {
+		secrets[i], issues[i].secret = issues[i].secret, ""
+	}
+
+	// an AWS access key can only be checked with a secret key from the
+	// same file
+	var awsSecret string
+	for i, issue := range issues {
+		if issue.Rule == "AWS Secret Key" && secrets[i] != "" {
+			awsSecret = secrets[i]
+			break
+		}
+	}
+
+	for i := range issues {
+		if issues[i].SuppressedBy != "" {
+			continue
+		}
+		var check func() (bool, error)
+		switch issues[i].Rule {
+		case "AWS Access Key":
+			if !v.cfg.AWS || awsSecret == "" || secrets[i] == "" {
+				continue
+			}
+			keyID := secrets[i]
+			check = func() (bool, error) { return v.checkAWS(keyID, awsSecret) }
+		case "GitHub Token", "GitHub Classic Token":
+			// a bare 40-character hex match is far more often a commit SHA
+			// or checksum than a token, and is not sent to GitHub
+			if !v.cfg.GitHub || secrets[i] == "" || isHexToken(secrets[i]) {
+				continue
+			}
+			token := secrets[i]
+			check = func() (bool, error) { return v.checkGitHub(token) }
+		case "Slack Token":
+			if !v.cfg.Slack || secrets[i] == "" {
+				continue
+			}
+			token := secrets[i]
+			check = func() (bool, error) { return v.checkSlack(token) }
+		default:
+			continue
+		}
+
+		status := v.cached(issues[i].SecretHash, check)
+		issues[i].Verified = status
+		if status == VerifiedLive {
+			issues[i].Severity = "critical"
+		}
+	}
+}
+
+func (v *secretVerifier) cached(hash string, check func() (bool, error)) string {
+	v.mu.Lock()
+	status, ok := v.results[hash]
+	v.mu.Unlock()
+	if ok {
+		return status
+	}
+
+	v.slots <- struct{}{}
+	live, err := check()
+	<-v.slots
+	switch {
+	case err != nil:
+		// unreachable providers leave the finding as it was
+		v.logger.Warn("secret verification failed", "error", err)
+		status = ""
+	case live:
+		status = VerifiedLive
+	default:
+		status = VerifiedInvalid
+	}
+
+	v.mu.Lock()
+	v.results[hash] = status
+	v.mu.Unlock()
+	return status
+}
+
+// calls GET /user with the token
+func (v *secretVerifier) checkGitHub(token string) (bool, error) {
+	req, err := http.NewRequest("GET", v.githubURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	return v.statusCheck(req)
+}
+
+// calls auth.test, which answers 200 with ok=false for bad tokens
+func (v *secretVerifier) checkSlack(token string) (bool, error) {
+	req, err := http.NewRequest("POST", v.slackURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := doJSON(v.client, req, &resp); err != nil {
+		return false, fmt.Errorf("slack: %w", err)
+	}
+	return resp.OK, nil
+}
+
+// calls STS GetCallerIdentity, which any valid key pair may call
+func (v *secretVerifier) checkAWS(keyID, secretKey string) (bool, error) {
+	body := "Action=GetCallerIdentity&Version=2011-06-15"
+	req, err := http.NewRequest("POST", v.stsURL, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(req, body, keyID, secretKey, "us-east-1", "sts", time.Now().UTC())
+
+	return v.statusCheck(req)
+}
+
+// treats 200 as live and 401/403 as invalid; rate limits, 429 or a 403
+// with no requests remaining or a Retry-After, are errors, since they say
+// nothing of the credential
+func (v *secretVerifier) statusCheck(req *http.Request) (bool, error) {
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	rateLimited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden &&
+			(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""))
+	switch {
+	case rateLimited:
+		return false, fmt.Errorf("%s rate limited the request (status %d)", req.URL.Host, resp.StatusCode)
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+}
+
+// adds an AWS Signature Version 4 Authorization header
+func signAWSRequest(req *http.Request, body, keyID, secretKey, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		keyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
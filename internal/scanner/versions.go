@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compares two version strings segment by segment, numerically where
+// possible; returns -1, 0 or 1
+func compareVersions(a, b string) int {
+	a = strings.TrimPrefix(strings.TrimSpace(a), "v")
+	b = strings.TrimPrefix(strings.TrimSpace(b), "v")
+
+	// a pre-release suffix sorts before the release it precedes
+	aMain, aPre, _ := strings.Cut(a, "-")
+	bMain, bPre, _ := strings.Cut(b, "-")
+
+	aParts := strings.Split(aMain, ".")
+	bParts := strings.Split(bMain, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var ap, bp string
+		if i < len(aParts) {
+			ap = aParts[i]
+		}
+		if i < len(bParts) {
+			bp = bParts[i]
+		}
+
+		if c := compareSegment(ap, bp); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return compareSegment(aPre, bPre)
+	}
+}
+
+func compareSegment(a, b string) int {
+	an, aErr := strconv.Atoi(orZero(a))
+	bn, bErr := strconv.Atoi(orZero(b))
+
+	if aErr == nil && bErr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// evaluates a comma-separated constraint list such as ">= 1.0, < 1.2.3"
+func versionInRange(version, constraints string) bool {
+	if strings.TrimSpace(constraints) == "" {
+		return false
+	}
+
+	for _, constraint := range strings.Split(constraints, ",") {
+		constraint = strings.TrimSpace(constraint)
+
+		op := ""
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(constraint, candidate) {
+				op = candidate
+				break
+			}
+		}
+		bound := strings.TrimSpace(strings.TrimPrefix(constraint, op))
+		c := compareVersions(version, bound)
+
+		var ok bool
+		switch op {
+		case ">=":
+			ok = c >= 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case "<":
+			ok = c < 0
+		case "!=":
+			ok = c != 0
+		default:
+			ok = c == 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
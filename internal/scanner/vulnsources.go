@@ -0,0 +1,784 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/pkg/report"
+)
+
+// a database that can report known vulnerabilities for dependencies;
+// every returned Vulnerability has its Dependency set. Query gives up,
+// returning what it has, once ctx is done.
+type VulnSource interface {
+	Name() string
+	Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error)
+}
+
+// builds the vulnerability sources enabled in the configuration
+func NewVulnSources(cfg config.DependencyConfig) []VulnSource {
+	var sources []VulnSource
+
+	if cfg.OSVEnabled {
+		osv := NewOSVSource()
+		if cfg.OSVWorkers > 0 {
+			osv.Workers = cfg.OSVWorkers
+		}
+		osv.RateLimit = cfg.OSVRateLimit
+		sources = append(sources, osv)
+	}
+	if cfg.GitHubToken != "" {
+		sources = append(sources, NewGitHubSource(cfg.GitHubToken))
+	}
+	if cfg.SnykAPIKey != "" {
+		sources = append(sources, NewSnykSource(cfg.SnykAPIKey))
+	}
+	if cfg.OfflineDB != "" {
+		sources = append(sources, NewOfflineSource(cfg.OfflineDB))
+	}
+
+	return sources
+}
+
+// queries every source and merges the results; a failing source does not
+// prevent the others from reporting
+func queryVulnSources(ctx context.Context, sources []VulnSource, deps []Dependency) ([]Vulnerability, error) {
+	var all []Vulnerability
+	var errs []string
+
+	for _, source := range sources {
+		vulns, err := source.Query(ctx, deps)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", source.Name(), err))
+		}
+		all = append(all, vulns...)
+	}
+
+	merged := mergeVulnerabilities(all)
+	if len(errs) > 0 {
+		return merged, fmt.Errorf("vulnerability source errors: %s", strings.Join(errs, "; "))
+	}
+	return merged, nil
+}
+
+// collapses reports of the same advisory for the same dependency, matching
+// on IDs and aliases so e.g. a GHSA and its CVE count as one finding
+func mergeVulnerabilities(vulns []Vulnerability) []Vulnerability {
+	var merged []Vulnerability
+
+	for _, vuln := range vulns {
+		found := false
+		for i := range merged {
+			if dependencyKey(merged[i].Dependency) == dependencyKey(vuln.Dependency) && sameAdvisory(merged[i], vuln) {
+				merged[i] = mergeVulnerability(merged[i], vuln)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, vuln)
+		}
+	}
+
+	return merged
+}
+
+// reports whether two vulnerabilities share an ID or alias
+func sameAdvisory(a, b Vulnerability) bool {
+	ids := make(map[string]bool)
+	for _, id := range append([]string{a.ID}, a.Aliases...) {
+		ids[strings.ToUpper(id)] = true
+	}
+	for _, id := range append([]string{b.ID}, b.Aliases...) {
+		if ids[strings.ToUpper(id)] {
+			return true
+		}
+	}
+	return false
+}
+
+// combines two reports of one advisory, keeping the highest severity
+func mergeVulnerability(a, b Vulnerability) Vulnerability {
+	if SeverityRank(b.Severity) > SeverityRank(a.Severity) {
+		a.Severity = b.Severity
+	}
+	if b.CVSS > a.CVSS {
+		a.CVSS = b.CVSS
+	}
+	if a.Summary == "" {
+		a.Summary = b.Summary
+	}
+	if a.Details == "" {
+		a.Details = b.Details
+	}
+	if b.ID != a.ID {
+		a.Aliases = append(a.Aliases, b.ID)
+	}
+	a.Aliases = uniqueStrings(a.Aliases, a.ID)
+	a.References = uniqueStrings(append(a.References, b.References...), "")
+	return a
+}
+
+func uniqueStrings(values []string, skip string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if v == "" || v == skip || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// orders severities from low, 1, to critical, 4; anything else is 0
+func SeverityRank(severity string) int {
+	return report.Severity(severity).Rank()
+}
+
+// queries the OSV database at osv.dev
+type OSVSource struct {
+	Endpoint string
+	Token    string // sent as a bearer token, for internal OSV-compatible endpoints
+	client   *http.Client
+
+	// querybatch requests run on Workers goroutines, at most RateLimit
+	// requests per second (0 for no limit), with BatchSize queries each
+	Workers   int
+	RateLimit float64
+	BatchSize int
+
+	// responses by (ecosystem, name, version), when caching is enabled
+	cache    *cache.Store
+	cacheTTL time.Duration
+}
+
+// the most queries osv.dev accepts in one querybatch request
+const osvMaxBatch = 1000
+
+func NewOSVSource() *OSVSource {
+	return &OSVSource{
+		Endpoint:  "https://api.osv.dev/v1/querybatch",
+		client:    &http.Client{Timeout: 30 * time.Second},
+		Workers:   4,
+		BatchSize: osvMaxBatch,
+	}
+}
+
+func (o *OSVSource) Name() string {
+	return "osv"
+}
+
+// keeps querybatch results in the store so unchanged dependencies are not
+// looked up again until ttl passes
+func (o *OSVSource) SetCache(store *cache.Store, ttl time.Duration) {
+	o.cache = store
+	o.cacheTTL = ttl
+}
+
+// answers what it can from the cache and looks up the rest in querybatch
+// requests; OSV returns one result per query, in query order, so each
+// batch maps its results back onto its own dependency list
+func (o *OSVSource) Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	var vulnerabilities []Vulnerability
+
+	var pending []Dependency
+	for _, dep := range deps {
+		if cached, ok := o.cache.Get(dependencyKey(dep)); ok {
+			var vulns []OSVVulnerability
+			if err := json.Unmarshal(cached, &vulns); err == nil {
+				for _, vuln := range vulns {
+					vulnerabilities = append(vulnerabilities, convertOSVVuln(vuln, dep))
+				}
+				continue
+			}
+		}
+		pending = append(pending, dep)
+	}
+
+	batchSize := o.BatchSize
+	if batchSize <= 0 || batchSize > osvMaxBatch {
+		batchSize = osvMaxBatch
+	}
+	var batches [][]Dependency
+	for len(pending) > 0 {
+		n := min(batchSize, len(pending))
+		batches = append(batches, pending[:n])
+		pending = pending[n:]
+	}
+
+	workers := o.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// results are kept per batch so the output order does not depend on
+	// which worker finishes first
+	found := make([][]Vulnerability, len(batches))
+	errs := make([]error, len(batches))
+	limiter := newRateLimiter(o.RateLimit)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(batches)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				found[i], errs[i] = o.queryBatch(ctx, batches[i], limiter)
+			}
+		}()
+	}
+	for i := range batches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failures []string
+	for i := range batches {
+		vulnerabilities = append(vulnerabilities, found[i]...)
+		if errs[i] != nil {
+			failures = append(failures, errs[i].Error())
+		}
+	}
+	if len(failures) > 0 {
+		return vulnerabilities, fmt.Errorf("OSV lookups failed: %s", strings.Join(uniqueStrings(failures, ""), "; "))
+	}
+	return vulnerabilities, nil
+}
+
+// sends one querybatch request, retrying when rate limited
+func (o *OSVSource) queryBatch(ctx context.Context, deps []Dependency, limiter *rateLimiter) ([]Vulnerability, error) {
+	queries := make([]map[string]interface{}, 0, len(deps))
+	for _, dep := range deps {
+		queries = append(queries, map[string]interface{}{
+			"package": map[string]string{
+				"ecosystem": mapToOSVEcosystem(dep.Ecosystem),
+				"name":      dep.Name,
+			},
+			"version": dep.Version,
+		})
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"queries": queries})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := postWithRetry(ctx, o.client, o.Endpoint, o.Token, jsonData, limiter)
+	if err != nil {
+		return nil, fmt.Errorf("OSV API request failed: %w", err)
+	}
+
+	var response struct {
+		Results []struct {
+			Vulns []OSVVulnerability `json:"vulns"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse OSV response: %w", err)
+	}
+	if len(response.Results) != len(deps) {
+		return nil, fmt.Errorf("OSV returned %d results for %d queries", len(response.Results), len(deps))
+	}
+
+	var vulnerabilities []Vulnerability
+	for i, result := range response.Results {
+		dep := deps[i]
+		for _, vuln := range result.Vulns {
+			vulnerabilities = append(vulnerabilities, convertOSVVuln(vuln, dep))
+		}
+
+		// clean results are cached too, they are the common case
+		if o.cache != nil {
+			if data, err := json.Marshal(result.Vulns); err == nil {
+				o.cache.Set(dependencyKey(dep), data, o.cacheTTL)
+			}
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// converts OSV vulnerability to project format
+func convertOSVVuln(osv OSVVulnerability, dep Dependency) Vulnerability {
+	vuln := Vulnerability{
+		ID:         osv.ID,
+		Summary:    osv.Summary,
+		Details:    osv.Details,
+		Published:  osv.Published,
+		Modified:   osv.Modified,
+		Aliases:    osv.Aliases,
+		Severity:   "medium",
+		Dependency: dep,
+	}
+
+	// extract CVSS score
+	scored := false
+	for _, severity := range osv.Severity {
+		if severity.Type == "CVSS_V3" {
+			// Parse CVSS score (simplified)
+			if strings.Contains(severity.Score, "CVSS:3.1/AV:") {
+				vuln.Severity = extractCVSSSeverity(severity.Score)
+				scored = true
+			}
+		}
+	}
+	if !scored && osv.DatabaseSpecific.Severity != "" {
+		vuln.Severity = normalizeSeverity(osv.DatabaseSpecific.Severity)
+	}
+
+	// extract references
+	for _, ref := range osv.References {
+		vuln.References = append(vuln.References, ref.URL)
+	}
+
+	return vuln
+}
+
+// extracts severity from CVSS score
+func extractCVSSSeverity(cvssString string) string {
+	if strings.Contains(cvssString, "/AV:N/") && strings.Contains(cvssString, "/AC:L/") {
+		return "high"
+	}
+	if strings.Contains(cvssString, "/PR:N/") {
+		return "critical"
+	}
+	return "medium"
+}
+
+// queries the GitHub Advisory Database through the GraphQL API
+type GitHubSource struct {
+	Endpoint string
+	token    string
+	client   *http.Client
+}
+
+func NewGitHubSource(token string) *GitHubSource {
+	return &GitHubSource{
+		Endpoint: "https://api.github.com/graphql",
+		token:    token,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (g *GitHubSource) Name() string {
+	return "github"
+}
+
+const githubAdvisoryQuery = `query($ecosystem: SecurityAdvisoryEcosystem!, $package: String!) {
+  securityVulnerabilities(ecosystem: $ecosystem, package: $package, first: 100) {
+    nodes {
+      severity
+      vulnerableVersionRange
+      advisory {
+        ghsaId
+        summary
+        description
+        publishedAt
+        updatedAt
+        identifiers { type value }
+        references { url }
+      }
+    }
+  }
+}`
+
+// issues one query per package, keeping advisories whose vulnerable range
+// contains the dependency version
+func (g *GitHubSource) Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	var vulnerabilities []Vulnerability
+
+	for _, dep := range deps {
+		ecosystem, ok := githubEcosystems[dep.Ecosystem]
+		if !ok {
+			continue
+		}
+
+		requestBody := map[string]interface{}{
+			"query": githubAdvisoryQuery,
+			"variables": map[string]string{
+				"ecosystem": ecosystem,
+				"package":   dep.Name,
+			},
+		}
+
+		jsonData, err := json.Marshal(requestBody)
+		if err != nil {
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.Endpoint, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return vulnerabilities, fmt.Errorf("failed to create GitHub request: %w", err)
+		}
+		req.Header.Set("Authorization", "bearer "+g.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		var response struct {
+			Data struct {
+				SecurityVulnerabilities struct {
+					Nodes []struct {
+						Severity               string `json:"severity"`
+						VulnerableVersionRange string `json:"vulnerableVersionRange"`
+						Advisory               struct {
+							GHSAID      string `json:"ghsaId"`
+							Summary     string `json:"summary"`
+							Description string `json:"description"`
+							PublishedAt string `json:"publishedAt"`
+							UpdatedAt   string `json:"updatedAt"`
+							Identifiers []struct {
+								Type  string `json:"type"`
+								Value string `json:"value"`
+							} `json:"identifiers"`
+							References []struct {
+								URL string `json:"url"`
+							} `json:"references"`
+						} `json:"advisory"`
+					} `json:"nodes"`
+				} `json:"securityVulnerabilities"`
+			} `json:"data"`
+			// GraphQL reports failures such as rate limits with status 200
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+
+		if err := doJSON(g.client, req, &response); err != nil {
+			return vulnerabilities, fmt.Errorf("GitHub advisory query failed: %w", err)
+		}
+		if len(response.Errors) > 0 {
+			messages := make([]string, len(response.Errors))
+			for i, e := range response.Errors {
+				messages[i] = e.Message
+			}
+			return vulnerabilities, fmt.Errorf("GitHub advisory query failed: %s", strings.Join(messages, "; "))
+		}
+
+		for _, node := range response.Data.SecurityVulnerabilities.Nodes {
+			if !versionInRange(dep.Version, node.VulnerableVersionRange) {
+				continue
+			}
+
+			vuln := Vulnerability{
+				ID:         node.Advisory.GHSAID,
+				Summary:    node.Advisory.Summary,
+				Details:    node.Advisory.Description,
+				Severity:   normalizeSeverity(node.Severity),
+				Published:  node.Advisory.PublishedAt,
+				Modified:   node.Advisory.UpdatedAt,
+				Dependency: dep,
+			}
+			for _, id := range node.Advisory.Identifiers {
+				if id.Value != vuln.ID {
+					vuln.Aliases = append(vuln.Aliases, id.Value)
+				}
+			}
+			for _, ref := range node.Advisory.References {
+				vuln.References = append(vuln.References, ref.URL)
+			}
+			vulnerabilities = append(vulnerabilities, vuln)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// maps our ecosystem names to GitHub's SecurityAdvisoryEcosystem enum
+var githubEcosystems = map[string]string{
+	"npm":       "NPM",
+	"PyPI":      "PIP",
+	"Go":        "GO",
+	"RubyGems":  "RUBYGEMS",
+	"Maven":     "MAVEN",
+	"Packagist": "COMPOSER",
+	"crates.io": "RUST",
+	"NuGet":     "NUGET",
+}
+
+// queries the Snyk v1 package test API
+type SnykSource struct {
+	Endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewSnykSource(apiKey string) *SnykSource {
+	return &SnykSource{
+		Endpoint: "https://snyk.io/api/v1",
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *SnykSource) Name() string {
+	return "snyk"
+}
+
+// tests each package version individually
+func (s *SnykSource) Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	var vulnerabilities []Vulnerability
+
+	for _, dep := range deps {
+		path, ok := snykTestPath(dep)
+		if !ok {
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Endpoint+"/test/"+path, nil)
+		if err != nil {
+			return vulnerabilities, fmt.Errorf("failed to create Snyk request: %w", err)
+		}
+		req.Header.Set("Authorization", "token "+s.apiKey)
+
+		var response struct {
+			Issues struct {
+				Vulnerabilities []struct {
+					ID               string  `json:"id"`
+					Title            string  `json:"title"`
+					Description      string  `json:"description"`
+					Severity         string  `json:"severity"`
+					CVSSScore        float64 `json:"cvssScore"`
+					PublicationTime  string  `json:"publicationTime"`
+					ModificationTime string  `json:"modificationTime"`
+					References       []struct {
+						URL string `json:"url"`
+					} `json:"references"`
+					Identifiers map[string][]string `json:"identifiers"`
+				} `json:"vulnerabilities"`
+			} `json:"issues"`
+		}
+
+		if err := doJSON(s.client, req, &response); err != nil {
+			return vulnerabilities, fmt.Errorf("Snyk test failed: %w", err)
+		}
+
+		for _, v := range response.Issues.Vulnerabilities {
+			vuln := Vulnerability{
+				ID:         v.ID,
+				Summary:    v.Title,
+				Details:    v.Description,
+				Severity:   normalizeSeverity(v.Severity),
+				CVSS:       v.CVSSScore,
+				Published:  v.PublicationTime,
+				Modified:   v.ModificationTime,
+				Dependency: dep,
+			}
+			for _, ids := range v.Identifiers {
+				vuln.Aliases = append(vuln.Aliases, ids...)
+			}
+			for _, ref := range v.References {
+				vuln.References = append(vuln.References, ref.URL)
+			}
+			vulnerabilities = append(vulnerabilities, vuln)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// builds the Snyk test endpoint path for a dependency
+func snykTestPath(dep Dependency) (string, bool) {
+	name := url.PathEscape(dep.Name)
+	version := url.PathEscape(dep.Version)
+
+	switch dep.Ecosystem {
+	case "npm":
+		return fmt.Sprintf("npm/%s/%s", name, version), true
+	case "PyPI":
+		return fmt.Sprintf("pip/%s/%s", name, version), true
+	case "RubyGems":
+		return fmt.Sprintf("rubygems/%s/%s", name, version), true
+	case "Maven":
+		group, artifact, ok := strings.Cut(dep.Name, ":")
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("maven/%s/%s/%s", url.PathEscape(group), url.PathEscape(artifact), version), true
+	default:
+		return "", false
+	}
+}
+
+// matches dependencies against OSV-format advisories stored on disk,
+// either a single JSON file or a directory of them
+type OfflineSource struct {
+	Path string
+}
+
+func NewOfflineSource(path string) *OfflineSource {
+	return &OfflineSource{Path: path}
+}
+
+func (o *OfflineSource) Name() string {
+	return "offline"
+}
+
+func (o *OfflineSource) Query(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	var vulnerabilities []Vulnerability
+
+	advisories, err := loadOSVAdvisories(o.Path)
+	if err != nil {
+		return vulnerabilities, err
+	}
+
+	for _, dep := range deps {
+		for _, advisory := range advisories {
+			if osvAffects(advisory, dep) {
+				vulnerabilities = append(vulnerabilities, convertOSVVuln(advisory, dep))
+			}
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// reads OSV advisories from a file (single advisory or array) or directory
+func loadOSVAdvisories(path string) ([]OSVVulnerability, error) {
+	files, err := advisoryFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offline database: %w", err)
+	}
+
+	var advisories []OSVVulnerability
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read advisory %s: %w", file, err)
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] == '[' {
+			var list []OSVVulnerability
+			if err := json.Unmarshal(data, &list); err != nil {
+				return nil, fmt.Errorf("failed to parse advisory %s: %w", file, err)
+			}
+			advisories = append(advisories, list...)
+			continue
+		}
+
+		var advisory OSVVulnerability
+		if err := json.Unmarshal(data, &advisory); err != nil {
+			return nil, fmt.Errorf("failed to parse advisory %s: %w", file, err)
+		}
+		advisories = append(advisories, advisory)
+	}
+
+	return advisories, nil
+}
+
+// returns path when it is a file, or the .json files under it
+func advisoryFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() && strings.HasSuffix(strings.ToLower(p), ".json") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// reports whether an OSV advisory applies to a dependency version
+func osvAffects(advisory OSVVulnerability, dep Dependency) bool {
+	for _, affected := range advisory.Affected {
+		if !strings.EqualFold(affected.Package.Ecosystem, mapToOSVEcosystem(dep.Ecosystem)) ||
+			affected.Package.Name != dep.Name {
+			continue
+		}
+
+		for _, v := range affected.Versions {
+			if v == dep.Version {
+				return true
+			}
+		}
+
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			if versionInEvents(dep.Version, r.Events) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluates an OSV introduced/fixed/last_affected event list against a
+// version
+func versionInEvents(version string, events []OSVEvent) bool {
+	affected := false
+	for _, event := range events {
+		if event.Introduced != "" && (event.Introduced == "0" || compareVersions(version, event.Introduced) >= 0) {
+			affected = true
+		}
+		if event.Fixed != "" && compareVersions(version, event.Fixed) >= 0 {
+			affected = false
+		}
+		if event.LastAffected != "" && compareVersions(version, event.LastAffected) > 0 {
+			affected = false
+		}
+	}
+	return affected
+}
+
+// maps provider severities onto critical/high/medium/low
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "critical"
+	case "high":
+		return "high"
+	case "moderate", "medium":
+		return "medium"
+	case "low":
+		return "low"
+	default:
+		return "medium"
+	}
+}
+
+// performs a request and decodes a JSON response
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// share of all checked matches above which a whitelist entry is
+// reported as too broad
+const broadWhitelistShare = 0.2
+
+// how often a whitelist entry suppressed a match during a scan
+type WhitelistStats struct {
+	Entry      string `json:"entry"`
+	Suppressed int    `json:"suppressed"`
+}
+
+// counts whitelist checks and hits over one scan
+type whitelistCounter struct {
+	mu      sync.Mutex
+	checked int
+	hits    map[string]int
+}
+
+func newWhitelistCounter() *whitelistCounter {
+	return &whitelistCounter{hits: make(map[string]int)}
+}
+
+func (c *whitelistCounter) record(entry string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked++
+	if entry != "" {
+		c.hits[entry]++
+	}
+}
+
+func (c *whitelistCounter) list() []WhitelistStats {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var list []WhitelistStats
+	for entry, n := range c.hits {
+		list = append(list, WhitelistStats{Entry: entry, Suppressed: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Suppressed != list[j].Suppressed {
+			return list[i].Suppressed > list[j].Suppressed
+		}
+		return list[i].Entry < list[j].Entry
+	})
+	return list
+}
+
+// reports whether a match in a file is whitelisted, counting which entry
+// hid it; entry paths are relative to root, when it is set
+func (s *Scanner) isWhitelisted(root, filePath, value string) bool {
+	lower := strings.ToLower(value)
+	for _, entry := range s.config.Whitelist {
+		if strings.Contains(lower, strings.ToLower(entry.Value)) && whitelistApplies(entry, root, filePath) {
+			s.whitelist.record(entry.String())
+			return true
+		}
+	}
+	s.whitelist.record("")
+	return false
+}
+
+// logs per-entry suppression counts and warns about entries broad
+// enough to hide real secrets, such as "test"
+func (s *Scanner) logWhitelistStats(stats []WhitelistStats) {
+	c := s.whitelist
+	if c == nil || len(stats) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	checked := c.checked
+	c.mu.Unlock()
+
+	for _, st := range stats {
+		share := float64(st.Suppressed) / float64(checked)
+		s.logger.Debug("whitelist suppressed matches", "entry", st.Entry, "suppressed", st.Suppressed, "checked", checked)
+		if share >= broadWhitelistShare && st.Suppressed >= 3 {
+			s.logger.Warn("whitelist entry is broad and may hide real secrets; consider a more specific entry",
+				"entry", st.Entry, "share", fmt.Sprintf("%.0f%%", share*100))
+		}
+	}
+}
+
+// reports whether an entry applies in a file: everywhere, or in the files
+// its paths match, relative to the root of a path scan
+func whitelistApplies(entry config.WhitelistEntry, root, filePath string) bool {
+	if len(entry.Paths) == 0 {
+		return true
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, filePath); err == nil && filepath.IsLocal(rel) {
+			filePath = rel
+		}
+	}
+	filePath = filepath.ToSlash(filePath)
+	for _, pattern := range entry.Paths {
+		if matchGlob(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/badge"
+	"github.com/JohnnyCannelloni/gitguardian/internal/findings"
+)
+
+// serves a badge for a repository from the findings store, as the scans
+// recorded there last saw it: its risk score, or with type=status the
+// outcome of its last scan, on branch when given:
+//
+//	GET /badge?repo=<path or name>[&type=status][&branch=<name>]  SVG badge
+//	GET /badge?repo=...&format=json                              the last scan, findings and risk
+//	GET /badge?repo=...&format=shields                           shields.io endpoint JSON
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	if s.findingsPath == "" {
+		writeError(w, http.StatusNotFound, "the findings store is not enabled")
+		return
+	}
+	query := r.URL.Query()
+	repo := query.Get("repo")
+	if repo == "" {
+		writeError(w, http.StatusBadRequest, "missing repo")
+		return
+	}
+	kind := query.Get("type")
+	if kind == "" {
+		kind = badge.KindRisk
+	}
+	branch := query.Get("branch")
+
+	// read on every request, since scans update the store
+	store, err := findings.Open(s.findingsPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	scope, err := badge.FindScope(store.ScannedScopes(), repo)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	now := time.Now()
+	b, err := badge.FromStore(store, scope, branch, kind, now)
+	if err != nil {
+		status := http.StatusNotFound
+		if kind != badge.KindStatus && kind != badge.KindRisk {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	switch format := query.Get("format"); format {
+	case "", "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprint(w, b.SVG())
+	case "json":
+		scan, _ := store.LastScan(scope, branch)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"repo":      scope,
+			"last_scan": scan,
+			"findings":  len(store.Current(scope)),
+			"risk":      badge.StoreRisk(store, scope, now),
+			"badge":     b.Shields(),
+		})
+	case "shields":
+		writeJSON(w, http.StatusOK, b.Shields())
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q: use svg, json or shields", format))
+	}
+}
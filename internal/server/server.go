@@ -0,0 +1,253 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// largest request body accepted by POST /scan
+const maxUploadSize = 100 << 20
+
+// exposes the scanner over HTTP:
+//
+//	POST /scan    tarball (application/x-tar, gzipped or not) or JSON file list
+//	GET  /health  liveness probe
+//	GET  /rules   the configured secret rules
+//	GET  /badge   a repository's risk score, from the findings store
+type Server struct {
+	config atomic.Pointer[config.Config]
+	token  string
+	mux    *http.ServeMux
+
+	findingsPath string // the store /badge reads; empty disables it
+	publicBadges bool   // serve /badge without the token
+}
+
+// creates a server; a non-empty token is required as a bearer token on
+// every request except /health
+func New(cfg *config.Config, token string) *Server {
+	s := &Server{token: token, mux: http.NewServeMux()}
+	s.config.Store(cfg)
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/rules", s.authorized(s.handleRules))
+	s.mux.HandleFunc("/scan", s.authorized(s.handleScan))
+	s.mux.HandleFunc("/badge", func(w http.ResponseWriter, r *http.Request) {
+		if s.publicBadges {
+			s.handleBadge(w, r)
+			return
+		}
+		s.authorized(s.handleBadge)(w, r)
+	})
+	return s
+}
+
+// serves /badge from the findings store at path; with public set no
+// token is needed, so badges can be embedded in dashboards and READMEs.
+// Call before serving.
+func (s *Server) SetFindings(path string, public bool) {
+	s.findingsPath = path
+	s.publicBadges = public
+}
+
+// swaps in a new configuration; requests already running finish with the
+// one they started with
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// a file in a JSON scan request
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// the JSON form of a scan request
+type ScanRequest struct {
+	Files []File `json:"files"`
+}
+
+// a secret rule as listed by GET /rules
+type Rule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "missing or invalid token")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+
+	cfg := s.config.Load()
+	rules := make([]Rule, 0, len(cfg.SecretPatterns))
+	for _, p := range cfg.SecretPatterns {
+		rules = append(rules, Rule{
+			Name:        p.Name,
+			Pattern:     p.Pattern,
+			Description: p.Description,
+			Severity:    p.Severity,
+		})
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+
+	cfg := s.config.Load()
+	scanType, err := parseScanType(r.URL.Query().Get("type"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the scanner's results, or with format=findings the public model of
+	// package report
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "findings" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q: use json or findings", format))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxUploadSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var blobs []scanner.Blob
+	switch mediaType {
+	case "application/json":
+		blobs, err = readFileList(body)
+	case "application/x-tar":
+		blobs, err = readTarball(body, cfg.MaxFileSize)
+	case "application/gzip", "application/x-gzip", "application/x-tar+gzip":
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(body); err == nil {
+			blobs, err = readTarball(gz, cfg.MaxFileSize)
+		}
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "send application/json or a tarball")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// a scanner per request, since scanners hold per-scan settings; the
+	// scan stops if the client goes away
+	results := scanner.New(cfg).ScanBlobs(r.Context(), blobs, scanType)
+	if format == "findings" {
+		writeJSON(w, http.StatusOK, results.Report(""))
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func parseScanType(value string) (scanner.ScanType, error) {
+	switch value {
+	case "", "all":
+		return scanner.ScanTypeAll, nil
+	case "secrets":
+		return scanner.ScanTypeSecrets, nil
+	case "dependencies":
+		return scanner.ScanTypeDependencies, nil
+	case "social":
+		return scanner.ScanTypeSocial, nil
+	default:
+		return 0, fmt.Errorf("unknown scan type %q", value)
+	}
+}
+
+func readFileList(r io.Reader) ([]scanner.Blob, error) {
+	var req ScanRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	blobs := make([]scanner.Blob, 0, len(req.Files))
+	for _, f := range req.Files {
+		if f.Path == "" {
+			return nil, fmt.Errorf("file without a path")
+		}
+		blobs = append(blobs, scanner.Blob{Path: f.Path, Content: f.Content})
+	}
+	return blobs, nil
+}
+
+// reads the regular files of a tar stream that a path scan would read
+func readTarball(r io.Reader, maxSize int64) ([]scanner.Blob, error) {
+	var blobs []scanner.Blob
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return blobs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxSize || !scanner.ShouldScan(name) {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+		blobs = append(blobs, scanner.Blob{Path: name, Content: string(data)})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,181 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// the version control systems a working copy can be in
+const (
+	Auto       = "auto"
+	Git        = "git"
+	Mercurial  = "hg"
+	Subversion = "svn"
+	None       = "none"
+)
+
+// the directory each system keeps at the root of a working copy
+var markers = []struct {
+	dir  string
+	kind string
+}{
+	{".git", Git},
+	{".hg", Mercurial},
+	{".svn", Subversion},
+}
+
+// reports whether kind is a known setting; empty means auto
+func Valid(kind string) bool {
+	switch kind {
+	case "", Auto, Git, Mercurial, Subversion, None:
+		return true
+	}
+	return false
+}
+
+// returns the system managing the working copy at path, looking for the
+// nearest .git, .hg or .svn from path up; None when there is none
+func Detect(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return None
+	}
+	for dir := abs; ; {
+		for _, m := range markers {
+			// .git is a file in worktrees and submodules
+			if _, err := os.Stat(filepath.Join(dir, m.dir)); err == nil {
+				return m.kind
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return None
+		}
+		dir = parent
+	}
+}
+
+// returns kind, or what Detect finds at path when kind is empty or auto
+func Resolve(kind, path string) string {
+	if kind == "" || kind == Auto {
+		return Detect(path)
+	}
+	return kind
+}
+
+// returns the files under path that the working copy adds or modifies,
+// including new files not yet added that the system does not ignore;
+// deleted files are left out. Paths are joined to path.
+func ChangedFiles(ctx context.Context, kind, path string) ([]string, error) {
+	var (
+		names []string
+		err   error
+	)
+	switch kind {
+	case Git:
+		names, err = gitChangedFiles(ctx, path)
+	case Mercurial:
+		names, err = hgChangedFiles(ctx, path)
+	case Subversion:
+		names, err = svnChangedFiles(ctx, path)
+	default:
+		return nil, fmt.Errorf("no version control to ask for changed files (vcs is %s)", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		file := filepath.Join(path, filepath.FromSlash(name))
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+		// added directories and paths removed from disk are not files to scan
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// changes against HEAD, staged or not, and untracked files; a repository
+// without commits yet lists what is staged
+func gitChangedFiles(ctx context.Context, path string) ([]string, error) {
+	changed, err := run(ctx, path, "git", "diff", "HEAD", "--name-only", "-z", "--relative", "--diff-filter=ACMR")
+	if err != nil {
+		if changed, err = run(ctx, path, "git", "ls-files", "-z", "--cached"); err != nil {
+			return nil, err
+		}
+	}
+	untracked, err := run(ctx, path, "git", "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return append(splitNull(changed), splitNull(untracked)...), nil
+}
+
+// hg status prints the paths relative to the current directory when
+// given one
+func hgChangedFiles(ctx context.Context, path string) ([]string, error) {
+	out, err := run(ctx, path, "hg", "status", "--modified", "--added", "--unknown", "--no-status", "--print0", ".")
+	if err != nil {
+		return nil, err
+	}
+	return splitNull(out), nil
+}
+
+// svn status prints seven status columns and a space before each path:
+//
+//	M       src/config.py
+//	?       notes.txt
+func svnChangedFiles(ctx context.Context, path string) ([]string, error) {
+	out, err := run(ctx, path, "svn", "status", "--non-interactive")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if len(line) < 9 {
+			continue
+		}
+		switch line[0] {
+		case 'A', 'M', 'R', '?':
+			names = append(names, filepath.ToSlash(line[8:]))
+		}
+	}
+	return names, nil
+}
+
+func run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s %s: %s", name, args[0], msg)
+		}
+		return nil, fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return out, nil
+}
+
+func splitNull(out []byte) []string {
+	var names []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// the logging flags every command takes
+type logFlags struct {
+	level  *string
+	format *string
+	quiet  *bool
+}
+
+func addLogFlags(fs *flag.FlagSet) logFlags {
+	return logFlags{
+		level:  fs.String("log-level", "", "Log level: debug, info, warn or error (default: log_level, or info)"),
+		format: fs.String("log-format", "", "Log format: text or json (default: log_format, or text)"),
+		quiet:  fs.Bool("quiet", false, "Only log errors"),
+	}
+}
+
+// applies the flags over the configuration's log settings and sets up
+// its logger on stderr, keeping stdout for results
+func (f logFlags) setup(cfg *config.Config) error {
+	if *f.level != "" {
+		cfg.LogLevel = *f.level
+	}
+	if *f.format != "" {
+		cfg.LogFormat = *f.format
+	}
+	if *f.quiet {
+		cfg.LogLevel = "error"
+	}
+	return cfg.SetupLogger(os.Stderr)
+}
@@ -1,46 +1,228 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/JohnnyCannelloni/gitguardian/internal/cache"
 	"github.com/JohnnyCannelloni/gitguardian/internal/config"
 	"github.com/JohnnyCannelloni/gitguardian/internal/hooks"
+	"github.com/JohnnyCannelloni/gitguardian/internal/i18n"
+	"github.com/JohnnyCannelloni/gitguardian/internal/notify"
+	"github.com/JohnnyCannelloni/gitguardian/internal/report"
 	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+	"github.com/JohnnyCannelloni/gitguardian/internal/vcs"
 )
 
+// subcommands, selected by the first argument; anything else is a scan
+var commands = map[string]func(args []string) error{
+	"action":          runActionCommand,
+	"badge":           runBadgeCommand,
+	"bundle":          runBundleCommand,
+	"cache":           runCacheCommand,
+	"config":          runConfigCommand,
+	"feedback":        runFeedbackCommand,
+	"history":         runHistoryCommand,
+	"hook":            runHookCommand,
+	"install-hooks":   runInstallHooksCommand,
+	"notify":          runNotifyCommand,
+	"queue":           runQueueCommand,
+	"report":          runReportCommand,
+	"rules":           runRulesCommand,
+	"scan-push-range": runPushRangeCommand,
+	"scan-range":      runScanRangeCommand,
+	"serve":           runServeCommand,
+	"store":           runStoreCommand,
+	"sync":            runSyncCommand,
+	"watch":           runWatchCommand,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				fatalf(exitCode(err), "%s: %v", os.Args[1], err)
+			}
+			return
+		}
+		if os.Args[1] == "scan" {
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		}
+	}
+
 	var (
 		scanPath     = flag.String("path", ".", "Path to scan")
 		installHooks = flag.Bool("install-hooks", false, "Install Git hooks")
 		configFile   = flag.String("config", "", "Configuration file path")
 		verbose      = flag.Bool("verbose", false, "Verbose output")
+		noPlaintext  = flag.Bool("no-plaintext", false, "Only output masks and hashes of secrets, never plaintext")
 		onlySecrets  = flag.Bool("secrets-only", false, "Only scan for secrets")
 		onlyDeps     = flag.Bool("deps-only", false, "Only scan dependencies")
-		format       = flag.String("format", "text", "Output format (text, json)")
+		format       = flag.String("format", "text", "Output format ("+strings.Join(report.Formats(), ", ")+")")
+		rules        = flag.String("rules", "", "Comma-separated list of rules or tag:<category> to run (default: all)")
+		excludeRules = flag.String("exclude-rules", "", "Comma-separated list of rules to skip")
+		shard        = flag.String("shard", "", "Only scan shard N of M files (e.g. 3/8)")
+		staged       = flag.Bool("staged", false, "Scan the staged content of the git index at -path")
+		failOn       = flag.String("fail-on", "", "Only exit non-zero for issues at or above this severity (low, medium, high, critical)")
+		verify       = flag.Bool("verify", false, "Check detected credentials against provider APIs")
+		rev          = flag.String("rev", "", "Scan the tree of this git revision at -path without checking it out")
+		manifestFile = flag.String("manifest", "", "Scan every repository listed in this manifest (repos.yaml)")
+		listFiles    = flag.Bool("list-files", false, "Print the files that would be scanned and exit")
+		listSkipped  = flag.Bool("report-skipped", false, "List skipped files and why in the results")
+		fix          = flag.Bool("fix", false, "Append suggested entries to the scanned directory's .gitignore")
+		profileRules = flag.Bool("profile-rules", false, "Time every secret rule and file and print the slowest to stderr")
+		noProgress   = flag.Bool("no-progress", false, "Do not show scan progress, even on a terminal")
+		allowUnsafe  = flag.Bool("allow-unsafe-patterns", false, "Use -pattern regexes that fail the safety checks, with a warning")
+		incremental  = flag.Bool("incremental", false, "Reuse the findings of files unchanged since an earlier scan (also \"cache.incremental\")")
+		gitignore    = flag.Bool("respect-gitignore", false, "Skip files git ignores (also \"respect_gitignore\")")
+		diffFile     = flag.String("diff", "", "Scan only the lines a unified diff adds (git, hg or svn diff output); - reads stdin")
+		changed      = flag.Bool("changed", false, "Only scan the files the working copy at -path adds or modifies")
+		diffOnly     = flag.Bool("diff-only", false, "With -staged, only report findings on the lines the commit adds or modifies (also \"diff_only\")")
+		vcsKind      = flag.String("vcs", "", "Version control at -path: auto, git, hg, svn or none (also \"vcs\")")
+		filename     = flag.String("filename", "stdin", "Name to scan content read from stdin (scan -) under, which decides the rules that apply, e.g. config.yml")
+		exitZero     = flag.Bool("exit-zero", false, "Exit 0 even when issues are found, for pipelines that only report; scan and configuration errors still fail")
+		patterns     stringList
+		includes     stringList
+		excludes     stringList
 	)
-	flag.Parse()
+	flag.Var(&patterns, "pattern", "Additional pattern as name=regex (repeatable)")
+	flag.Var(&includes, "include", "Only scan paths matching this glob (repeatable)")
+	flag.Var(&excludes, "exclude", "Skip paths matching this glob (repeatable)")
+	logging := addLogFlags(flag.CommandLine)
+	output := addASCIIFlag(flag.CommandLine)
+	storage := addStorageFlags(flag.CommandLine)
+	timeout := addTimeoutFlag(flag.CommandLine)
+	parseFlags(flag.CommandLine, os.Args[1:])
+
+	// "gitguardian scan -" reads the content to scan from stdin
+	readStdin := false
+	switch {
+	case flag.NArg() == 1 && flag.Arg(0) == "-":
+		readStdin = true
+	case flag.NArg() > 0:
+		fatalf(exitConfig, "Unexpected argument %q: pass the directory with -path, or - to read stdin", flag.Arg(0))
+	}
 
 	cfg, err := config.Load(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatalf(exitConfig, "Failed to load configuration: %v", err)
 	}
 
 	if *verbose {
 		cfg.Verbose = true
 	}
+	storage.apply(cfg)
+	if err := logging.setup(cfg); err != nil {
+		fatalf(exitConfig, "Invalid logging settings: %v", err)
+	}
+	if err := i18n.SetLanguage(cfg.Language); err != nil {
+		fatalf(exitConfig, "Invalid language: %v", err)
+	}
+	logger := cfg.Log()
+
+	if *noPlaintext {
+		cfg.NoPlaintext = true
+	}
+
+	if *verify {
+		cfg.Verify.Enabled = true
+	}
+
+	if *gitignore {
+		cfg.RespectGitignore = true
+	}
+
+	if *failOn != "" {
+		cfg.FailOn = *failOn
+	}
+	if cfg.FailOn != "" && !scanner.ValidSeverity(cfg.FailOn) {
+		fatalf(exitConfig, "Invalid fail-on severity %q: use low, medium, high or critical", cfg.FailOn)
+	}
+
+	if *diffOnly {
+		if !*staged {
+			fatalf(exitConfig, "-diff-only needs -staged; -diff, scan-range and history already scan only added lines")
+		}
+		cfg.DiffOnly = true
+	}
+
+	if *vcsKind != "" {
+		if !vcs.Valid(*vcsKind) {
+			fatalf(exitConfig, "Invalid vcs %q: use auto, git, hg, svn or none", *vcsKind)
+		}
+		cfg.VCS = *vcsKind
+	}
+	// hooks, the index, revisions and Git LFS are git's alone; other
+	// working copies get path, -changed and -diff scans; manifest clones
+	// are always git
+	workingCopy := vcs.Resolve(cfg.VCS, *scanPath)
+	if workingCopy != vcs.Git && *manifestFile == "" {
+		if option := gitOnlyOption(*installHooks, *staged, *rev); option != "" {
+			fatalf(exitConfig, "%s needs git, but vcs is %s at %s; use -changed or -diff instead", option, workingCopy, *scanPath)
+		}
+		cfg.LFS.Enabled = false
+	}
+
+	if *allowUnsafe {
+		cfg.AllowUnsafePatterns = true
+	}
+	for _, p := range patterns {
+		name, expr, ok := strings.Cut(p, "=")
+		if !ok || strings.TrimSpace(name) == "" || expr == "" {
+			fatalf(exitConfig, "Invalid pattern %q: expected name=regex", p)
+		}
+		if err := cfg.AddPattern(strings.TrimSpace(name), expr); err != nil {
+			fatalf(exitConfig, "Invalid pattern %q: %v", p, err)
+		}
+	}
+
+	if err := cfg.SelectPatterns(splitList(*rules), splitList(*excludeRules)); err != nil {
+		fatalf(exitConfig, "Invalid rule selection: %v", err)
+	}
 
 	if *installHooks {
-		if err := hooks.Install(*scanPath); err != nil {
-			log.Fatalf("Failed to install hooks: %v", err)
+		if cfg.NoWrite {
+			fatalf(exitConfig, "Cannot install hooks with -no-write")
+		}
+		// the hooks print to whatever terminal commits are made from, so
+		// only an explicit -ascii applies to them
+		if err := hooks.Install(*scanPath, *output.value, logger); err != nil {
+			fatalf(exitError, "Failed to install hooks: %v", err)
 		}
-		fmt.Println("Git hooks installed successfully!")
 		return
 	}
 
+	if _, err := report.Get(*format); err != nil {
+		fatalf(exitConfig, "Invalid output format: %v", err)
+	}
+
 	s := scanner.New(cfg)
+	s.SetPathFilters(includes, excludes)
+	s.SetReportSkipped(*listSkipped)
+	s.SetProfiling(*profileRules)
+
+	if *shard != "" {
+		index, total, err := scanner.ParseShard(*shard)
+		if err != nil {
+			fatalf(exitConfig, "Invalid shard: %v", err)
+		}
+		s.SetShard(index, total)
+	}
+
+	if *listFiles {
+		files, err := s.ListFiles(*scanPath)
+		if err != nil {
+			fatalf(exitError, "Failed to list files: %v", err)
+		}
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		return
+	}
 
 	// determine scan type
 	scanType := scanner.ScanTypeAll
@@ -50,28 +232,195 @@ func main() {
 		scanType = scanner.ScanTypeDependencies
 	}
 
-	results, err := s.ScanPath(*scanPath, scanType)
+	sinks, err := notify.NewSinks(cfg.Notify)
 	if err != nil {
-		log.Fatalf("Scan failed: %v", err)
+		fatalf(exitConfig, "Invalid notification settings: %v", err)
+	}
+	if cfg.Notify.OnlyNew && !cfg.Findings.Enabled {
+		fatalf(exitConfig, "Invalid notification settings: only_new requires the findings store (findings.enabled)")
 	}
 
-	if err := outputResults(results, *format); err != nil {
-		log.Fatalf("Failed to output results: %v", err)
+	if *incremental {
+		cfg.Cache.Incremental = true
+	}
+	var scanCache cache.Backend
+	if (cfg.DependencyAPIs.CacheEnabled && scanType != scanner.ScanTypeSecrets) || cfg.Cache.Incremental {
+		if scanCache, err = openCache(cfg); err != nil {
+			logger.Warn("cache unavailable", "error", err)
+		} else {
+			s.SetCache(scanCache)
+			if cfg.Cache.Incremental {
+				s.SetResultsCache(scanCache)
+			}
+		}
 	}
 
-	// exit with error code if issues found
-	if results.HasIssues() {
-		os.Exit(1)
+	// the progress line goes to stderr, and only when a person is watching
+	var bar *progressBar
+	if !*noProgress && *manifestFile == "" && isTerminal(os.Stdout) && isTerminal(os.Stderr) {
+		bar = startProgress(os.Stderr)
+		s.SetProgress(bar.update)
 	}
-}
 
-func outputResults(results *scanner.Results, format string) error {
-	switch format {
-	case "json":
-		return results.OutputJSON(os.Stdout)
-	case "text":
-		return results.OutputText(os.Stdout)
+	ctx, stop := timeout.context()
+	defer stop()
+
+	var results *scanner.Results
+	switch {
+	case readStdin:
+		results, err = scanStdin(ctx, s, *filename, cfg.MaxFileSize, scanType)
+	case *manifestFile != "":
+		results, err = scanManifest(ctx, *manifestFile, cfg, scanType, includes, excludes)
+	case *diffFile != "":
+		results, err = scanDiff(ctx, s, *diffFile, scanType)
+	case *changed:
+		var files []string
+		if files, err = vcs.ChangedFiles(ctx, workingCopy, *scanPath); err == nil {
+			results = s.ScanChangedFiles(ctx, *scanPath, files, scanType)
+		}
+	case *rev != "":
+		results, err = s.ScanRevision(ctx, *scanPath, *rev, scanType)
+	case *staged:
+		results, err = s.ScanStaged(ctx, *scanPath, scanType)
 	default:
-		return fmt.Errorf("unsupported output format: %s", format)
+		results, err = s.ScanPath(ctx, *scanPath, scanType)
 	}
+	if bar != nil {
+		bar.stop()
+	}
+
+	// persisted before any exit below
+	if scanCache != nil {
+		if closeErr := scanCache.Close(); closeErr != nil {
+			logger.Warn("failed to save cache", "error", closeErr)
+		}
+	}
+
+	if err != nil && ctx.Err() != nil {
+		fatalf(exitError, "Scan %s before anything was scanned", timeout.reason(ctx))
+	}
+	if err != nil {
+		fatalf(exitError, "Scan failed: %v", err)
+	}
+
+	if cfg.Findings.Enabled {
+		scope, scopeRev := *scanPath, *rev
+		if *manifestFile != "" {
+			scope, scopeRev = *manifestFile, ""
+		}
+		if err := trackFindings(cfg, scope, scopeRev, results); err != nil {
+			logger.Warn("findings store unavailable", "error", err)
+		}
+	}
+
+	if *fix && cfg.NoWrite {
+		logger.Warn("-fix ignored: nothing is written with -no-write")
+	} else if *fix && *manifestFile == "" && *rev == "" && !*staged && *diffFile == "" && !readStdin {
+		added, err := scanner.AppendGitignore(*scanPath, results.Issues)
+		if err != nil {
+			logger.Warn("failed to update .gitignore", "error", err)
+		} else if len(added) > 0 {
+			logger.Info("added to .gitignore", "entries", strings.Join(added, ", "))
+		}
+	}
+
+	// deliveries that fail are queued for the next run, unless nothing may
+	// be written
+	var queue *notify.Queue
+	if len(sinks) > 0 && cfg.Notify.Queue.Enabled && !cfg.NoWrite {
+		if queue, err = openNotifyQueue(cfg); err != nil {
+			logger.Warn("notification queue unavailable", "error", err)
+		}
+	}
+	for _, err := range notify.Send(context.Background(), cfg.Notify, sinks, results, queue) {
+		logger.Warn("notification failed", "error", err)
+	}
+	if queue != nil {
+		if err := queue.Save(); err != nil {
+			logger.Warn("failed to save notification queue", "error", err)
+		}
+	}
+
+	if err := report.Write(output.reportWriter(os.Stdout, *format), *format, results); err != nil {
+		fatalf(exitError, "Failed to output results: %v", err)
+	}
+	if results.Profile != nil {
+		results.Profile.Write(output.writer(os.Stderr), 10)
+	}
+
+	// exit with error code if issues found, or if the scan did not get to
+	// everything it should have checked
+	failed := results.HasIssuesAtOrAbove(cfg.FailOn)
+	if failed && !*exitZero {
+		os.Exit(exitFindings)
+	}
+	if results.Incomplete {
+		fatalf(exitError, "Scan %s: the results are partial", timeout.reason(ctx))
+	}
+	if results.HasIssues() && !failed {
+		logger.Warn("issues below the fail-on threshold", "issues", len(results.Issues), "fail_on", cfg.FailOn)
+	}
+}
+
+// returns the first of the options that only work in a git repository
+// that is set, as its flag
+func gitOnlyOption(installHooks, staged bool, rev string) string {
+	switch {
+	case installHooks:
+		return "-install-hooks"
+	case staged:
+		return "-staged"
+	case rev != "":
+		return "-rev"
+	}
+	return ""
+}
+
+// scans what is piped to stdin as a file called name, up to maxSize bytes
+func scanStdin(ctx context.Context, s *scanner.Scanner, name string, maxSize int64, scanType scanner.ScanType) (*scanner.Results, error) {
+	content, err := io.ReadAll(io.LimitReader(os.Stdin, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	defer clear(content)
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("stdin is larger than max_file_size (%d bytes)", maxSize)
+	}
+	return s.ScanBlobs(ctx, []scanner.Blob{{Path: name, Content: string(content)}}, scanType), nil
+}
+
+// scans the added lines of the diff in file, or on stdin for -
+func scanDiff(ctx context.Context, s *scanner.Scanner, file string, scanType scanner.ScanType) (*scanner.Results, error) {
+	if file == "-" {
+		return s.ScanDiff(ctx, os.Stdin, scanType)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return s.ScanDiff(ctx, f, scanType)
+}
+
+// splits a comma-separated flag value into its non-empty parts
+func splitList(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// collects the values of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/manifest"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// scans every repository in a manifest and merges the results into one
+// report whose file paths are prefixed with the repository name; once ctx
+// is done the repositories scanned so far are reported
+func scanManifest(ctx context.Context, file string, base *config.Config, scanType scanner.ScanType, includes, excludes []string) (*scanner.Results, error) {
+	m, err := manifest.Load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []*scanner.Results
+	for _, repo := range m.Repos {
+		if ctx.Err() != nil && len(parts) > 0 {
+			break
+		}
+		results, err := scanManifestRepo(ctx, repo, base, scanType, includes, excludes)
+		if err != nil && ctx.Err() != nil && len(parts) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		parts = append(parts, results)
+	}
+
+	merged, err := scanner.MergeResults(parts)
+	if merged != nil && ctx.Err() != nil {
+		merged.Incomplete = true
+	}
+	return merged, err
+}
+
+func scanManifestRepo(ctx context.Context, repo manifest.Repo, base *config.Config, scanType scanner.ScanType, includes, excludes []string) (*scanner.Results, error) {
+	cfg := *base
+	if repo.Config != "" {
+		loaded, err := config.Load(repo.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configuration: %w", err)
+		}
+		cfg = *loaded
+	}
+	if err := cfg.SelectPatterns(repo.Rules, nil); err != nil {
+		return nil, fmt.Errorf("invalid rule selection: %w", err)
+	}
+
+	switch repo.ScanType {
+	case "", "all":
+	case "secrets":
+		scanType = scanner.ScanTypeSecrets
+	case "dependencies":
+		scanType = scanner.ScanTypeDependencies
+	default:
+		return nil, fmt.Errorf("unknown scan_type %q", repo.ScanType)
+	}
+
+	s := scanner.New(&cfg)
+	s.SetPathFilters(append(append([]string{}, includes...), repo.Include...),
+		append(append([]string{}, excludes...), repo.Exclude...))
+
+	root := repo.Path
+	if repo.URL != "" {
+		dir, err := os.MkdirTemp(base.TempDir, "gitguardian-manifest-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if repo.Ref != "" {
+			args = append(args, "--branch", repo.Ref)
+		}
+		args = append(args, "--", repo.URL, dir)
+		if out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %s", repo.URL, strings.TrimSpace(string(out)))
+		}
+		root = dir
+	}
+
+	var results *scanner.Results
+	var err error
+	if repo.URL == "" && repo.Ref != "" {
+		results, err = s.ScanRevision(ctx, root, repo.Ref, scanType)
+	} else {
+		results, err = s.ScanPath(ctx, root, scanType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issues := range [][]scanner.Issue{results.Issues, results.Suppressed} {
+		for i := range issues {
+			issues[i].File = repoRelative(repo.Name, root, issues[i].File)
+			for j, location := range issues[i].Locations {
+				if k := strings.LastIndex(location, ":"); k > 0 {
+					issues[i].Locations[j] = repoRelative(repo.Name, root, location[:k]) + location[k:]
+				}
+			}
+		}
+	}
+	for i := range results.Skipped {
+		results.Skipped[i].Path = repo.Name + "/" + results.Skipped[i].Path
+	}
+	return results, nil
+}
+
+// rewrites a scanned path as <repo name>/<path within the repo>
+func repoRelative(name, root, file string) string {
+	if filepath.IsAbs(file) || strings.HasPrefix(file, root) {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			file = rel
+		}
+	}
+	return name + "/" + filepath.ToSlash(file)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/ascii"
+)
+
+// the -ascii flag of the commands that print reports or messages
+type asciiFlag struct {
+	fs    *flag.FlagSet
+	value *bool
+}
+
+func addASCIIFlag(fs *flag.FlagSet) asciiFlag {
+	return asciiFlag{
+		fs:    fs,
+		value: fs.Bool("ascii", false, "Replace emoji and other non-ASCII characters in the tool's icons and labels (default: on when not writing to a terminal, or on a legacy Windows console)"),
+	}
+}
+
+// reports whether output to f should be plain ASCII: as the flag says when
+// it was given, otherwise whenever f may not render unicode
+func (a asciiFlag) enabled(f *os.File) bool {
+	set := false
+	a.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "ascii" {
+			set = true
+		}
+	})
+	if set {
+		return *a.value
+	}
+	return !isTerminal(f) || legacyConsole()
+}
+
+// returns f, marked for ASCII icons and labels when enabled for it
+func (a asciiFlag) writer(f *os.File) io.Writer {
+	if a.enabled(f) {
+		return ascii.NewWriter(f)
+	}
+	return f
+}
+
+// returns where to write a report in format; only the text report is
+// converted, machine-readable formats are written as they are
+func (a asciiFlag) reportWriter(f *os.File, format string) io.Writer {
+	if format != "text" {
+		return f
+	}
+	return a.writer(f)
+}
+
+// reports whether this is the classic Windows console, which renders
+// emoji as boxes; Windows Terminal, VS Code and ConEmu announce themselves
+func legacyConsole() bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	return os.Getenv("WT_SESSION") == "" && os.Getenv("TERM_PROGRAM") == "" && os.Getenv("ConEmuANSI") != "ON"
+}
@@ -0,0 +1,207 @@
+// Package gitguardian embeds gitguardian's scanning in other Go programs,
+// such as bots and CI services, without shelling out to the CLI:
+//
+//	s, err := gitguardian.NewScanner(gitguardian.WithSecretsOnly())
+//	if err != nil {
+//		return err
+//	}
+//	result, err := s.ScanDir(ctx, "path/to/repo")
+//
+// Findings are those of package report, the model the CLI's "findings"
+// output format and the server's scan responses share.
+package gitguardian
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+	"github.com/JohnnyCannelloni/gitguardian/pkg/report"
+)
+
+// the finding model of package report, which the CLI and the server
+// report in too
+type (
+	Severity = report.Severity
+	Finding  = report.Finding
+	// what ScanDir found
+	Result = report.Report
+)
+
+const (
+	SeverityCritical = report.SeverityCritical
+	SeverityHigh     = report.SeverityHigh
+	SeverityMedium   = report.SeverityMedium
+	SeverityLow      = report.SeverityLow
+)
+
+type options struct {
+	configFile  string
+	secretsOnly bool
+	rules       []string
+	skipRules   []string
+	patterns    [][2]string
+	include     []string
+	exclude     []string
+	noPlaintext bool
+	verify      bool
+	logger      *slog.Logger
+}
+
+// configures a Scanner
+type Option func(*options)
+
+// loads the configuration from a gitguardian.json file, and only it: a
+// .gitguardian.yml in the working directory is not merged in. Without it
+// the built-in defaults are used and no file is read.
+func WithConfigFile(path string) Option {
+	return func(o *options) { o.configFile = path }
+}
+
+// scans for secrets only, skipping dependency and social engineering checks
+func WithSecretsOnly() Option {
+	return func(o *options) { o.secretsOnly = true }
+}
+
+// restricts secret detection to the named rules; "tag:<name>" selects
+// every rule carrying the tag
+func WithRules(names ...string) Option {
+	return func(o *options) { o.rules = append(o.rules, names...) }
+}
+
+// leaves the named rules out, as WithRules selects them
+func WithoutRules(names ...string) Option {
+	return func(o *options) { o.skipRules = append(o.skipRules, names...) }
+}
+
+// adds a secret rule matching the regular expression
+func WithPattern(name, pattern string) Option {
+	return func(o *options) { o.patterns = append(o.patterns, [2]string{name, pattern}) }
+}
+
+// scans only files matching the globs, relative to the scanned directory
+func WithInclude(globs ...string) Option {
+	return func(o *options) { o.include = append(o.include, globs...) }
+}
+
+// skips files matching the globs, relative to the scanned directory
+func WithExclude(globs ...string) Option {
+	return func(o *options) { o.exclude = append(o.exclude, globs...) }
+}
+
+// masks secrets completely and leaves matched lines out of
+// Finding.Content, so no part of a secret leaves the scanner
+func WithNoPlaintext() Option {
+	return func(o *options) { o.noPlaintext = true }
+}
+
+// checks found secrets against their providers, which makes network
+// requests
+func WithVerify() Option {
+	return func(o *options) { o.verify = true }
+}
+
+// logs scan progress and warnings to logger; by default nothing is logged
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// scans directories and readers; it is safe to reuse for several scans,
+// one at a time
+type Scanner struct {
+	scanner  *scanner.Scanner
+	scanType scanner.ScanType
+}
+
+// creates a scanner configured by opts
+func NewScanner(opts ...Option) (*Scanner, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// only the file given is read, and the scanner never writes state such
+	// as the cache or an installation hash key
+	cfg := config.DefaultConfig()
+	cfg.NoWrite = true
+	if o.configFile != "" {
+		var err error
+		if cfg, err = config.LoadFile(o.configFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.SelectPatterns(o.rules, o.skipRules); err != nil {
+		return nil, err
+	}
+	for _, p := range o.patterns {
+		if err := cfg.AddPattern(p[0], p[1]); err != nil {
+			return nil, err
+		}
+	}
+	if o.noPlaintext {
+		cfg.NoPlaintext = true
+	}
+	if o.verify {
+		cfg.Verify.Enabled = true
+	}
+	cfg.Logger = o.logger
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	s := &Scanner{scanner: scanner.New(cfg), scanType: scanner.ScanTypeAll}
+	if o.secretsOnly {
+		s.scanType = scanner.ScanTypeSecrets
+	}
+	s.scanner.SetPathFilters(o.include, o.exclude)
+	return s, nil
+}
+
+// scans the files under path. Once ctx is done no more files are
+// started and the findings so far are returned, marked Incomplete, along
+// with ctx's error.
+func (s *Scanner) ScanDir(ctx context.Context, path string) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	results, err := s.scanner.ScanPath(ctx, root, s.scanType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := results.Report(root)
+	result.Duration = time.Since(start)
+	if results.Incomplete {
+		return result, ctx.Err()
+	}
+	return result, nil
+}
+
+// scans the content of r as a file called name; the name decides which
+// detectors apply, e.g. package.json is checked for vulnerable dependencies
+func (s *Scanner) ScanReader(ctx context.Context, name string, r io.Reader) ([]Finding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	results := s.scanner.ScanBlobs(ctx, []scanner.Blob{{Path: name, Content: string(content)}}, s.scanType)
+	if results.Incomplete {
+		return nil, ctx.Err()
+	}
+	return results.Report("").Findings, nil
+}
@@ -0,0 +1,108 @@
+// Package report is the public model of gitguardian's findings, shared by
+// the Go package, the CLI's "findings" output format and the server's
+// scan responses, so each of them gives a finding the same fields:
+//
+//	var r report.Report
+//	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+//		return err
+//	}
+//	if r.HasFindingsAtOrAbove(report.SeverityHigh) {
+//		...
+//	}
+//
+// The types here are stable; the scanner's own are copied into them so
+// its internals can change without breaking callers.
+package report
+
+import "time"
+
+// how serious a finding is
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// orders severities from low, 1, to critical, 4; anything else is 0
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// one issue found by a scan
+type Finding struct {
+	// secret, vulnerability, social, entropy or advisory
+	Type     string   `json:"type"`
+	Severity Severity `json:"severity"`
+	// the rule that matched, e.g. "AWS Access Key"
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+
+	// slash-separated and relative to the scanned directory, or the name
+	// the content was scanned under
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+
+	// the matched text with the secret masked
+	Content string `json:"content,omitempty"`
+	// HMAC-SHA-256 of a matched secret with the secret hash key, for
+	// correlating findings without it
+	SecretHash string `json:"secret_hash,omitempty"`
+	// class of secret: cloud, vcs, database...
+	Category string `json:"category,omitempty"`
+	// the advisory behind a vulnerability, e.g. GHSA-xxxx-xxxx-xxxx
+	AdvisoryID string `json:"advisory_id,omitempty"`
+	// of a vulnerability: listed in the KEV catalog, and its EPSS score
+	KnownExploited bool    `json:"known_exploited,omitempty"`
+	EPSS           float64 `json:"epss,omitempty"`
+	// live or invalid, when verification is enabled
+	Verified string `json:"verified,omitempty"`
+
+	// from the rule: how to rotate or revoke the credential, documentation
+	// on it, and who maintains the rule
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+
+	// the commit and its author, of a finding in git history
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+
+	// identifies the finding across scans, from its file relative to the
+	// scanned directory; the "id" of the CLI's JSON reports
+	Fingerprint string `json:"fingerprint"`
+}
+
+// what a scan found
+type Report struct {
+	Findings     []Finding     `json:"findings"`
+	FilesScanned int           `json:"files_scanned"`
+	Duration     time.Duration `json:"duration"`
+
+	// set when the scan stopped before every file was scanned
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// reports whether any finding is at or above severity
+func (r *Report) HasFindingsAtOrAbove(severity Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.Rank() >= severity.Rank() {
+			return true
+		}
+	}
+	return false
+}
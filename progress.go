@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/scanner"
+)
+
+// how often the progress line is redrawn
+const progressInterval = 200 * time.Millisecond
+
+// draws a single, continuously updated progress line while a scan runs
+type progressBar struct {
+	out   io.Writer
+	start time.Time
+
+	mu   sync.Mutex
+	last scanner.Progress
+
+	stopOnce sync.Once
+	done     chan struct{}
+	drawn    chan struct{}
+}
+
+// reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func startProgress(out io.Writer) *progressBar {
+	p := &progressBar{
+		out:   out,
+		start: time.Now(),
+		done:  make(chan struct{}),
+		drawn: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// records the latest progress; passed to Scanner.SetProgress
+func (p *progressBar) update(progress scanner.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if progress.Files > p.last.Files {
+		p.last = progress
+	}
+}
+
+// clears the progress line, leaving the terminal to the results
+func (p *progressBar) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+		<-p.drawn
+	})
+}
+
+func (p *progressBar) run() {
+	defer close(p.drawn)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			fmt.Fprint(p.out, "\r\033[K")
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			progress := p.last
+			p.mu.Unlock()
+			if progress.Total > 0 {
+				fmt.Fprint(p.out, "\r\033[K"+p.line(progress, time.Since(p.start)))
+			}
+		}
+	}
+}
+
+// renders e.g. "[#####.....] 5120/10240 files  850 files/s  3 findings  ETA 6s"
+func (p *progressBar) line(progress scanner.Progress, elapsed time.Duration) string {
+	const width = 20
+	filled := width * progress.Files / progress.Total
+
+	rate := float64(progress.Files) / elapsed.Seconds()
+	eta := "?"
+	if rate > 0 {
+		remaining := time.Duration(float64(progress.Total-progress.Files) / rate * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("[%s%s] %d/%d files  %.0f files/s  %d findings  ETA %s",
+		strings.Repeat("#", filled), strings.Repeat(".", width-filled),
+		progress.Files, progress.Total, rate, progress.Issues, eta)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+
+	"github.com/JohnnyCannelloni/gitguardian/internal/config"
+)
+
+// the flags of commands that keep a cache or state on disk
+type storageFlags struct {
+	cacheDir *string
+	stateDir *string
+	tempDir  *string
+	noWrite  *bool
+}
+
+func addStorageFlags(fs *flag.FlagSet) storageFlags {
+	return storageFlags{
+		cacheDir: fs.String("cache-dir", "", "Cache directory (default: GITGUARDIAN_CACHE_DIR, cache.dir, or the user cache directory)"),
+		stateDir: fs.String("state-dir", "", "Directory of the findings store (default: GITGUARDIAN_STATE_DIR, state_dir, or the cache directory)"),
+		tempDir:  fs.String("temp-dir", "", "Directory for temporary clones (default: GITGUARDIAN_TMPDIR, temp_dir, or the system temp directory)"),
+		noWrite:  fs.Bool("no-write", false, "Never write the cache, findings store or .gitignore, for read-only filesystems (also GITGUARDIAN_NO_WRITE)"),
+	}
+}
+
+// resolves where the tool may write: the flags first, then the
+// environment, then the configuration
+func (f storageFlags) apply(cfg *config.Config) {
+	applyStorageEnv(cfg)
+	if *f.cacheDir != "" {
+		cfg.Cache.Dir = *f.cacheDir
+	}
+	if *f.stateDir != "" {
+		cfg.StateDir = *f.stateDir
+	}
+	if *f.tempDir != "" {
+		cfg.TempDir = *f.tempDir
+	}
+	if *f.noWrite {
+		cfg.NoWrite = true
+	}
+}
+
+// applies GITGUARDIAN_CACHE_DIR, GITGUARDIAN_STATE_DIR, GITGUARDIAN_TMPDIR
+// and GITGUARDIAN_NO_WRITE over the configuration, so container images can
+// be set up without a config file
+func applyStorageEnv(cfg *config.Config) {
+	if v := os.Getenv("GITGUARDIAN_CACHE_DIR"); v != "" {
+		cfg.Cache.Dir = v
+	}
+	if v := os.Getenv("GITGUARDIAN_STATE_DIR"); v != "" {
+		cfg.StateDir = v
+	}
+	if v := os.Getenv("GITGUARDIAN_TMPDIR"); v != "" {
+		cfg.TempDir = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("GITGUARDIAN_NO_WRITE")); err == nil && v {
+		cfg.NoWrite = true
+	}
+}
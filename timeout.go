@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// the -timeout flag of the commands that scan
+type timeoutFlag struct {
+	value *time.Duration
+}
+
+func addTimeoutFlag(fs *flag.FlagSet) timeoutFlag {
+	var fallback time.Duration
+	if d, err := time.ParseDuration(os.Getenv("GITGUARDIAN_TIMEOUT")); err == nil {
+		fallback = d
+	}
+	return timeoutFlag{
+		value: fs.Duration("timeout", fallback, "Stop scanning after this long and report what was found, e.g. 2m (default: GITGUARDIAN_TIMEOUT, or no limit)"),
+	}
+}
+
+// returns the context a scan runs under: it is done once the timeout
+// passes or the process is interrupted, so an interrupted scan stops
+// cleanly and its partial results are still reported. A second interrupt
+// kills the process as usual.
+func (f timeoutFlag) context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	context.AfterFunc(ctx, stop)
+	if *f.value <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, *f.value)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// explains why a scan under ctx was cut short
+func (f timeoutFlag) reason(ctx context.Context) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return "timed out after " + f.value.String()
+	}
+	return "interrupted"
+}